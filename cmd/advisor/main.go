@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/runes"
+)
+
+// spellTiming is what the predictor needs to know about casting a spell.
+type spellTiming struct {
+	castTime    float64 // seconds, before haste
+	cooldown    float64
+	manaCost    float64
+	dotDuration float64 // debuff of the same name applied on cast
+	dotTicks    int
+}
+
+// advisor recommends actions for game-state snapshots.
+type advisor struct {
+	rotation *apl.CompiledRotation
+	timings  map[string]spellTiming
+	gcd      float64 // seconds
+	maxMana  float64
+	lifeTap  float64 // mana gained per Life Tap
+	tapGlyph bool
+	next     int
+}
+
+// step is one recommended or predicted action.
+type step struct {
+	Action   string  `json:"action"`             // spell name, "wait" or "none"
+	Location string  `json:"location,omitempty"` // rotation entry that acts
+	At       float64 `json:"at"`                 // seconds from the snapshot
+}
+
+// advice is the answer to one snapshot.
+type advice struct {
+	Recommended step   `json:"recommended"`
+	Next        []step `json:"next"`
+	Error       string `json:"error,omitempty"`
+}
+
+func main() {
+	configDir := flag.String("config-dir", "./configs", "Path to config directory")
+	rotationFlag := flag.String("rotation", "", "Rotation file name (defaults to player.yaml value)")
+	stream := flag.Bool("stream", false, "Read one JSON snapshot per line and answer each on one line")
+	jsonOut := flag.Bool("json", false, "Print advice as JSON")
+	next := flag.Int("next", 2, "Number of follow-up actions to predict")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configDir)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	rotationFile := *rotationFlag
+	if rotationFile == "" {
+		rotationFile = cfg.Player.Rotation
+		if rotationFile == "" {
+			rotationFile = "destruction-default.yaml"
+		}
+	}
+	rotationDir := filepath.Join(*configDir, "rotations")
+	rotRaw, err := apl.LoadRotation(rotationDir, rotationFile)
+	if err != nil {
+		log.Fatalf("Failed to load rotation %s: %v", filepath.Join(rotationDir, rotationFile), err)
+	}
+	rotation, err := apl.Compile(rotRaw)
+	if err != nil {
+		log.Fatalf("Failed to compile rotation: %v", err)
+	}
+
+	adv := &advisor{
+		rotation: rotation,
+		timings:  spellTimings(cfg),
+		gcd:      cfg.Constants.GCD.Base,
+		maxMana:  cfg.Player.Stats.MaxMana,
+		lifeTap:  cfg.Spells.LifeTap.ManaBase + cfg.Player.Stats.SpellPower*cfg.Spells.LifeTap.SpellpowerCoefficient,
+		tapGlyph: cfg.Player.HasRune(runes.RuneGlyphOfLifeTap),
+		next:     *next,
+	}
+
+	if *stream {
+		if err := adv.stream(os.Stdin, os.Stdout, *jsonOut); err != nil {
+			log.Fatalf("read: %v", err)
+		}
+		return
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		log.Fatalf("read: %v", err)
+	}
+	var state apl.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Fatalf("parse snapshot: %v", err)
+	}
+	out := adv.advise(state)
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(out)
+		return
+	}
+	fmt.Printf("Recommended: %s\n", formatStep(out.Recommended, false))
+	for _, s := range out.Next {
+		fmt.Printf("Then:        %s\n", formatStep(s, true))
+	}
+}
+
+// stream answers each non-blank input line. A line that does not parse gets
+// an error answer so the feeder stays in sync.
+func (a *advisor) stream(in io.Reader, out io.Writer, jsonOut bool) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var state apl.State
+		var result advice
+		if err := json.Unmarshal([]byte(line), &state); err != nil {
+			result = advice{Recommended: step{Action: apl.ExpectNone}, Error: err.Error()}
+		} else {
+			result = a.advise(state)
+		}
+		if jsonOut {
+			if err := enc.Encode(result); err != nil {
+				return err
+			}
+			continue
+		}
+		if result.Error != "" {
+			fmt.Fprintf(out, "error: %s\n", result.Error)
+			continue
+		}
+		parts := []string{result.Recommended.Action}
+		for _, s := range result.Next {
+			parts = append(parts, fmt.Sprintf("%s +%.1fs", s.Action, s.At))
+		}
+		fmt.Fprintln(out, strings.Join(parts, " | "))
+	}
+	return scanner.Err()
+}
+
+// advise picks the action for state and predicts the following ones by
+// stepping a copy of the snapshot forward.
+func (a *advisor) advise(snapshot apl.State) advice {
+	state := cloneState(snapshot.WithDefaults(a.rotation))
+	var out advice
+	elapsed := 0.0
+	if state.GCD > 0 {
+		elapsed = state.GCD
+		a.advance(&state, state.GCD)
+	}
+	for i := 0; i <= a.next; i++ {
+		s := step{Action: apl.ExpectNone, At: round(elapsed)}
+		sel, ok := a.rotation.Select(&state, func(spell string) bool { return a.castable(&state, spell) })
+		if ok {
+			s.Location = sel.Location
+			s.Action = sel.Spell()
+			if s.Action == "" {
+				s.Action = apl.ExpectWait
+			}
+		}
+		if i == 0 {
+			out.Recommended = s
+		} else {
+			out.Next = append(out.Next, s)
+		}
+		if !ok {
+			break
+		}
+		elapsed += a.apply(&state, sel)
+	}
+	return out
+}
+
+// castable is State.Castable plus a mana check against the spell's cost.
+func (a *advisor) castable(state *apl.State, spell string) bool {
+	if !state.Castable(spell) {
+		return false
+	}
+	cost := a.timings[spell].manaCost
+	return cost <= 0 || a.maxMana <= 0 || state.ResourcePercent("mana")*a.maxMana >= cost
+}
+
+// apply plays sel on state and returns the seconds it took.
+func (a *advisor) apply(state *apl.State, sel apl.Selection) float64 {
+	action := sel.Action
+	switch action.Type {
+	case apl.ActionWait:
+		d := action.Duration.Seconds()
+		a.advance(state, d)
+		return d
+	case apl.ActionWaitUntil:
+		const poll = 0.05
+		waited := 0.0
+		for waited < action.Duration.Seconds() && !action.Until.Eval(state) {
+			a.advance(state, poll)
+			waited += poll
+		}
+		return waited
+	}
+
+	spell := action.Spell
+	timing := a.timings[spell]
+	castTime := timing.castTime
+	if v, ok := state.CastTimes[spell]; ok {
+		castTime = v
+	}
+	d := math.Max(castTime, a.gcd)
+
+	if timing.manaCost > 0 && a.maxMana > 0 {
+		a.setMana(state, state.ResourcePercent("mana")-timing.manaCost/a.maxMana)
+	}
+	if spell == "life_tap" && a.maxMana > 0 {
+		a.setMana(state, state.ResourcePercent("mana")+a.lifeTap/a.maxMana)
+	}
+	a.advance(state, d)
+
+	// Effects land when the cast finishes.
+	if timing.cooldown > 0 {
+		state.Cooldowns[spell] = timing.cooldown
+	}
+	if timing.dotDuration > 0 {
+		state.Debuffs[spell] = apl.AuraState{Remaining: timing.dotDuration, Ticks: timing.dotTicks}
+	}
+	if spell == "life_tap" && a.tapGlyph {
+		state.Buffs["life_tap_buff"] = apl.AuraState{Remaining: runes.GlyphOfLifeTapDurationSec}
+	}
+	for name := range apl.KnownSpells() {
+		state.CastCounts[name]++
+	}
+	state.CastCounts[spell] = 0
+	state.PrevCast = spell
+	return d
+}
+
+func (a *advisor) setMana(state *apl.State, v float64) {
+	v = math.Max(0, math.Min(1, v))
+	state.Mana = &v
+}
+
+// advance moves the snapshot d seconds forward: timers count down and auras
+// that run out are removed.
+func (a *advisor) advance(state *apl.State, d float64) {
+	state.Time += d
+	state.GCD = math.Max(0, state.GCD-d)
+	if state.Remaining != nil {
+		v := math.Max(0, *state.Remaining-d)
+		state.Remaining = &v
+	}
+	for _, auras := range []map[string]apl.AuraState{state.Buffs, state.Debuffs} {
+		for name, aura := range auras {
+			aura.Remaining -= d
+			if aura.Remaining <= 0 {
+				delete(auras, name)
+				continue
+			}
+			if timing, ok := a.timings[name]; ok && timing.dotTicks > 0 && aura.Ticks > 0 {
+				tick := timing.dotDuration / float64(timing.dotTicks)
+				aura.Ticks = int(math.Ceil(aura.Remaining/tick - 1e-9))
+			}
+			auras[name] = aura
+		}
+	}
+	for name, cd := range state.Cooldowns {
+		if cd -= d; cd <= 0 {
+			delete(state.Cooldowns, name)
+			continue
+		}
+		state.Cooldowns[name] = cd
+	}
+}
+
+// cloneState copies the maps the predictor mutates.
+func cloneState(s apl.State) apl.State {
+	out := s
+	out.Buffs = copyMap(s.Buffs)
+	out.Debuffs = copyMap(s.Debuffs)
+	out.Cooldowns = copyMap(s.Cooldowns)
+	out.CastCounts = copyMap(s.CastCounts)
+	out.CastTimes = copyMap(s.CastTimes)
+	return out
+}
+
+func copyMap[V any](m map[string]V) map[string]V {
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+// spellTimings reads cast times, cooldowns, costs and DoT durations from
+// spells.yaml.
+func spellTimings(cfg *config.Config) map[string]spellTiming {
+	s := cfg.Spells
+	return map[string]spellTiming{
+		"immolate":              {castTime: s.Immolate.CastTime, manaCost: s.Immolate.ManaCost, dotDuration: s.Immolate.DotDuration, dotTicks: s.Immolate.DotTicks},
+		"incinerate":            {castTime: s.Incinerate.CastTime, manaCost: s.Incinerate.ManaCost},
+		"chaos_bolt":            {castTime: s.ChaosBolt.CastTime, cooldown: s.ChaosBolt.Cooldown, manaCost: s.ChaosBolt.ManaCost},
+		"conflagrate":           {castTime: s.Conflagrate.CastTime, cooldown: s.Conflagrate.Cooldown, manaCost: s.Conflagrate.ManaCost},
+		"soul_fire":             {castTime: s.SoulFire.CastTime, manaCost: s.SoulFire.ManaCost},
+		"life_tap":              {castTime: s.LifeTap.CastTime, cooldown: s.LifeTap.Cooldown},
+		"shadow_bolt":           {castTime: s.ShadowBolt.CastTime, manaCost: s.ShadowBolt.ManaCost},
+		"shadowburn":            {castTime: s.Shadowburn.CastTime, cooldown: s.Shadowburn.Cooldown, manaCost: s.Shadowburn.ManaCost},
+		"corruption":            {manaCost: s.Corruption.ManaCost, dotDuration: s.Corruption.DotDuration, dotTicks: s.Corruption.DotTicks},
+		"curse_of_agony":        {manaCost: s.CurseOfAgony.ManaCost, dotDuration: s.CurseOfAgony.DotDuration, dotTicks: s.CurseOfAgony.DotTicks},
+		"shadowfury":            {castTime: s.ShadowFury.CastTime, cooldown: s.ShadowFury.Cooldown, manaCost: s.ShadowFury.ManaCost},
+		"shadow_crash":          {castTime: s.ShadowCrash.CastTime, cooldown: s.ShadowCrash.Cooldown, manaCost: s.ShadowCrash.ManaCost},
+		"immolation_aura":       {cooldown: s.ImmolationAura.Cooldown, manaCost: s.ImmolationAura.ManaCost},
+		"curse_of_doom":         {cooldown: s.CurseOfDoom.Cooldown, manaCost: s.CurseOfDoom.ManaCost, dotDuration: s.CurseOfDoom.Duration, dotTicks: 1},
+		"curse_of_the_elements": {dotDuration: 300}, // hardcoded in the engine
+		"inferno":               {castTime: s.Inferno.CastTime, cooldown: s.Inferno.Cooldown, manaCost: s.Inferno.ManaCost},
+	}
+}
+
+func formatStep(s step, offset bool) string {
+	out := s.Action
+	if s.Location != "" {
+		out += " (" + s.Location
+		if offset {
+			out += fmt.Sprintf(", +%.1fs", s.At)
+		}
+		out += ")"
+	}
+	return out
+}
+
+func round(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
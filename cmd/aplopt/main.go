@@ -0,0 +1,579 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/engine"
+	"wotlk-destro-sim/internal/ttest"
+)
+
+// searchRange is the set of candidate values for one rotation variable.
+type searchRange struct {
+	name    string
+	values  []float64
+	current float64
+}
+
+// rangeFlags collects repeated -var name=min:max:step (or name=a,b,c) flags.
+type rangeFlags []string
+
+func (r *rangeFlags) String() string { return strings.Join(*r, " ") }
+
+func (r *rangeFlags) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// sample is the DPS of one candidate on each of the common seeds.
+type sample struct {
+	values []float64
+	dps    []float64
+}
+
+func (s sample) mean() float64 {
+	m, _ := ttest.MeanStderr(s.dps)
+	return m
+}
+
+type optimizer struct {
+	cfg         *config.Config
+	simCfg      engine.SimulationConfig
+	file        *apl.File
+	stats       character.Stats
+	ranges      []searchRange
+	seeds       []int64
+	concurrency int
+	cache       map[string]sample
+	evals       int
+}
+
+func main() {
+	configDir := flag.String("config-dir", "./configs", "Path to config directory")
+	rotationFlag := flag.String("rotation", "", "Rotation file name (defaults to player.yaml value)")
+	iterations := flag.Int("iterations", 0, "Iterations per seed (0 = use player.yaml)")
+	seedBase := flag.Int64("seed-base", 0, "Base RNG seed (0 = random)")
+	seedCount := flag.Int("seeds", 5, "Common seeds every candidate is simulated with during the search")
+	validationSeeds := flag.Int("validation-seeds", 10, "Fresh seeds the winner and the current values are re-simulated with for the reported gain (>=2 for confidence intervals)")
+	method := flag.String("method", "coord", "Search method: coord (coordinate descent) or grid (every combination)")
+	passes := flag.Int("passes", 3, "Maximum coordinate descent passes")
+	concurrency := flag.Int("concurrency", 0, "Concurrent sims (0 = num CPU)")
+	outPath := flag.String("out", "", "Write a copy of the rotation with the best values to this file")
+	var ranges rangeFlags
+	flag.Var(&ranges, "var", "Search range name=min:max:step or name=v1,v2,... (repeatable; default: every numeric variable, 50%-150% of its value in 5 steps)")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configDir)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	rotationFile := *rotationFlag
+	if rotationFile == "" {
+		rotationFile = cfg.Player.Rotation
+		if rotationFile == "" {
+			rotationFile = "destruction-default.yaml"
+		}
+	}
+	rotationDir := filepath.Join(*configDir, "rotations")
+	rotRaw, err := apl.LoadRotation(rotationDir, rotationFile)
+	if err != nil {
+		log.Fatalf("Failed to load rotation %s: %v", filepath.Join(rotationDir, rotationFile), err)
+	}
+	if _, err := apl.Compile(rotRaw); err != nil {
+		log.Fatalf("Failed to compile rotation: %v", err)
+	}
+
+	searchRanges, err := buildRanges(rotRaw.Variables, ranges)
+	if err != nil {
+		log.Fatalf("Search range error: %v", err)
+	}
+
+	simCfg := simulationConfigFromPlayer(cfg)
+	if *iterations > 0 {
+		simCfg.Iterations = *iterations
+	}
+	baseSeed := *seedBase
+	if baseSeed == 0 {
+		baseSeed = time.Now().UnixNano()
+	}
+	if *seedCount < 1 {
+		*seedCount = 1
+	}
+	if *validationSeeds < 1 {
+		*validationSeeds = 1
+	}
+	if *concurrency <= 0 {
+		*concurrency = runtime.NumCPU()
+	}
+
+	// The validation seeds follow the search seeds in the same stream and
+	// never repeat one, so the reported gain is not measured on the samples
+	// that picked the winner.
+	rng := rand.New(rand.NewSource(baseSeed))
+	used := map[int64]bool{}
+	drawSeeds := func(n int) []int64 {
+		out := make([]int64, 0, n)
+		for len(out) < n {
+			s := rng.Int63()
+			if !used[s] {
+				used[s] = true
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	seeds := drawSeeds(*seedCount)
+	freshSeeds := drawSeeds(*validationSeeds)
+
+	opt := &optimizer{
+		cfg:         cfg,
+		simCfg:      simCfg,
+		file:        rotRaw,
+		stats:       statsFromPlayer(cfg),
+		ranges:      searchRanges,
+		seeds:       seeds,
+		concurrency: *concurrency,
+		cache:       map[string]sample{},
+	}
+
+	fmt.Printf("APL Variable Optimizer (%s, base seed %d)\n", *method, baseSeed)
+	fmt.Printf("Rotation: %s\n", rotationFile)
+	fmt.Printf("Seeds: %d search + %d validation x %d iterations, Duration: %.0fs\n", len(seeds), len(freshSeeds), simCfg.Iterations, simCfg.Duration.Seconds())
+	for _, r := range searchRanges {
+		fmt.Printf("  %s: %s (current %s)\n", r.name, formatValues(r.values), formatValue(r.current))
+	}
+	fmt.Println()
+
+	baseline := opt.evaluate([][]float64{opt.currentValues()})[0]
+
+	var best sample
+	switch *method {
+	case "coord":
+		best = opt.coordinateDescent(baseline, *passes)
+	case "grid":
+		best = opt.grid(baseline)
+	default:
+		log.Fatalf("unsupported method %q (use coord|grid)", *method)
+	}
+
+	fmt.Println()
+	fmt.Printf("Simulated %d candidates\n\n", opt.evals)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Rank\t%s\tDPS\n", strings.Join(rangeNames(searchRanges), "\t"))
+	for i, s := range opt.ranked() {
+		if i == 5 {
+			break
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.2f\n", i+1, strings.Join(strings.Split(candidateKey(s.values), ","), "\t"), s.mean())
+	}
+	w.Flush()
+	fmt.Println()
+	fmt.Fprintf(w, "Variable\tCurrent\tBest\n")
+	for i, r := range searchRanges {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.name, formatValue(r.current), formatValue(best.values[i]))
+	}
+	w.Flush()
+	fmt.Println()
+	fmt.Printf("Search (%d seeds, used to pick the winner): current %.2f, best %.2f\n\n", len(seeds), baseline.mean(), best.mean())
+
+	// Re-simulate both on seeds the search never saw; the best search score
+	// is biased upward by having been selected.
+	check := *opt
+	check.seeds = freshSeeds
+	check.cache = map[string]sample{}
+	confirmed := check.evaluate([][]float64{baseline.values, best.values})
+	fmt.Printf("Validation (%d fresh seeds):\n", len(freshSeeds))
+	fmt.Printf("Current DPS: %s\n", formatInterval(confirmed[0].dps))
+	fmt.Printf("Best DPS:    %s\n", formatInterval(confirmed[1].dps))
+	gain := ttest.Paired(confirmed[0].dps, confirmed[1].dps)
+	diffs := make([]float64, len(freshSeeds))
+	for i := range diffs {
+		diffs[i] = confirmed[1].dps[i] - confirmed[0].dps[i]
+	}
+	fmt.Printf("Gain:        %s, p %.4f (paired over fresh seeds)\n", formatInterval(diffs), gain.P)
+
+	if *outPath != "" {
+		if err := writeOptimized(filepath.Join(rotationDir, rotationFile), *outPath, searchRanges, best.values); err != nil {
+			log.Fatalf("Failed to write %s: %v", *outPath, err)
+		}
+		fmt.Printf("\nWrote optimized rotation to %s\n", *outPath)
+	}
+}
+
+// buildRanges parses the -var flags, or derives default ranges for every
+// numeric variable the rotation declares when none are given.
+func buildRanges(vars map[string]any, specs []string) ([]searchRange, error) {
+	var out []searchRange
+	if len(specs) == 0 {
+		names := make([]string, 0, len(vars))
+		for name := range vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			current, ok := numericVariable(vars[name])
+			if !ok || current == 0 {
+				continue
+			}
+			values := make([]float64, 0, 5)
+			for _, factor := range []float64{0.5, 0.75, 1, 1.25, 1.5} {
+				values = append(values, roundValue(current*factor))
+			}
+			out = append(out, searchRange{name: name, values: values, current: current})
+		}
+		if len(out) == 0 {
+			return nil, fmt.Errorf("rotation declares no non-zero numeric variables; pass -var name=min:max:step")
+		}
+		return out, nil
+	}
+
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		name, body, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid -var %q (want name=min:max:step or name=v1,v2,...)", spec)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("variable '%s' given more than once", name)
+		}
+		seen[name] = true
+		raw, declared := vars[name]
+		if !declared {
+			return nil, fmt.Errorf("variable '%s' is not declared under variables:", name)
+		}
+		current, numeric := numericVariable(raw)
+		if !numeric {
+			return nil, fmt.Errorf("variable '%s' is not numeric (%v)", name, raw)
+		}
+		values, err := parseValues(body)
+		if err != nil {
+			return nil, fmt.Errorf("variable '%s': %w", name, err)
+		}
+		out = append(out, searchRange{name: name, values: values, current: current})
+	}
+	return out, nil
+}
+
+func parseValues(body string) ([]float64, error) {
+	if parts := strings.Split(body, ":"); len(parts) == 3 {
+		var bounds [3]float64
+		for i, part := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid range %q", body)
+			}
+			bounds[i] = v
+		}
+		start, stop, step := bounds[0], bounds[1], bounds[2]
+		if step <= 0 {
+			return nil, fmt.Errorf("step must be > 0 (got %g)", step)
+		}
+		if stop < start {
+			return nil, fmt.Errorf("stop must be >= start (start=%g, stop=%g)", start, stop)
+		}
+		var values []float64
+		for i := 0; start+float64(i)*step <= stop+1e-9; i++ {
+			values = append(values, roundValue(start+float64(i)*step))
+		}
+		return values, nil
+	}
+	var values []float64
+	for _, part := range strings.Split(body, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q", part)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func numericVariable(raw any) (float64, bool) {
+	switch v := raw.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func (o *optimizer) currentValues() []float64 {
+	values := make([]float64, len(o.ranges))
+	for i, r := range o.ranges {
+		values[i] = r.current
+	}
+	return values
+}
+
+// ranked returns every simulated candidate, best mean DPS first.
+func (o *optimizer) ranked() []sample {
+	out := make([]sample, 0, len(o.cache))
+	for _, s := range o.cache {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].mean() != out[j].mean() {
+			return out[i].mean() > out[j].mean()
+		}
+		return candidateKey(out[i].values) < candidateKey(out[j].values)
+	})
+	return out
+}
+
+func rangeNames(ranges []searchRange) []string {
+	names := make([]string, len(ranges))
+	for i, r := range ranges {
+		names[i] = r.name
+	}
+	return names
+}
+
+// coordinateDescent sweeps one variable at a time over its range, keeping the
+// others fixed, and repeats until a full pass changes nothing.
+func (o *optimizer) coordinateDescent(start sample, passes int) sample {
+	best := start
+	for pass := 1; pass <= passes; pass++ {
+		changed := false
+		for i, r := range o.ranges {
+			candidates := make([][]float64, len(r.values))
+			for j, v := range r.values {
+				candidate := append([]float64(nil), best.values...)
+				candidate[i] = v
+				candidates[j] = candidate
+			}
+			for _, s := range o.evaluate(candidates) {
+				if s.mean() > best.mean() {
+					best = s
+					changed = true
+				}
+			}
+			fmt.Printf("pass %d %-28s best %-8s %.2f DPS\n", pass, r.name, formatValue(best.values[i]), best.mean())
+		}
+		if !changed {
+			break
+		}
+	}
+	return best
+}
+
+// grid simulates every combination of the search ranges.
+func (o *optimizer) grid(start sample) sample {
+	candidates := [][]float64{nil}
+	for _, r := range o.ranges {
+		next := make([][]float64, 0, len(candidates)*len(r.values))
+		for _, prefix := range candidates {
+			for _, v := range r.values {
+				next = append(next, append(append([]float64(nil), prefix...), v))
+			}
+		}
+		candidates = next
+	}
+	fmt.Printf("grid: %d combinations\n", len(candidates))
+	best := start
+	for _, s := range o.evaluate(candidates) {
+		if s.mean() > best.mean() {
+			best = s
+		}
+	}
+	return best
+}
+
+// evaluate runs every candidate on the common seeds, reusing earlier results.
+func (o *optimizer) evaluate(candidates [][]float64) []sample {
+	type job struct {
+		candidate int
+		seed      int
+	}
+	results := make([]sample, len(candidates))
+	var pending []int
+	for i, values := range candidates {
+		if cached, ok := o.cache[candidateKey(values)]; ok {
+			results[i] = cached
+			continue
+		}
+		results[i] = sample{values: values, dps: make([]float64, len(o.seeds))}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return results
+	}
+
+	rotations := make(map[int]*apl.CompiledRotation, len(pending))
+	for _, i := range pending {
+		rot, err := o.compile(candidates[i])
+		if err != nil {
+			log.Fatalf("Failed to compile candidate %s: %v", candidateKey(candidates[i]), err)
+		}
+		rotations[i] = rot
+	}
+
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	wg.Add(o.concurrency)
+	for w := 0; w < o.concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				char := character.NewCharacter(o.stats)
+				sim := engine.NewSimulator(o.cfg, o.simCfg, rotations[j.candidate], o.seeds[j.seed], false, nil)
+				results[j.candidate].dps[j.seed] = sim.Run(char).TotalDPS
+			}
+		}()
+	}
+	for _, i := range pending {
+		for seed := range o.seeds {
+			jobs <- job{candidate: i, seed: seed}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, i := range pending {
+		o.cache[candidateKey(candidates[i])] = results[i]
+		o.evals++
+	}
+	return results
+}
+
+// compile builds the rotation with the candidate values substituted for the
+// searched variables.
+func (o *optimizer) compile(values []float64) (*apl.CompiledRotation, error) {
+	file := *o.file
+	file.Variables = make(map[string]any, len(o.file.Variables))
+	for name, v := range o.file.Variables {
+		file.Variables[name] = v
+	}
+	for i, r := range o.ranges {
+		file.Variables[r.name] = values[i]
+	}
+	return apl.Compile(&file)
+}
+
+// writeOptimized copies the rotation file to outPath with the searched
+// variables replaced in place, so comments and layout survive.
+func writeOptimized(srcPath, outPath string, ranges []searchRange, values []float64) error {
+	data, err := os.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parse %s: %w", srcPath, err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a YAML mapping", srcPath)
+	}
+	root := doc.Content[0]
+	var vars *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "variables" {
+			vars = root.Content[i+1]
+		}
+	}
+	if vars == nil || vars.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: no variables: mapping", srcPath)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	for i, r := range ranges {
+		var node *yaml.Node
+		for j := 0; j+1 < len(vars.Content); j += 2 {
+			if vars.Content[j].Value == r.name {
+				node = vars.Content[j+1]
+			}
+		}
+		if node == nil || node.Kind != yaml.ScalarNode || node.Line < 1 || node.Line > len(lines) {
+			return fmt.Errorf("%s: variable '%s' is not a plain scalar", srcPath, r.name)
+		}
+		line := lines[node.Line-1]
+		col := node.Column - 1
+		if col < 0 || col > len(line) {
+			return fmt.Errorf("%s:%d: cannot locate value of '%s'", srcPath, node.Line, r.name)
+		}
+		// Keep any trailing comment after the value.
+		rest := line[col:]
+		tail := ""
+		if idx := strings.Index(rest, " #"); idx >= 0 {
+			tail = rest[idx:]
+		}
+		lines[node.Line-1] = line[:col] + formatValue(values[i]) + tail
+	}
+	return os.WriteFile(outPath, []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func candidateKey(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func roundValue(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(roundValue(v), 'f', -1, 64)
+}
+
+func formatValues(values []float64) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = formatValue(v)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// formatInterval renders the mean with a 95% confidence interval over the
+// per-seed samples.
+func formatInterval(samples []float64) string {
+	m, _ := ttest.MeanStderr(samples)
+	if len(samples) < 2 {
+		return fmt.Sprintf("%.2f", m)
+	}
+	half := ttest.HalfWidth(samples, 0.95)
+	return fmt.Sprintf("%.2f ± %.2f (95%% CI %.2f .. %.2f)", m, half, m-half, m+half)
+}
+
+func statsFromPlayer(cfg *config.Config) character.Stats {
+	return character.Stats{
+		Intellect:  cfg.Player.Stats.Intellect,
+		SpellPower: cfg.Player.Stats.SpellPower,
+		CritPct:    cfg.Player.Stats.CritPercent,
+		HastePct:   cfg.Player.Stats.HastePercent,
+		Spirit:     cfg.Player.Stats.Spirit,
+		HitPct:     cfg.Player.Stats.HitPercent,
+		MaxMana:    cfg.Player.Stats.MaxMana,
+	}
+}
+
+func simulationConfigFromPlayer(cfg *config.Config) engine.SimulationConfig {
+	return engine.SimulationConfig{
+		Duration:   time.Duration(cfg.Player.Simulation.DurationSeconds) * time.Second,
+		Iterations: cfg.Player.Simulation.Iterations,
+		IsBoss:     cfg.Player.Target.Type == "boss",
+	}
+}
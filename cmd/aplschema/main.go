@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"wotlk-destro-sim/internal/schema"
+)
+
+func main() {
+	kind := flag.String("kind", "rotation", "Schema to print: rotation (configs/rotations/*.yaml) or player (configs/player.yaml)")
+	outPath := flag.String("out", "", "Write the schema to this file instead of stdout")
+	flag.Parse()
+
+	var doc map[string]any
+	switch *kind {
+	case "rotation":
+		doc = schema.Rotation()
+	case "player":
+		doc = schema.Player()
+	default:
+		log.Fatalf("unknown -kind %q (expected rotation or player)", *kind)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		log.Fatalf("encode schema: %v", err)
+	}
+	data = append(data, '\n')
+	if *outPath == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*outPath, data, 0644); err != nil {
+		log.Fatalf("write %s: %v", *outPath, err)
+	}
+}
@@ -1,31 +1,201 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
 
 	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/engine"
 )
 
+type report struct {
+	Rotation    string              `json:"rotation"`
+	Source      string              `json:"source"`
+	Valid       bool                `json:"valid"`
+	Diagnostics []apl.Diagnostic    `json:"diagnostics"`
+	Tests       []apl.FixtureResult `json:"tests,omitempty"`
+	// TestsSkipped says why -tests ran nothing, e.g. a rotation without a
+	// fixture file. It does not make the rotation invalid.
+	TestsSkipped string `json:"tests_skipped,omitempty"`
+}
+
 func main() {
 	var rotationPath string
+	var jsonOutput bool
+	var strict bool
+	var fromSimC string
+	var toSimC bool
+	var outPath string
+	var runTests bool
 	flag.StringVar(&rotationPath, "rotation", "configs/rotations/destruction-default.yaml", "Path to rotation YAML")
+	flag.BoolVar(&jsonOutput, "json", false, "Print diagnostics as JSON")
+	flag.BoolVar(&strict, "strict", false, "Treat warnings as errors")
+	flag.StringVar(&fromSimC, "from-simc", "", "Read a SimulationCraft-style text APL instead of -rotation and print it as YAML")
+	flag.BoolVar(&toSimC, "to-simc", false, "Print the rotation as a SimulationCraft-style text APL")
+	flag.StringVar(&outPath, "out", "", "Write converted output (-from-simc/-to-simc) to this file instead of stdout")
+	flag.BoolVar(&runTests, "tests", false, "Run the fixtures in <rotation>"+apl.FixtureSuffix+" and fail if any expectation is not met")
 	flag.Parse()
 
-	rotationPath = filepath.Clean(rotationPath)
-	baseDir := filepath.Dir(rotationPath)
-	rel := filepath.Base(rotationPath)
+	converting := fromSimC != "" || toSimC
+	if converting && jsonOutput && outPath == "" {
+		log.Fatalf("-json with -from-simc/-to-simc needs -out for the converted rotation")
+	}
+	// Keep stdout clean for the converted rotation.
+	status := os.Stdout
+	if converting && outPath == "" {
+		status = os.Stderr
+	}
 
-	file, err := apl.LoadRotation(baseDir, rel)
+	var file *apl.File
+	var err error
+	source := filepath.Clean(rotationPath)
+	if fromSimC != "" {
+		source = filepath.Clean(fromSimC)
+		file, err = loadSimC(source)
+	} else {
+		file, err = apl.LoadRotation(filepath.Dir(source), filepath.Base(source))
+	}
 	if err != nil {
+		if jsonOutput {
+			emitJSON(report{
+				Source:      source,
+				Diagnostics: []apl.Diagnostic{{Severity: apl.SeverityError, Message: fmt.Sprintf("failed to load rotation: %v", err)}},
+			})
+			os.Exit(1)
+		}
 		log.Fatalf("failed to load rotation: %v", err)
 	}
 
-	if _, err := apl.Compile(file); err != nil {
-		log.Fatalf("rotation invalid: %v", err)
+	support := engine.APLSupport()
+	diags := apl.Analyze(file, &support)
+	failed := apl.HasErrors(diags) || (strict && len(diags) > 0)
+
+	var tests []apl.FixtureResult
+	var testsSkipped string
+	testsPath := apl.FixturePath(source)
+	if runTests && !apl.HasErrors(diags) {
+		tests, err = runFixtures(file, testsPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			testsSkipped = "no fixtures found at " + testsPath
+		} else if err != nil {
+			diags = append(diags, apl.Diagnostic{Severity: apl.SeverityError, Message: err.Error()})
+			failed = true
+		}
+		for _, t := range tests {
+			if !t.Passed {
+				failed = true
+			}
+		}
+	}
+
+	if jsonOutput {
+		if diags == nil {
+			diags = []apl.Diagnostic{}
+		}
+		emitJSON(report{Rotation: file.Name, Source: source, Valid: !failed, Diagnostics: diags, Tests: tests, TestsSkipped: testsSkipped})
+	} else {
+		for _, d := range diags {
+			fmt.Fprintln(status, d.String())
+		}
+		if len(tests) > 0 {
+			passed := 0
+			for _, t := range tests {
+				fmt.Fprintln(status, t.String())
+				if t.Passed {
+					passed++
+				}
+			}
+			fmt.Fprintf(status, "Fixtures: %d passed, %d failed (%s)\n", passed, len(tests)-passed, testsPath)
+		}
+		if testsSkipped != "" {
+			fmt.Fprintf(status, "Fixtures: skipped, %s\n", testsSkipped)
+		}
+		if !failed {
+			fmt.Fprintf(status, "Rotation '%s' validated successfully (source: %s)\n", file.Name, source)
+		} else {
+			fmt.Fprintf(status, "Rotation '%s' is invalid (source: %s)\n", file.Name, source)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+	if converting {
+		if err := writeConverted(file, toSimC, outPath); err != nil {
+			log.Fatalf("convert rotation: %v", err)
+		}
+	}
+}
+
+func runFixtures(file *apl.File, path string) ([]apl.FixtureResult, error) {
+	fixtures, err := apl.LoadFixtures(path)
+	if err != nil {
+		return nil, err
+	}
+	rot, err := apl.Compile(file)
+	if err != nil {
+		return nil, err
+	}
+	return apl.RunFixtures(rot, fixtures), nil
+}
+
+func loadSimC(path string) (*apl.File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := apl.ParseSimC(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
 	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return file, nil
+}
 
-	fmt.Printf("Rotation '%s' validated successfully (source: %s)\n", file.Name, rotationPath)
+// writeConverted renders the rotation as SimC text (toSimC) or YAML.
+func writeConverted(file *apl.File, toSimC bool, outPath string) error {
+	var out []byte
+	if toSimC {
+		rot, err := apl.Compile(file)
+		if err != nil {
+			return err
+		}
+		text, err := apl.ExportSimC(rot)
+		if err != nil {
+			return err
+		}
+		out = []byte(text)
+	} else {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(file); err != nil {
+			return err
+		}
+		out = buf.Bytes()
+	}
+	if outPath == "" {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+	return os.WriteFile(outPath, out, 0644)
+}
+
+func emitJSON(r report) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		log.Fatalf("failed to encode report: %v", err)
+	}
 }
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/engine"
+	"wotlk-destro-sim/internal/spells"
+	"wotlk-destro-sim/internal/ttest"
+)
+
+// variant is one rotation/profile combination under comparison.
+type variant struct {
+	label    string
+	cfg      *config.Config
+	rotation *apl.CompiledRotation
+	result   *engine.SimulationResult
+}
+
+func main() {
+	configDir := flag.String("config-dir", "./configs", "Path to config directory")
+	rotationsFlag := flag.String("rotations", "", "Comma-separated rotation file names (defaults to each profile's rotation)")
+	profilesFlag := flag.String("profiles", "", "Comma-separated player profile paths in player.yaml format (defaults to <config-dir>/player.yaml)")
+	iterations := flag.Int("iterations", 0, "Iterations (0 = use player.yaml)")
+	seedBase := flag.Int64("seed-base", 0, "Base RNG seed shared by every variant (0 = random)")
+	alpha := flag.Float64("alpha", 0.05, "Significance level; differences with p >= alpha are flagged as within noise")
+	showSpells := flag.Bool("spells", true, "Print per-spell DPS deltas against the baseline")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configDir)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	variants, err := buildVariants(cfg, *configDir, splitList(*rotationsFlag), splitList(*profilesFlag))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if len(variants) < 2 {
+		log.Fatalf("need at least two variants to compare (use -rotations a.yaml,b.yaml and/or -profiles p1.yaml,p2.yaml)")
+	}
+
+	// Every variant shares iterations, duration and base seed so iteration i
+	// sees the same RNG seed everywhere and the runs pair up.
+	simCfg := engine.SimulationConfig{
+		Duration:   time.Duration(cfg.Player.Simulation.DurationSeconds) * time.Second,
+		Iterations: cfg.Player.Simulation.Iterations,
+	}
+	if *iterations > 0 {
+		simCfg.Iterations = *iterations
+	}
+	if simCfg.Iterations < 2 {
+		log.Fatalf("need at least 2 iterations for paired statistics (got %d)", simCfg.Iterations)
+	}
+	baseSeed := *seedBase
+	if baseSeed == 0 {
+		baseSeed = time.Now().UnixNano()
+	}
+
+	fmt.Printf("Paired Comparison (shared seed %d)\n", baseSeed)
+	fmt.Printf("Iterations: %d, Duration: %.0fs\n", simCfg.Iterations, simCfg.Duration.Seconds())
+	fmt.Printf("Baseline: %s\n\n", variants[0].label)
+
+	var wg sync.WaitGroup
+	for _, v := range variants {
+		wg.Add(1)
+		go func(v *variant) {
+			defer wg.Done()
+			vCfg := simCfg
+			vCfg.IsBoss = v.cfg.Player.Target.Type == "boss"
+			char := character.NewCharacter(statsFromPlayer(&v.cfg.Player))
+			sim := engine.NewSimulator(v.cfg, vCfg, v.rotation, baseSeed, false, nil)
+			v.result = sim.Run(char)
+		}(v)
+	}
+	wg.Wait()
+
+	baseline := variants[0]
+	diffs := make(map[*variant]ttest.Diff, len(variants))
+	for _, v := range variants[1:] {
+		diffs[v] = ttest.Paired(baseline.result.IterationDPS, v.result.IterationDPS)
+	}
+
+	ranked := append([]*variant(nil), variants...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].result.TotalDPS > ranked[j].result.TotalDPS
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Rank\tVariant\tDPS\tSE\tΔ vs Baseline\tSE(Δ)\tp\tVerdict\n")
+	for i, v := range ranked {
+		_, se := ttest.MeanStderr(v.result.IterationDPS)
+		if v == baseline {
+			fmt.Fprintf(w, "%d\t%s\t%.2f\t%.2f\t-\t-\t-\tbaseline\n", i+1, v.label, v.result.TotalDPS, se)
+			continue
+		}
+		d := diffs[v]
+		verdict := "significant"
+		if d.P >= *alpha {
+			verdict = "within noise"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%.2f\t%.2f\t%+.2f\t%.2f\t%s\t%s\n",
+			i+1, v.label, v.result.TotalDPS, se, d.Mean, d.Stderr, formatP(d.P), verdict)
+	}
+	w.Flush()
+	fmt.Printf("\nΔ is the mean per-iteration DPS difference on shared seeds; p is a two-sided paired t-test (alpha %.2g).\n", *alpha)
+
+	if *showSpells {
+		for _, v := range variants[1:] {
+			printSpellDeltas(baseline, v)
+		}
+	}
+}
+
+func buildVariants(base *config.Config, configDir string, rotations, profiles []string) ([]*variant, error) {
+	type profile struct {
+		name   string
+		player config.Player
+	}
+	profileList := []profile{{name: "player.yaml", player: base.Player}}
+	if len(profiles) > 0 {
+		profileList = profileList[:0]
+		for _, path := range profiles {
+			player, err := config.LoadPlayer(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load profile: %w", err)
+			}
+			profileList = append(profileList, profile{name: filepath.Base(path), player: *player})
+		}
+	}
+
+	rotationDir := filepath.Join(configDir, "rotations")
+	var out []*variant
+	for _, prof := range profileList {
+		cfg := *base
+		cfg.Player = prof.player
+		talents, err := config.LoadTalents(configDir, prof.player.Talents)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", prof.name, err)
+		}
+		cfg.Talents = *talents
+		if err := cfg.Player.ValidateTalents(&cfg.Talents); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", prof.name, err)
+		}
+		rotationNames := rotations
+		if len(rotationNames) == 0 {
+			name := prof.player.Rotation
+			if name == "" {
+				name = "destruction-default.yaml"
+			}
+			rotationNames = []string{name}
+		}
+		for _, name := range rotationNames {
+			rotRaw, err := apl.LoadRotation(rotationDir, name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load rotation %s: %w", filepath.Join(rotationDir, name), err)
+			}
+			rot, err := apl.Compile(rotRaw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compile rotation %s: %w", name, err)
+			}
+			label := name
+			if len(profileList) > 1 {
+				label = prof.name + " / " + name
+			}
+			vCfg := cfg
+			out = append(out, &variant{label: label, cfg: &vCfg, rotation: rot})
+		}
+	}
+	return out, nil
+}
+
+func formatP(p float64) string {
+	if p < 0.0001 {
+		return "<0.0001"
+	}
+	return fmt.Sprintf("%.4f", p)
+}
+
+// printSpellDeltas shows where a variant's DPS difference comes from, largest
+// absolute change first.
+func printSpellDeltas(baseline, other *variant) {
+	type row struct {
+		label      string
+		baseDPS    float64
+		otherDPS   float64
+		castsDelta float64
+	}
+	perIterDPS := func(r *engine.SimulationResult, stats *engine.SpellStats) float64 {
+		if stats == nil {
+			return 0
+		}
+		return stats.Damage / float64(r.Iterations) / r.Duration.Seconds()
+	}
+	perIterCasts := func(r *engine.SimulationResult, stats *engine.SpellStats) float64 {
+		if stats == nil {
+			return 0
+		}
+		return float64(stats.Casts) / float64(r.Iterations)
+	}
+
+	seen := map[spells.SpellType]bool{}
+	var rows []row
+	for _, r := range []*engine.SimulationResult{baseline.result, other.result} {
+		for spell, stats := range r.SpellBreakdown {
+			if seen[spell] || (stats.Casts == 0 && stats.Damage == 0) {
+				continue
+			}
+			seen[spell] = true
+			base := baseline.result.SpellBreakdown[spell]
+			alt := other.result.SpellBreakdown[spell]
+			rows = append(rows, row{
+				label:      engine.SpellLabel(spell),
+				baseDPS:    perIterDPS(baseline.result, base),
+				otherDPS:   perIterDPS(other.result, alt),
+				castsDelta: perIterCasts(other.result, alt) - perIterCasts(baseline.result, base),
+			})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		di := math.Abs(rows[i].otherDPS - rows[i].baseDPS)
+		dj := math.Abs(rows[j].otherDPS - rows[j].baseDPS)
+		if di == dj {
+			return rows[i].label < rows[j].label
+		}
+		return di > dj
+	})
+
+	fmt.Printf("\nSpell DPS: %s vs %s\n", other.label, baseline.label)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Spell\tBaseline DPS\tVariant DPS\tΔ DPS\tΔ Casts/iter\n")
+	for _, r := range rows {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%+.2f\t%+.2f\n", r.label, r.baseDPS, r.otherDPS, r.otherDPS-r.baseDPS, r.castsDelta)
+	}
+	w.Flush()
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func statsFromPlayer(p *config.Player) character.Stats {
+	return character.Stats{
+		Intellect:  p.Stats.Intellect,
+		SpellPower: p.Stats.SpellPower,
+		CritPct:    p.Stats.CritPercent,
+		HastePct:   p.Stats.HastePercent,
+		Spirit:     p.Stats.Spirit,
+		HitPct:     p.Stats.HitPercent,
+		MaxMana:    p.Stats.MaxMana,
+	}
+}
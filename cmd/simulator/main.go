@@ -17,12 +17,32 @@ import (
 
 func main() {
 	logCombat := flag.Bool("log-combat", false, "Enable combat log mode (forces 1 iteration, 60s duration)")
+	logTrace := flag.Bool("log-trace", false, "Also log APL decisions: chosen entry and why higher-priority entries were skipped (implies -log-combat)")
 	seedBase := flag.Int64("seed-base", 0, "Base RNG seed (0 = random)")
+	outputFormat := flag.String("output", "text", "Result format: text or json")
+	outputFile := flag.String("output-file", "", "Write the json result to this file instead of stdout (requires -output json)")
 	flag.Parse()
+	if *logTrace {
+		*logCombat = true
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		log.Fatalf("Unknown -output %q (want text or json)", *outputFormat)
+	}
+	if *outputFile != "" && *outputFormat != "json" {
+		log.Fatalf("-output-file requires -output json")
+	}
 
-	fmt.Println("WotLK Destruction Warlock Simulator - Phase 3")
-	fmt.Println("==================================================")
-	fmt.Println()
+	// When the json document goes to stdout the progress text is dropped
+	// and the combat log moves to stderr, so stdout holds only the document.
+	jsonToStdout := *outputFormat == "json" && *outputFile == ""
+	var out io.Writer = os.Stdout
+	if jsonToStdout {
+		out = io.Discard
+	}
+
+	fmt.Fprintln(out, "WotLK Destruction Warlock Simulator - Phase 3")
+	fmt.Fprintln(out, "==================================================")
+	fmt.Fprintln(out)
 
 	// Load configuration (now includes player.yaml)
 	cfg, err := config.LoadConfig("./configs")
@@ -57,21 +77,28 @@ func main() {
 
 	char := character.NewCharacter(charStats)
 
-	fmt.Printf("Character: %s (Level %d)\n", cfg.Player.Character.Name, cfg.Player.Character.Level)
-	fmt.Println("Character Stats:")
-	fmt.Printf("  Intellect: %.0f\n", char.Stats.Intellect)
-	fmt.Printf("  Spell Power: %.0f\n", char.Stats.SpellPower)
-	fmt.Printf("  Crit: %.1f%%\n", char.Stats.CritPct)
-	fmt.Printf("  Haste: %.1f%%\n", char.Stats.HastePct)
-	fmt.Printf("  Spirit: %.0f\n", char.Stats.Spirit)
-	fmt.Printf("  Hit: %.1f%%\n", char.Stats.HitPct)
-	fmt.Printf("  Max Mana: %.0f\n", char.Stats.MaxMana)
+	fmt.Fprintf(out, "Character: %s (Level %d)\n", cfg.Player.Character.Name, cfg.Player.Character.Level)
+	fmt.Fprintln(out, "Character Stats:")
+	fmt.Fprintf(out, "  Intellect: %.0f\n", char.Stats.Intellect)
+	fmt.Fprintf(out, "  Spell Power: %.0f\n", char.Stats.SpellPower)
+	fmt.Fprintf(out, "  Crit: %.1f%%\n", char.Stats.CritPct)
+	fmt.Fprintf(out, "  Haste: %.1f%%\n", char.Stats.HastePct)
+	fmt.Fprintf(out, "  Spirit: %.0f\n", char.Stats.Spirit)
+	fmt.Fprintf(out, "  Hit: %.1f%%\n", char.Stats.HitPct)
+	fmt.Fprintf(out, "  Max Mana: %.0f\n", char.Stats.MaxMana)
 	if cfg.Player.Pet.Summon != "" {
-		fmt.Printf("  Pet: %s\n", cfg.Player.Pet.Summon)
+		if cfg.Player.PetSacrificed() {
+			fmt.Fprintf(out, "  Pet: %s (sacrificed)\n", cfg.Player.Pet.Summon)
+		} else {
+			fmt.Fprintf(out, "  Pet: %s\n", cfg.Player.Pet.Summon)
+		}
 	} else {
-		fmt.Println("  Pet: none")
+		fmt.Fprintln(out, "  Pet: none")
+	}
+	if cfg.Player.SelfBuffs.Armor != "" {
+		fmt.Fprintf(out, "  Armor: %s\n", cfg.Player.SelfBuffs.Armor)
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
 
 	// Configure simulation from YAML
 	isBoss := cfg.Player.Target.Type == "boss"
@@ -83,9 +110,12 @@ func main() {
 
 	var logWriter io.Writer
 	if *logCombat {
-		fmt.Println("Combat log mode enabled: forcing 1 iteration; using configured duration.")
+		fmt.Fprintln(out, "Combat log mode enabled: forcing 1 iteration; using configured duration.")
 		simConfig.Iterations = 1
 		logWriter = os.Stdout
+		if jsonToStdout {
+			logWriter = os.Stderr
+		}
 	}
 
 	baseSeed := *seedBase
@@ -93,22 +123,52 @@ func main() {
 		baseSeed = time.Now().UnixNano()
 	}
 
-	fmt.Printf("Simulation Config:\n")
-	fmt.Printf("  Fight Duration: %.0f seconds\n", simConfig.Duration.Seconds())
-	fmt.Printf("  Iterations: %d\n", simConfig.Iterations)
-	fmt.Printf("  Target: %s (Level %d)\n",
+	fmt.Fprintf(out, "Simulation Config:\n")
+	fmt.Fprintf(out, "  Fight Duration: %.0f seconds\n", simConfig.Duration.Seconds())
+	fmt.Fprintf(out, "  Iterations: %d\n", simConfig.Iterations)
+	fmt.Fprintf(out, "  Target: %s (Level %d)\n",
 		map[bool]string{true: "Boss", false: "Equal Level"}[simConfig.IsBoss],
 		cfg.Player.Target.Level)
-	fmt.Printf("  Base Seed: %d\n", baseSeed)
-	fmt.Println()
+	fmt.Fprintf(out, "  Base Seed: %d\n", baseSeed)
+	fmt.Fprintln(out)
 
-	fmt.Println("Running simulation...")
-	fmt.Println()
+	fmt.Fprintln(out, "Running simulation...")
+	fmt.Fprintln(out)
 
 	// Create and run simulator
 	sim := engine.NewSimulator(cfg, simConfig, compiledRotation, baseSeed, *logCombat, logWriter)
+	sim.TraceAPL = *logTrace
 	result := sim.Run(char)
 
-	// Print results
-	result.PrintResults()
+	if *outputFormat == "text" {
+		result.PrintResults()
+		return
+	}
+	report := engine.ReportInput{
+		Rotation:     compiledRotation.Name,
+		RotationFile: rotationFile,
+		Seed:         baseSeed,
+		Stats:        char.Stats,
+	}
+	equipped := cfg.Player.MysticEnchants.Equipped
+	for _, group := range [][]string{equipped.Legendary, equipped.Epic, equipped.Rare} {
+		report.Runes = append(report.Runes, group...)
+	}
+	if *outputFile == "" {
+		if err := result.WriteJSON(os.Stdout, report); err != nil {
+			log.Fatalf("Failed to write json result: %v", err)
+		}
+		return
+	}
+	f, err := os.Create(*outputFile)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", *outputFile, err)
+	}
+	if err := result.WriteJSON(f, report); err != nil {
+		log.Fatalf("Failed to write %s: %v", *outputFile, err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalf("Failed to write %s: %v", *outputFile, err)
+	}
+	fmt.Printf("Results written to %s\n", *outputFile)
 }
@@ -18,7 +18,9 @@ import (
 
 	"wotlk-destro-sim/internal/apl"
 	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/engine"
 	"wotlk-destro-sim/internal/runes"
+	"wotlk-destro-sim/internal/schema"
 )
 
 //go:embed static/index.html
@@ -28,7 +30,10 @@ type playerResponse struct {
 	Player  config.Player `json:"player"`
 	Options struct {
 		Rotations []string                   `json:"rotations"`
+		Talents   []string                   `json:"talents"`
 		Pets      []string                   `json:"pets"`
+		PetModes  []string                   `json:"petModes"`
+		Armors    []string                   `json:"armors"`
 		Runes     map[string][]string        `json:"runes"`
 		Limits    config.MysticEnchantConfig `json:"limits"`
 	} `json:"options"`
@@ -56,28 +61,38 @@ type rotationDTO struct {
 	Imports     []string       `json:"imports"`
 	Variables   map[string]any `json:"variables"`
 	Rotation    []actionDTO    `json:"rotation"`
+
+	ActionLists map[string][]actionDTO `json:"action_lists,omitempty"`
 }
 
 type actionDTO struct {
 	Action          string        `json:"action"`
 	Spell           string        `json:"spell,omitempty"`
 	Item            string        `json:"item,omitempty"`
+	List            string        `json:"list,omitempty"`
+	Variable        string        `json:"variable,omitempty"`
+	Value           any           `json:"value,omitempty"`
 	DurationSeconds float64       `json:"duration_seconds,omitempty"`
 	Tags            []string      `json:"tags,omitempty"`
 	Steps           []actionDTO   `json:"steps,omitempty"`
 	When            *conditionDTO `json:"when,omitempty"`
+	Until           *conditionDTO `json:"until,omitempty"`
+	TimeoutSeconds  float64       `json:"timeout_seconds,omitempty"`
+	ResetWhen       *conditionDTO `json:"reset_when,omitempty"`
 }
 
 type conditionDTO struct {
-	Type string `json:"type"` // all, any, not, buff_active, debuff_active, dot_remaining, cooldown_ready, cooldown_remaining, resource_percent, charges, true, false
+	Type string `json:"type"` // all, any, not, buff_active, debuff_active, dot_remaining, cooldown_ready, cooldown_remaining, resource_percent, charges, time_elapsed, time_remaining, target_health_percent, cast_time, gcd_remaining, last_cast, casts_since, ticks_remaining, variable, expr, true, false
 
 	Children []conditionDTO `json:"children,omitempty"` // for all/any/not
 
 	Buff   string `json:"buff,omitempty"`
 	Debuff string `json:"debuff,omitempty"`
-	Spell  string `json:"spell,omitempty"` // dot_remaining or cooldown names
+	Spell  string `json:"spell,omitempty"` // dot_remaining, cooldown and cast_time/last_cast/casts_since names
 
-	Resource string `json:"resource,omitempty"`
+	Resource string   `json:"resource,omitempty"`
+	Variable string   `json:"variable,omitempty"` // for variable
+	Eq       *float64 `json:"eq,omitempty"`
 
 	MinRemainingSeconds *float64 `json:"min_remaining_seconds,omitempty"`
 	MaxRemainingSeconds *float64 `json:"max_remaining_seconds,omitempty"`
@@ -91,6 +106,8 @@ type conditionDTO struct {
 	LteCharges *int `json:"lte_charges,omitempty"`
 	GtCharges  *int `json:"gt_charges,omitempty"`
 	GteCharges *int `json:"gte_charges,omitempty"`
+
+	Expr string `json:"expr,omitempty"` // for expr
 }
 
 func main() {
@@ -120,6 +137,8 @@ func main() {
 	})
 
 	http.HandleFunc("/api/identifiers", handleIdentifiers)
+	http.HandleFunc("/api/schema/rotation", handleSchema(schema.Rotation))
+	http.HandleFunc("/api/schema/player", handleSchema(schema.Player))
 	http.HandleFunc("/api/rotations", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
 			handleListRotations(w, r, *configDir)
@@ -171,7 +190,14 @@ func handleGetPlayer(w http.ResponseWriter, r *http.Request, configDir string) {
 		http.Error(w, fmt.Sprintf("list rotations: %v", err), http.StatusInternalServerError)
 		return
 	}
-	resp.Options.Pets = []string{"imp", "none"}
+	resp.Options.Talents, err = listTalentProfiles(configDir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("list talent profiles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	resp.Options.Pets = engine.SupportedPets()
+	resp.Options.PetModes = []string{config.PetModeActive, config.PetModeSacrificed}
+	resp.Options.Armors = []string{config.ArmorNone, config.ArmorFelArmor}
 	resp.Options.Runes = groupRunesByRarity()
 	resp.Options.Limits = cfg.Player.MysticEnchants
 
@@ -207,8 +233,7 @@ func handleSavePlayer(w http.ResponseWriter, r *http.Request, configDir string)
 }
 
 func validatePlayerPayload(p config.Player, configDir string) error {
-	allowedPets := []string{"imp", "none"}
-	if p.Pet.Summon != "" && !slices.Contains(allowedPets, strings.ToLower(p.Pet.Summon)) {
+	if p.Pet.Summon != "" && !slices.Contains(engine.SupportedPets(), strings.ToLower(p.Pet.Summon)) {
 		return fmt.Errorf("invalid pet: %s", p.Pet.Summon)
 	}
 	p.Pet.Summon = strings.ToLower(p.Pet.Summon)
@@ -221,11 +246,41 @@ func validatePlayerPayload(p config.Player, configDir string) error {
 		return fmt.Errorf("rotation not found: %s", p.Rotation)
 	}
 
+	profiles, err := listTalentProfiles(configDir)
+	if err != nil {
+		return fmt.Errorf("list talent profiles: %w", err)
+	}
+	if p.Talents != "" && !slices.Contains(profiles, p.Talents) {
+		return fmt.Errorf("talent profile not found: %s", p.Talents)
+	}
+
 	cfg := config.Config{Player: p}
 	if err := cfg.Player.Validate(); err != nil {
 		return err
 	}
-	return nil
+	talents, err := config.LoadTalents(configDir, p.Talents)
+	if err != nil {
+		return fmt.Errorf("load talents: %w", err)
+	}
+	return cfg.Player.ValidateTalents(talents)
+}
+
+// listTalentProfiles lists talents.yaml and the talents-*.yaml profiles in
+// configDir.
+func listTalentProfiles(configDir string) ([]string, error) {
+	entries, err := os.ReadDir(configDir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && (name == "talents.yaml" || strings.HasPrefix(name, "talents-") && strings.HasSuffix(name, ".yaml")) {
+			files = append(files, name)
+		}
+	}
+	slices.Sort(files)
+	return files, nil
 }
 
 func listRotationFiles(dir string) ([]string, error) {
@@ -239,6 +294,9 @@ func listRotationFiles(dir string) ([]string, error) {
 			continue
 		}
 		name := e.Name()
+		if strings.HasSuffix(name, apl.FixtureSuffix) {
+			continue
+		}
 		if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
 			files = append(files, name)
 		}
@@ -274,6 +332,15 @@ func handleIdentifiers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+// handleSchema serves a JSON Schema document; the rotation editor builds its
+// condition choices from the rotation schema.
+func handleSchema(build func() map[string]any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+		json.NewEncoder(w).Encode(build())
+	}
+}
+
 func collectKeys(m map[string]struct{}) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
@@ -359,6 +426,20 @@ func rotationFileToDTO(f *apl.File) (*rotationDTO, error) {
 		}
 		dto.Rotation = append(dto.Rotation, *a)
 	}
+	for name, actions := range f.ActionLists {
+		if dto.ActionLists == nil {
+			dto.ActionLists = map[string][]actionDTO{}
+		}
+		list := []actionDTO{}
+		for _, act := range actions {
+			a, err := actionToDTO(act)
+			if err != nil {
+				return nil, fmt.Errorf("action list %s: %w", name, err)
+			}
+			list = append(list, *a)
+		}
+		dto.ActionLists[name] = list
+	}
 	return dto, nil
 }
 
@@ -367,9 +448,13 @@ func actionToDTO(a apl.ActionDefinition) (*actionDTO, error) {
 		Action:          a.Action,
 		Spell:           a.Spell,
 		Item:            a.Item,
+		List:            a.List,
+		Variable:        a.Variable,
+		Value:           a.Value,
 		DurationSeconds: a.DurationSeconds,
 		Tags:            a.Tags,
 	}
+	dto.TimeoutSeconds = a.TimeoutSeconds
 	if a.When != nil {
 		c, err := conditionToDTO(a.When.Node())
 		if err != nil {
@@ -377,6 +462,20 @@ func actionToDTO(a apl.ActionDefinition) (*actionDTO, error) {
 		}
 		dto.When = c
 	}
+	if a.Until != nil {
+		c, err := conditionToDTO(a.Until.Node())
+		if err != nil {
+			return nil, err
+		}
+		dto.Until = c
+	}
+	if a.ResetWhen != nil {
+		c, err := conditionToDTO(a.ResetWhen.Node())
+		if err != nil {
+			return nil, err
+		}
+		dto.ResetWhen = c
+	}
 	for _, step := range a.Steps {
 		child, err := actionToDTO(step)
 		if err != nil {
@@ -397,6 +496,8 @@ func conditionToDTO(node *yaml.Node) (*conditionDTO, error) {
 			return &conditionDTO{Type: "true"}, nil
 		case "false":
 			return &conditionDTO{Type: "false"}, nil
+		default:
+			return &conditionDTO{Type: "expr", Expr: node.Value}, nil
 		}
 	}
 	if node.Kind != yaml.MappingNode || len(node.Content) == 0 {
@@ -464,6 +565,52 @@ func conditionToDTO(node *yaml.Node) (*conditionDTO, error) {
 		dto.GtSeconds = parseOptFloat(m, "gt")
 		dto.GteSeconds = parseOptFloat(m, "gte")
 		return dto, nil
+	case "time_elapsed", "time_remaining":
+		m := mapNodeToMap(node.Content[1])
+		dto := &conditionDTO{Type: key}
+		dto.LtSeconds = parseOptFloat(m, "lt_seconds")
+		dto.LteSeconds = parseOptFloat(m, "lte_seconds")
+		dto.GtSeconds = parseOptFloat(m, "gt_seconds")
+		dto.GteSeconds = parseOptFloat(m, "gte_seconds")
+		return dto, nil
+	case "target_health_percent":
+		m := mapNodeToMap(node.Content[1])
+		dto := &conditionDTO{Type: "target_health_percent"}
+		dto.LtSeconds = parseOptFloat(m, "lt")
+		dto.LteSeconds = parseOptFloat(m, "lte")
+		dto.GtSeconds = parseOptFloat(m, "gt")
+		dto.GteSeconds = parseOptFloat(m, "gte")
+		return dto, nil
+	case "cast_time", "gcd_remaining":
+		m := mapNodeToMap(node.Content[1])
+		dto := &conditionDTO{Type: key, Spell: m["spell"]}
+		dto.LtSeconds = parseOptFloat(m, "lt_seconds")
+		dto.LteSeconds = parseOptFloat(m, "lte_seconds")
+		dto.GtSeconds = parseOptFloat(m, "gt_seconds")
+		dto.GteSeconds = parseOptFloat(m, "gte_seconds")
+		return dto, nil
+	case "last_cast":
+		m := mapNodeToMap(node.Content[1])
+		return &conditionDTO{Type: "last_cast", Spell: m["spell"]}, nil
+	case "casts_since", "ticks_remaining":
+		m := mapNodeToMap(node.Content[1])
+		dto := &conditionDTO{Type: key, Spell: m["spell"], Debuff: m["debuff"]}
+		dto.LtCharges = parseOptInt(m, "lt")
+		dto.LteCharges = parseOptInt(m, "lte")
+		dto.GtCharges = parseOptInt(m, "gt")
+		dto.GteCharges = parseOptInt(m, "gte")
+		return dto, nil
+	case "variable":
+		m := mapNodeToMap(node.Content[1])
+		dto := &conditionDTO{Type: "variable", Variable: m["name"]}
+		dto.Eq = parseOptFloat(m, "eq")
+		dto.LtSeconds = parseOptFloat(m, "lt")
+		dto.LteSeconds = parseOptFloat(m, "lte")
+		dto.GtSeconds = parseOptFloat(m, "gt")
+		dto.GteSeconds = parseOptFloat(m, "gte")
+		return dto, nil
+	case "expr":
+		return &conditionDTO{Type: "expr", Expr: node.Content[1].Value}, nil
 	case "charges":
 		m := mapNodeToMap(node.Content[1])
 		dto := &conditionDTO{Type: "charges", Buff: m["buff"]}
@@ -527,6 +674,20 @@ func dtoToRotationFile(dto *rotationDTO) (*apl.File, error) {
 		}
 		file.Rotation = append(file.Rotation, *act)
 	}
+	for name, actions := range dto.ActionLists {
+		if file.ActionLists == nil {
+			file.ActionLists = map[string][]apl.ActionDefinition{}
+		}
+		list := []apl.ActionDefinition{}
+		for _, a := range actions {
+			act, err := dtoToAction(a)
+			if err != nil {
+				return nil, fmt.Errorf("action list %s: %w", name, err)
+			}
+			list = append(list, *act)
+		}
+		file.ActionLists[name] = list
+	}
 	return file, nil
 }
 
@@ -535,9 +696,13 @@ func dtoToAction(a actionDTO) (*apl.ActionDefinition, error) {
 		Action:          a.Action,
 		Spell:           a.Spell,
 		Item:            a.Item,
+		List:            a.List,
+		Variable:        a.Variable,
+		Value:           a.Value,
 		DurationSeconds: a.DurationSeconds,
 		Tags:            a.Tags,
 	}
+	act.TimeoutSeconds = a.TimeoutSeconds
 	if a.When != nil {
 		node, err := conditionDTOToNode(a.When)
 		if err != nil {
@@ -545,6 +710,20 @@ func dtoToAction(a actionDTO) (*apl.ActionDefinition, error) {
 		}
 		act.When = apl.NewConditionNode(node)
 	}
+	if a.Until != nil {
+		node, err := conditionDTOToNode(a.Until)
+		if err != nil {
+			return nil, err
+		}
+		act.Until = apl.NewConditionNode(node)
+	}
+	if a.ResetWhen != nil {
+		node, err := conditionDTOToNode(a.ResetWhen)
+		if err != nil {
+			return nil, err
+		}
+		act.ResetWhen = apl.NewConditionNode(node)
+	}
 	for _, step := range a.Steps {
 		child, err := dtoToAction(step)
 		if err != nil {
@@ -617,19 +796,65 @@ func conditionDTOToNode(c *conditionDTO) (*yaml.Node, error) {
 		return mapToNode("resource_percent", mapAnyToNode(m)), nil
 	case "charges":
 		m := map[string]any{"buff": c.Buff}
-		if c.LtCharges != nil {
-			m["lt"] = *c.LtCharges
+		addCountComparators(m, c)
+		return mapToNode("charges", mapAnyToNode(m)), nil
+	case "cast_time":
+		m := map[string]any{"spell": c.Spell}
+		addComparators(m, c)
+		return mapToNode("cast_time", mapAnyToNode(m)), nil
+	case "gcd_remaining":
+		m := map[string]any{}
+		addComparators(m, c)
+		return mapToNode("gcd_remaining", mapAnyToNode(m)), nil
+	case "last_cast":
+		return mapToNode("last_cast", mapAnyToNode(map[string]any{"spell": c.Spell})), nil
+	case "casts_since":
+		m := map[string]any{"spell": c.Spell}
+		addCountComparators(m, c)
+		return mapToNode("casts_since", mapAnyToNode(m)), nil
+	case "ticks_remaining":
+		m := map[string]any{"debuff": c.Debuff}
+		addCountComparators(m, c)
+		return mapToNode("ticks_remaining", mapAnyToNode(m)), nil
+	case "time_elapsed", "time_remaining":
+		m := map[string]any{}
+		addComparators(m, c)
+		return mapToNode(c.Type, mapAnyToNode(m)), nil
+	case "target_health_percent":
+		m := map[string]any{}
+		if c.LtSeconds != nil {
+			m["lt"] = *c.LtSeconds
 		}
-		if c.LteCharges != nil {
-			m["lte"] = *c.LteCharges
+		if c.LteSeconds != nil {
+			m["lte"] = *c.LteSeconds
 		}
-		if c.GtCharges != nil {
-			m["gt"] = *c.GtCharges
+		if c.GtSeconds != nil {
+			m["gt"] = *c.GtSeconds
 		}
-		if c.GteCharges != nil {
-			m["gte"] = *c.GteCharges
+		if c.GteSeconds != nil {
+			m["gte"] = *c.GteSeconds
 		}
-		return mapToNode("charges", mapAnyToNode(m)), nil
+		return mapToNode("target_health_percent", mapAnyToNode(m)), nil
+	case "variable":
+		m := map[string]any{"name": c.Variable}
+		if c.Eq != nil {
+			m["eq"] = *c.Eq
+		}
+		if c.LtSeconds != nil {
+			m["lt"] = *c.LtSeconds
+		}
+		if c.LteSeconds != nil {
+			m["lte"] = *c.LteSeconds
+		}
+		if c.GtSeconds != nil {
+			m["gt"] = *c.GtSeconds
+		}
+		if c.GteSeconds != nil {
+			m["gte"] = *c.GteSeconds
+		}
+		return mapToNode("variable", mapAnyToNode(m)), nil
+	case "expr":
+		return mapToNode("expr", &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Value: c.Expr}), nil
 	default:
 		return nil, fmt.Errorf("unsupported condition type %s", c.Type)
 	}
@@ -650,6 +875,21 @@ func addComparators(m map[string]any, c *conditionDTO) {
 	}
 }
 
+func addCountComparators(m map[string]any, c *conditionDTO) {
+	if c.LtCharges != nil {
+		m["lt"] = *c.LtCharges
+	}
+	if c.LteCharges != nil {
+		m["lte"] = *c.LteCharges
+	}
+	if c.GtCharges != nil {
+		m["gt"] = *c.GtCharges
+	}
+	if c.GteCharges != nil {
+		m["gte"] = *c.GteCharges
+	}
+}
+
 func mapAnyToNode(m map[string]any) *yaml.Node {
 	node := &yaml.Node{Kind: yaml.MappingNode}
 	for k, v := range m {
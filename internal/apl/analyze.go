@@ -0,0 +1,805 @@
+package apl
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity classifies an analysis finding.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is one finding reported by Analyze.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Location string   `json:"location,omitempty"` // e.g. "rotation[3]" or "action_lists.opener[0]"
+	Message  string   `json:"message"`
+}
+
+// Support lists the identifiers the simulator actually implements. The APL
+// accepts everything in names.go; names missing here compile but do nothing.
+type Support struct {
+	Spells    map[string]struct{}
+	Buffs     map[string]struct{}
+	Debuffs   map[string]struct{}
+	Resources map[string]struct{}
+}
+
+// HasErrors reports whether any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Analyze compiles file and looks for rules that can never fire, contradictory
+// conditions, variable mistakes and names the engine ignores. support may be
+// nil to skip the ignored-name check.
+func Analyze(file *File, support *Support) []Diagnostic {
+	if file == nil {
+		return []Diagnostic{{Severity: SeverityError, Message: "nil rotation file"}}
+	}
+	a := &analyzer{support: support}
+	a.checkVariables(file)
+
+	rot, err := Compile(file)
+	if err != nil {
+		a.add(SeverityError, "", err.Error())
+		return a.sorted()
+	}
+	a.checkList("rotation", rot.Actions)
+	for _, name := range sortedListNames(rot.Lists) {
+		a.checkList("action_lists."+name, rot.Lists[name])
+	}
+	a.checkList("action_lists."+PrecombatList, rot.Precombat)
+	a.checkList("action_lists."+PetList, rot.Pet)
+	for idx, phase := range rot.Phases {
+		a.checkCondition(fmt.Sprintf("phases[%d]", idx), phase.Condition)
+	}
+	return a.sorted()
+}
+
+type analyzer struct {
+	support *Support
+	diags   []Diagnostic
+}
+
+func (a *analyzer) add(sev Severity, loc, format string, args ...any) {
+	a.diags = append(a.diags, Diagnostic{Severity: sev, Location: loc, Message: fmt.Sprintf(format, args...)})
+}
+
+func (a *analyzer) sorted() []Diagnostic {
+	sort.SliceStable(a.diags, func(i, j int) bool {
+		if a.diags[i].Severity != a.diags[j].Severity {
+			return a.diags[i].Severity == SeverityError
+		}
+		return false
+	})
+	return a.diags
+}
+
+func sortedListNames(lists map[string][]*Action) []string {
+	names := make([]string, 0, len(lists))
+	for name := range lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// --- reachability ---
+
+func (a *analyzer) checkList(prefix string, actions []*Action) {
+	unconditional := map[string]string{} // spell -> location of the unconditional cast
+	conditional := map[string][]int{}    // spell -> indexes of earlier conditional casts
+	blockedBy := ""
+	for idx, action := range actions {
+		if action == nil {
+			continue
+		}
+		loc := fmt.Sprintf("%s[%d]", prefix, idx)
+		if blockedBy != "" {
+			a.add(SeverityWarning, loc, "unreachable: %s always ends the decision", blockedBy)
+			continue
+		}
+		a.checkAction(loc, action)
+
+		always := isAlwaysTrue(action.Condition)
+		switch action.Type {
+		case ActionCastSpell:
+			if first, ok := unconditional[action.Spell]; ok {
+				a.add(SeverityWarning, loc, "never fires: shadowed by unconditional cast of %s at %s", action.Spell, first)
+			} else if always {
+				unconditional[action.Spell] = loc
+			} else {
+				for _, prev := range conditional[action.Spell] {
+					if implies(action.Condition, actions[prev].Condition) {
+						a.add(SeverityWarning, loc, "never fires: whenever its condition holds, the cast of %s at %s[%d] fires first", action.Spell, prefix, prev)
+						break
+					}
+				}
+				if action.Interrupt == nil {
+					conditional[action.Spell] = append(conditional[action.Spell], idx)
+				}
+			}
+		case ActionWait:
+			if always {
+				blockedBy = "unconditional wait at " + loc
+			}
+		case ActionRunList:
+			if always {
+				blockedBy = "unconditional run_action_list at " + loc
+			}
+		}
+	}
+}
+
+func (a *analyzer) checkAction(loc string, action *Action) {
+	if _, ok := action.Condition.(falseCondition); ok {
+		a.add(SeverityWarning, loc, "never fires: condition is always false")
+	}
+	a.checkCondition(loc, action.Condition)
+	a.checkCondition(loc+".until", action.Until)
+	a.checkCondition(loc+".reset_when", action.Reset)
+	a.checkCondition(loc+".interrupt_if", action.Interrupt)
+	switch action.Type {
+	case ActionCastSpell:
+		a.checkName(loc, "spell", action.Spell)
+	case ActionCancelBuff:
+		a.checkName(loc, "buff", action.Buff)
+	}
+	for stepIdx, step := range action.Steps {
+		if step != nil {
+			a.checkAction(fmt.Sprintf("%s.steps[%d]", loc, stepIdx), step)
+		}
+	}
+}
+
+func isAlwaysTrue(c Condition) bool {
+	switch v := c.(type) {
+	case nil, trueCondition:
+		return true
+	case allCondition:
+		for _, child := range v.children {
+			if !isAlwaysTrue(child) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// implies reports whether c holding guarantees that d holds. It only follows
+// all/any structure, identical subtrees and nested ranges of the same
+// predicate, so false means "not shown" rather than "does not imply".
+func implies(c, d Condition) bool {
+	if isAlwaysTrue(d) || reflect.DeepEqual(c, d) {
+		return true
+	}
+	if all, ok := d.(allCondition); ok {
+		for _, child := range all.children {
+			if !implies(c, child) {
+				return false
+			}
+		}
+		return true
+	}
+	if any, ok := c.(anyCondition); ok && len(any.children) > 0 {
+		for _, child := range any.children {
+			if !implies(child, d) {
+				return false
+			}
+		}
+		return true
+	}
+	if any, ok := d.(anyCondition); ok {
+		for _, child := range any.children {
+			if implies(c, child) {
+				return true
+			}
+		}
+	}
+	if all, ok := c.(allCondition); ok {
+		for _, child := range all.children {
+			if implies(child, d) {
+				return true
+			}
+		}
+	}
+	if reflect.TypeOf(c) != reflect.TypeOf(d) {
+		return false
+	}
+	cs, civ, cok := conditionRange(c)
+	ds, div, dok := conditionRange(d)
+	return cok && dok && cs == ds && div.contains(civ)
+}
+
+// --- conditions ---
+
+// interval is the set of values a comparator predicate accepts.
+type interval struct {
+	lo, hi         float64
+	loIncl, hiIncl bool
+}
+
+func fullInterval() interval {
+	return interval{lo: math.Inf(-1), hi: math.Inf(1), loIncl: true, hiIncl: true}
+}
+
+func (iv interval) empty() bool {
+	return iv.lo > iv.hi || (iv.lo == iv.hi && !(iv.loIncl && iv.hiIncl))
+}
+
+func (iv interval) intersect(o interval) interval {
+	out := iv
+	if o.lo > out.lo || (o.lo == out.lo && !o.loIncl) {
+		out.lo, out.loIncl = o.lo, o.loIncl
+	}
+	if o.hi < out.hi || (o.hi == out.hi && !o.hiIncl) {
+		out.hi, out.hiIncl = o.hi, o.hiIncl
+	}
+	return out
+}
+
+// contains reports whether every value o accepts is also in iv.
+func (iv interval) contains(o interval) bool {
+	if o.empty() {
+		return true
+	}
+	loOK := iv.lo < o.lo || (iv.lo == o.lo && (iv.loIncl || !o.loIncl))
+	hiOK := iv.hi > o.hi || (iv.hi == o.hi && (iv.hiIncl || !o.hiIncl))
+	return loOK && hiOK
+}
+
+func floatBounds(lt, lte, gt, gte *float64) interval {
+	iv := fullInterval()
+	if lt != nil {
+		iv = iv.intersect(interval{lo: math.Inf(-1), hi: *lt, loIncl: true})
+	}
+	if lte != nil {
+		iv = iv.intersect(interval{lo: math.Inf(-1), hi: *lte, loIncl: true, hiIncl: true})
+	}
+	if gt != nil {
+		iv = iv.intersect(interval{lo: *gt, hi: math.Inf(1), hiIncl: true})
+	}
+	if gte != nil {
+		iv = iv.intersect(interval{lo: *gte, hi: math.Inf(1), loIncl: true, hiIncl: true})
+	}
+	return iv
+}
+
+func secondsPtr(d *time.Duration) *float64 {
+	if d == nil {
+		return nil
+	}
+	s := d.Seconds()
+	return &s
+}
+
+func durationBounds(lt, lte, gt, gte *time.Duration) interval {
+	return floatBounds(secondsPtr(lt), secondsPtr(lte), secondsPtr(gt), secondsPtr(gte))
+}
+
+// intBounds turns strict integer bounds into inclusive ones so that
+// "gt 2, lt 3" is recognised as empty.
+func intBounds(lt, lte, gt, gte *int) interval {
+	iv := fullInterval()
+	if lt != nil {
+		iv = iv.intersect(interval{lo: math.Inf(-1), hi: float64(*lt - 1), loIncl: true, hiIncl: true})
+	}
+	if lte != nil {
+		iv = iv.intersect(interval{lo: math.Inf(-1), hi: float64(*lte), loIncl: true, hiIncl: true})
+	}
+	if gt != nil {
+		iv = iv.intersect(interval{lo: float64(*gt + 1), hi: math.Inf(1), loIncl: true, hiIncl: true})
+	}
+	if gte != nil {
+		iv = iv.intersect(interval{lo: float64(*gte), hi: math.Inf(1), loIncl: true, hiIncl: true})
+	}
+	return iv
+}
+
+// conditionRange describes the quantity a leaf predicate constrains and the
+// values it accepts. ok is false for predicates without comparators.
+func conditionRange(c Condition) (subject string, iv interval, ok bool) {
+	switch v := c.(type) {
+	case dotRemainingCondition:
+		return "dot_remaining(" + v.spell + ")", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case resourcePercentCondition:
+		return "resource_percent(" + v.resource + ")", floatBounds(v.lt, v.lte, v.gt, v.gte), true
+	case cooldownRemainingCondition:
+		return "cooldown_remaining(" + v.name + ")", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case petCooldownRemainingCondition:
+		return "pet_cooldown_remaining(" + v.spell + ")", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case chargesCondition:
+		return "charges(" + v.buff + ")", intBounds(v.lt, v.lte, v.gt, v.gte), true
+	case fightTimeCondition:
+		subject = "time_elapsed"
+		if v.remaining {
+			subject = "time_remaining"
+		}
+		return subject, durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case targetHealthCondition:
+		return "target_health_percent", floatBounds(v.lt, v.lte, v.gt, v.gte), true
+	case castTimeCondition:
+		return "cast_time(" + v.spell + ")", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case gcdRemainingCondition:
+		return "gcd_remaining", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case castsSinceCondition:
+		return "casts_since(" + v.spell + ")", intBounds(v.lt, v.lte, v.gt, v.gte), true
+	case ticksRemainingCondition:
+		return "ticks_remaining(" + v.debuff + ")", intBounds(v.lt, v.lte, v.gt, v.gte), true
+	case variableCondition:
+		iv = floatBounds(v.lt, v.lte, v.gt, v.gte)
+		if v.eq != nil {
+			iv = iv.intersect(interval{lo: *v.eq, hi: *v.eq, loIncl: true, hiIncl: true})
+		}
+		return "variable(" + v.name + ")", iv, true
+	case buffActiveCondition:
+		return "buff_remaining(" + v.name + ")", durationBounds(nil, v.maxRemaining, nil, v.minRemaining), true
+	case debuffActiveCondition:
+		return "debuff_remaining(" + v.name + ")", durationBounds(nil, v.maxRemaining, nil, v.minRemaining), true
+	case auraActiveCondition:
+		return "aura_remaining(" + v.name + ")", durationBounds(nil, v.maxRemaining, nil, v.minRemaining), true
+	case auraRemainingCondition:
+		return "aura_remaining(" + v.name + ")", durationBounds(v.lt, v.lte, v.gt, v.gte), true
+	case auraStacksCondition:
+		return "aura_stacks(" + v.name + ")", intBounds(v.lt, v.lte, v.gt, v.gte), true
+	}
+	return "", interval{}, false
+}
+
+func (a *analyzer) checkCondition(loc string, c Condition) {
+	if c == nil {
+		return
+	}
+	switch v := c.(type) {
+	case allCondition:
+		a.checkConjunction(loc, v.children)
+		for _, child := range v.children {
+			a.checkCondition(loc, child)
+		}
+		return
+	case anyCondition:
+		for _, child := range v.children {
+			a.checkCondition(loc, child)
+		}
+		return
+	case notCondition:
+		a.checkCondition(loc, v.child)
+		return
+	case exprCondition:
+		for _, ref := range v.refs {
+			switch ref.kind {
+			case argSpell, argCooldown:
+				a.checkName(loc, "spell", ref.name)
+			case argBuff:
+				a.checkName(loc, "buff", ref.name)
+			case argDebuff:
+				a.checkName(loc, "debuff", ref.name)
+			case argAura:
+				a.checkName(loc, "aura", ref.name)
+			case argResource:
+				a.checkName(loc, "resource", ref.name)
+			}
+		}
+		a.checkExpr(loc, v.root)
+		return
+	}
+	if subject, iv, ok := conditionRange(c); ok && iv.empty() {
+		a.add(SeverityError, loc, "contradictory condition: %s can never satisfy its own bounds", subject)
+	}
+	a.checkLeafNames(loc, c)
+}
+
+// checkConjunction looks for children of one `all` that cannot hold together.
+// Bounds a single child already contradicts are left to that child's report.
+func (a *analyzer) checkConjunction(loc string, children []Condition) {
+	ranges := map[string]interval{}
+	selfEmpty := map[string]bool{}
+	var order []string
+	for _, child := range children {
+		for subject, iv := range intersectRanges(conditionRanges(child)) {
+			if iv.empty() {
+				selfEmpty[subject] = true
+			}
+			prev, seen := ranges[subject]
+			if !seen {
+				ranges[subject] = iv
+				order = append(order, subject)
+				continue
+			}
+			ranges[subject] = prev.intersect(iv)
+		}
+	}
+	sort.Strings(order)
+	for _, subject := range order {
+		if iv := ranges[subject]; iv.empty() && !selfEmpty[subject] {
+			a.add(SeverityError, loc, "contradictory condition: the bounds on %s never overlap", subject)
+		}
+	}
+	for i, child := range children {
+		negated, ok := child.(notCondition)
+		if !ok {
+			continue
+		}
+		for j, other := range children {
+			if i != j && reflect.DeepEqual(negated.child, other) {
+				a.add(SeverityError, loc, "contradictory condition: requires both X and not X")
+				return
+			}
+		}
+	}
+}
+
+// subjectRange is one bound on a subject, as conditionRange reports it.
+type subjectRange struct {
+	subject string
+	iv      interval
+}
+
+// conditionRanges returns the bounds c places on its subjects when it holds:
+// the leaf's own range, or for an expression the `ident op constant`
+// comparisons joined by its top-level `and`.
+func conditionRanges(c Condition) []subjectRange {
+	if expr, ok := c.(exprCondition); ok {
+		var out []subjectRange
+		for _, conjunct := range exprConjuncts(expr.root, nil) {
+			if subject, iv, ok := exprRange(conjunct); ok {
+				out = append(out, subjectRange{subject, iv})
+			}
+		}
+		return out
+	}
+	if subject, iv, ok := conditionRange(c); ok {
+		return []subjectRange{{subject, iv}}
+	}
+	return nil
+}
+
+func intersectRanges(ranges []subjectRange) map[string]interval {
+	out := map[string]interval{}
+	for _, r := range ranges {
+		if prev, ok := out[r.subject]; ok {
+			out[r.subject] = prev.intersect(r.iv)
+		} else {
+			out[r.subject] = r.iv
+		}
+	}
+	return out
+}
+
+// checkExpr runs the range checks on every `and` chain inside an expression.
+func (a *analyzer) checkExpr(loc string, node boolExpr) {
+	switch v := node.(type) {
+	case andExpr:
+		var ranges []subjectRange
+		for _, conjunct := range exprConjuncts(v, nil) {
+			if subject, iv, ok := exprRange(conjunct); ok {
+				ranges = append(ranges, subjectRange{subject, iv})
+				continue
+			}
+			a.checkExpr(loc, conjunct)
+		}
+		bounds := intersectRanges(ranges)
+		subjects := make([]string, 0, len(bounds))
+		for subject := range bounds {
+			subjects = append(subjects, subject)
+		}
+		sort.Strings(subjects)
+		for _, subject := range subjects {
+			if bounds[subject].empty() {
+				a.add(SeverityError, loc, "contradictory condition: the bounds on %s never overlap", subject)
+			}
+		}
+	case orExpr:
+		a.checkExpr(loc, v.left)
+		a.checkExpr(loc, v.right)
+	case notExpr:
+		a.checkExpr(loc, v.operand)
+	case compareExpr:
+		if subject, iv, ok := exprRange(v); ok && iv.empty() {
+			a.add(SeverityError, loc, "contradictory condition: %s can never satisfy its own bounds", subject)
+		}
+	}
+}
+
+// exprConjuncts flattens a chain of `and` into its operands.
+func exprConjuncts(node boolExpr, out []boolExpr) []boolExpr {
+	if and, ok := node.(andExpr); ok {
+		out = exprConjuncts(and.left, out)
+		return exprConjuncts(and.right, out)
+	}
+	return append(out, node)
+}
+
+// exprSubjects renames expression functions to the subjects conditionRange
+// uses for the matching predicate, so both forms share one interval.
+var exprSubjects = map[string]string{
+	"resource_pct":      "resource_percent",
+	"buff_charges":      "charges",
+	"target_health_pct": "target_health_percent",
+}
+
+// exprIntegerSubjects only take whole values, so strict bounds tighten like
+// intBounds.
+var exprIntegerSubjects = map[string]bool{
+	"buff_charges":    true,
+	"casts_since":     true,
+	"ticks_remaining": true,
+	"aura_stacks":     true,
+}
+
+// exprRange turns a comparison between a value and a constant (either way
+// round) into the subject it bounds and the interval it accepts.
+func exprRange(node boolExpr) (subject string, iv interval, ok bool) {
+	cmp, isCmp := node.(compareExpr)
+	if !isCmp {
+		return "", interval{}, false
+	}
+	op := cmp.op
+	call, isCall := cmp.left.(numCallExpr)
+	bound, isConst := constantValue(cmp.right)
+	if !isCall || !isConst {
+		call, isCall = cmp.right.(numCallExpr)
+		bound, isConst = constantValue(cmp.left)
+		if !isCall || !isConst {
+			return "", interval{}, false
+		}
+		op = map[string]string{"<": ">", "<=": ">=", ">": "<", ">=": "<=", "==": "==", "!=": "!="}[op]
+	}
+	if exprIntegerSubjects[call.name] {
+		switch op {
+		case "<":
+			op, bound = "<=", math.Ceil(bound)-1
+		case ">":
+			op, bound = ">=", math.Floor(bound)+1
+		case "<=":
+			bound = math.Floor(bound)
+		case ">=":
+			bound = math.Ceil(bound)
+		case "==":
+			if bound != math.Trunc(bound) {
+				return exprSubject(call), interval{lo: 1, hi: 0}, true
+			}
+		}
+	}
+	switch op {
+	case "<":
+		iv = interval{lo: math.Inf(-1), hi: bound, loIncl: true}
+	case "<=":
+		iv = interval{lo: math.Inf(-1), hi: bound, loIncl: true, hiIncl: true}
+	case ">":
+		iv = interval{lo: bound, hi: math.Inf(1), hiIncl: true}
+	case ">=":
+		iv = interval{lo: bound, hi: math.Inf(1), loIncl: true, hiIncl: true}
+	case "==":
+		iv = interval{lo: bound, hi: bound, loIncl: true, hiIncl: true}
+	default:
+		return "", interval{}, false
+	}
+	return exprSubject(call), iv, true
+}
+
+func exprSubject(call numCallExpr) string {
+	name := call.name
+	if renamed, ok := exprSubjects[name]; ok {
+		name = renamed
+	}
+	if len(call.args) == 0 {
+		return name
+	}
+	return name + "(" + strings.Join(call.args, ", ") + ")"
+}
+
+func (a *analyzer) checkLeafNames(loc string, c Condition) {
+	switch v := c.(type) {
+	case buffActiveCondition:
+		a.checkName(loc, "buff", v.name)
+	case chargesCondition:
+		a.checkName(loc, "buff", v.buff)
+	case debuffActiveCondition:
+		a.checkName(loc, "debuff", v.name)
+	case dotRemainingCondition:
+		a.checkName(loc, "debuff", v.spell)
+	case ticksRemainingCondition:
+		a.checkName(loc, "debuff", v.debuff)
+	case resourcePercentCondition:
+		a.checkName(loc, "resource", v.resource)
+	case castTimeCondition:
+		a.checkName(loc, "spell", v.spell)
+	case lastCastCondition:
+		a.checkName(loc, "spell", v.spell)
+	case castsSinceCondition:
+		a.checkName(loc, "spell", v.spell)
+	case auraActiveCondition:
+		a.checkName(loc, "aura", v.name)
+	case auraRemainingCondition:
+		a.checkName(loc, "aura", v.name)
+	case auraStacksCondition:
+		a.checkName(loc, "aura", v.name)
+	case auraAtMaxStacksCondition:
+		a.checkName(loc, "aura", v.name)
+	}
+}
+
+// checkName reports identifiers the APL accepts but the engine does not implement.
+func (a *analyzer) checkName(loc, kind, name string) {
+	if a.support == nil || name == "" {
+		return
+	}
+	var set map[string]struct{}
+	switch kind {
+	case "spell":
+		set = a.support.Spells
+	case "buff":
+		set = a.support.Buffs
+	case "debuff":
+		set = a.support.Debuffs
+	case "aura":
+		if _, ok := a.support.Debuffs[name]; ok {
+			return
+		}
+		set = a.support.Buffs
+	case "resource":
+		set = a.support.Resources
+	}
+	if set == nil {
+		return
+	}
+	if _, ok := set[name]; !ok {
+		a.add(SeverityWarning, loc, "%s '%s' is accepted by the APL but ignored by the engine", kind, name)
+	}
+}
+
+// --- variables ---
+
+var varRefPattern = regexp.MustCompile(`\$\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}`)
+
+// checkVariables works on the raw file so it can report every undefined
+// ${...} reference, not just the first one Compile trips over. A variable
+// counts as used when it is referenced as ${name}, named by a variable
+// action or predicate, or read in an expression (variable(name) or the bare
+// name).
+func (a *analyzer) checkVariables(file *File) {
+	used := map[string]bool{}
+	var visit func(loc string, def *ActionDefinition)
+	visit = func(loc string, def *ActionDefinition) {
+		var scalars []string
+		if def.Variable != "" {
+			used[def.Variable] = true
+		}
+		if str, ok := def.Value.(string); ok {
+			scalars = append(scalars, str)
+		}
+		for _, node := range []*ConditionNode{def.When, def.Until, def.ResetWhen, def.InterruptIf} {
+			scalars = appendScalars(scalars, node.Node())
+			conditionVariables(node.Node(), file.Variables, used)
+		}
+		for _, text := range scalars {
+			for _, m := range varRefPattern.FindAllStringSubmatch(text, -1) {
+				name := m[1]
+				if _, ok := file.Variables[name]; !ok {
+					a.add(SeverityError, loc, "reference to undefined variable '${%s}'", name)
+				}
+				used[name] = true
+			}
+		}
+		for idx := range def.Steps {
+			visit(fmt.Sprintf("%s.steps[%d]", loc, idx), &def.Steps[idx])
+		}
+	}
+	for idx := range file.Rotation {
+		visit(fmt.Sprintf("rotation[%d]", idx), &file.Rotation[idx])
+	}
+	listNames := make([]string, 0, len(file.ActionLists))
+	for name := range file.ActionLists {
+		listNames = append(listNames, name)
+	}
+	sort.Strings(listNames)
+	for _, name := range listNames {
+		for idx := range file.ActionLists[name] {
+			visit(fmt.Sprintf("action_lists.%s[%d]", name, idx), &file.ActionLists[name][idx])
+		}
+	}
+	for idx := range file.Phases {
+		visit(fmt.Sprintf("phases[%d]", idx), &ActionDefinition{When: file.Phases[idx].When})
+	}
+
+	declared := make([]string, 0, len(file.Variables))
+	for name := range file.Variables {
+		declared = append(declared, name)
+	}
+	sort.Strings(declared)
+	for _, name := range declared {
+		if !used[name] {
+			a.add(SeverityWarning, "variables."+name, "variable '%s' is declared but never used", name)
+		}
+	}
+}
+
+// conditionVariables marks the variables a raw condition reads through the
+// variable predicate or inside expressions. Expressions that do not parse
+// are left to Compile.
+func conditionVariables(node *yaml.Node, vars map[string]any, used map[string]bool) {
+	if node == nil {
+		return
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		expressionVariables(node.Value, vars, used)
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			conditionVariables(child, vars, used)
+		}
+	case yaml.MappingNode:
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key, value := node.Content[idx].Value, node.Content[idx+1]
+			switch key {
+			case "all", "any", "not":
+				conditionVariables(value, vars, used)
+			case "expr":
+				expressionVariables(value.Value, vars, used)
+			case "variable":
+				for vi := 0; vi+1 < len(value.Content); vi += 2 {
+					if value.Content[vi].Value == "name" {
+						used[value.Content[vi+1].Value] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+func expressionVariables(source string, vars map[string]any, used map[string]bool) {
+	_, refs, err := parseExpression(source, vars)
+	if err != nil {
+		return
+	}
+	for _, ref := range refs {
+		if ref.kind == argVariable {
+			used[ref.name] = true
+		}
+	}
+}
+
+func appendScalars(out []string, node *yaml.Node) []string {
+	if node == nil {
+		return out
+	}
+	if node.Kind == yaml.ScalarNode {
+		return append(out, node.Value)
+	}
+	for idx, child := range node.Content {
+		// Skip mapping keys; only values can reference variables.
+		if node.Kind == yaml.MappingNode && idx%2 == 0 {
+			continue
+		}
+		out = appendScalars(out, child)
+	}
+	return out
+}
+
+// String renders a diagnostic as "severity: location: message".
+func (d Diagnostic) String() string {
+	if d.Location == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", d.Severity, d.Location, strings.TrimSpace(d.Message))
+}
@@ -0,0 +1,117 @@
+package apl
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func analyzeSource(t *testing.T, src string) []Diagnostic {
+	t.Helper()
+	var file File
+	if err := yaml.Unmarshal([]byte(src), &file); err != nil {
+		t.Fatalf("parse rotation: %v", err)
+	}
+	return Analyze(&file, nil)
+}
+
+func hasDiagnostic(diags []Diagnostic, sev Severity, loc, fragment string) bool {
+	for _, d := range diags {
+		if d.Severity == sev && d.Location == loc && strings.Contains(d.Message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAnalyzeExpressionBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		when string
+		want string // "" means no contradiction
+	}{
+		{"disjoint", `"mana_pct < 0.2 and mana_pct > 0.5"`, "resource_percent(mana)"},
+		{"literal first", `"0.2 > mana_pct and mana_pct >= 0.2"`, "resource_percent(mana)"},
+		{"nested in or", `"last_cast(conflagrate) or (time_elapsed > 10 and time_elapsed < 5)"`, "time_elapsed"},
+		{"integer gap", `"backdraft_charges > 2 and backdraft_charges < 3"`, "charges(backdraft)"},
+		{"variable", `"variable(bursts) == 1 and variable(bursts) >= 2"`, "variable(bursts)"},
+		{"overlap", `"mana_pct > 0.2 and mana_pct < 0.5"`, ""},
+		{"either side", `"mana_pct < 0.2 or mana_pct > 0.5"`, ""},
+		{"different subjects", `"mana_pct < 0.2 and pet_mana_pct > 0.5"`, ""},
+		{"mixed with struct", "\n      all:\n        - resource_percent: {resource: mana, lt: 0.2}\n        - \"mana_pct > 0.5\"", "resource_percent(mana)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := analyzeSource(t, `
+variables:
+  bursts: 0
+rotation:
+  - action: increment_variable
+    variable: bursts
+  - action: cast_spell
+    spell: incinerate
+    when: `+tt.when+`
+`)
+			var contradictions []Diagnostic
+			for _, d := range diags {
+				if strings.Contains(d.Message, "contradictory") {
+					contradictions = append(contradictions, d)
+				}
+			}
+			if tt.want == "" {
+				if len(contradictions) > 0 {
+					t.Fatalf("unexpected diagnostics: %+v", contradictions)
+				}
+				return
+			}
+			if len(contradictions) != 1 || !hasDiagnostic(contradictions, SeverityError, "rotation[1]", tt.want) {
+				t.Fatalf("want one contradiction on %s, got %+v", tt.want, diags)
+			}
+		})
+	}
+}
+
+func TestAnalyzeShadowedByImpliedCondition(t *testing.T) {
+	diags := analyzeSource(t, `
+rotation:
+  - action: cast_spell
+    spell: life_tap
+    when:
+      any:
+        - buff_active: {buff: life_tap_buff, max_remaining: 5}
+        - resource_percent: {resource: mana, lt: 0.3}
+  - action: cast_spell
+    spell: life_tap
+    when:
+      all:
+        - buff_active: {buff: life_tap_buff, max_remaining: 3}
+        - cooldown_ready: {spell: chaos_bolt}
+  - action: cast_spell
+    spell: life_tap
+    when:
+      buff_active: {buff: life_tap_buff, max_remaining: 8}
+`)
+	if !hasDiagnostic(diags, SeverityWarning, "rotation[1]", "rotation[0] fires first") {
+		t.Errorf("rotation[1] not reported as shadowed: %+v", diags)
+	}
+	if hasDiagnostic(diags, SeverityWarning, "rotation[2]", "never fires") {
+		t.Errorf("rotation[2] can fire with 5-8s left but was reported: %+v", diags)
+	}
+}
+
+// destructuin-decisivfe-2.yaml's Chaos Bolt buffer Life Tap only fires with
+// the Life Tap buff under 5s, which the first Life Tap entry already covers.
+func TestAnalyzeDecisiveTwoDeadLifeTap(t *testing.T) {
+	file, err := LoadRotation("../../configs/rotations", "destructuin-decisivfe-2.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags := Analyze(file, nil)
+	if !hasDiagnostic(diags, SeverityWarning, "rotation[7]", "cast of life_tap at rotation[0] fires first") {
+		t.Fatalf("dead Life Tap at rotation[7] not reported: %+v", diags)
+	}
+	if len(diags) != 1 {
+		t.Errorf("want only the dead Life Tap, got %+v", diags)
+	}
+}
@@ -2,6 +2,7 @@ package apl
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,8 +16,38 @@ type CompiledRotation struct {
 	Description string
 	Variables   map[string]any
 	Actions     []*Action
+	Lists       map[string][]*Action // named action lists, excluding precombat and pet
+	Precombat   []*Action            // cast once, in order, before the pull
+	Pet         []*Action            // evaluated by the pet controller whenever the pet is free
+	// RuntimeVariables holds the starting value of every numeric or boolean
+	// variable; set/increment/reset_variable mutate a per-iteration copy.
+	RuntimeVariables map[string]float64
+	// Phases are checked in order before each decision; the first match
+	// runs its list instead of Actions.
+	Phases []*Phase
+	// Symbols indexes the names the compiled Programs refer to.
+	Symbols Symbols
 }
 
+// DefaultPhase names the rotation list when no phase matches.
+const DefaultPhase = "default"
+
+// Phase is a compiled encounter phase.
+type Phase struct {
+	Name      string
+	Marker    string // encounter phase marker; empty matches any
+	List      string
+	Condition Condition
+	Program   *Program
+	Actions   []*Action // the named list
+}
+
+// PrecombatList is the reserved action list name for pre-pull casts.
+const PrecombatList = "precombat"
+
+// PetList is the reserved action list name for pet abilities.
+const PetList = "pet"
+
 // ActionType enumerates supported rotation actions.
 type ActionType int
 
@@ -25,17 +56,81 @@ const (
 	ActionUseItem
 	ActionWait
 	ActionMacro
+	ActionCallList // evaluate a named list; fall through if it casts nothing
+	ActionRunList  // evaluate a named list exclusively
+	ActionSetVariable
+	ActionIncrementVariable
+	ActionResetVariable
+	ActionSequence   // steps run strictly in order across decisions
+	ActionWaitUntil  // idle until a condition holds or the timeout expires
+	ActionPetCast    // pet ability, pet list only
+	ActionCancelBuff // drop one of the player's buffs; takes no time
 )
 
+// IsVariableAction reports whether the action only updates a runtime variable.
+// Variable actions take no time and evaluation continues with the next entry.
+func (t ActionType) IsVariableAction() bool {
+	return t == ActionSetVariable || t == ActionIncrementVariable || t == ActionResetVariable
+}
+
+// String returns the YAML name of the action type.
+func (t ActionType) String() string {
+	switch t {
+	case ActionCastSpell:
+		return "cast_spell"
+	case ActionUseItem:
+		return "use_item"
+	case ActionWait:
+		return "wait"
+	case ActionMacro:
+		return "macro"
+	case ActionCallList:
+		return "call_action_list"
+	case ActionRunList:
+		return "run_action_list"
+	case ActionSetVariable:
+		return "set_variable"
+	case ActionIncrementVariable:
+		return "increment_variable"
+	case ActionResetVariable:
+		return "reset_variable"
+	case ActionSequence:
+		return "sequence"
+	case ActionWaitUntil:
+		return "wait_until"
+	case ActionPetCast:
+		return "pet_cast"
+	case ActionCancelBuff:
+		return "cancel_buff"
+	default:
+		return fmt.Sprintf("action(%d)", int(t))
+	}
+}
+
 // Action is a compiled, ready-to-evaluate rotation entry.
 type Action struct {
 	Type      ActionType
-	Spell     string
+	Spell     string // spell, or pet ability for pet_cast
 	Item      string
-	Duration  time.Duration
+	Buff      string // cancel_buff
+	List      string
+	Variable  string
+	Value     float64       // new value for set_variable, step for increment_variable
+	Duration  time.Duration // wait length, or wait_until timeout
 	Steps     []*Action
 	Condition Condition
+	Until     Condition // wait_until
+	Reset     Condition // sequence reset_when; nil restarts once complete
+	Interrupt Condition // cast_spell interrupt_if; nil casts are never interrupted
 	Tags      []string
+
+	// Flattened Condition/Until/Reset/Interrupt for the engine; nil when the
+	// tree is nil.
+	Program          *Program
+	UntilProgram     *Program
+	ResetProgram     *Program
+	InterruptProgram *Program
+	SpellSym         int // index of Spell in Symbols.Spells, -1 if not a cast
 }
 
 // Compile turns a parsed File into a CompiledRotation.
@@ -43,20 +138,197 @@ func Compile(file *File) (*CompiledRotation, error) {
 	if file == nil {
 		return nil, fmt.Errorf("nil rotation file")
 	}
+	vars := variableScope(file)
 	var actions []*Action
 	for idx, def := range file.Rotation {
-		action, err := compileAction(&def, file.Variables)
+		action, err := compileAction(&def, vars)
 		if err != nil {
 			return nil, fmt.Errorf("rotation entry %d: %w", idx, err)
 		}
+		if err := checkPetCasts(action); err != nil {
+			return nil, fmt.Errorf("rotation entry %d: %w", idx, err)
+		}
 		actions = append(actions, action)
 	}
-	return &CompiledRotation{
+	compiled := &CompiledRotation{
 		Name:        file.Name,
 		Description: file.Description,
 		Variables:   file.Variables,
 		Actions:     actions,
-	}, nil
+		Lists:       map[string][]*Action{},
+
+		RuntimeVariables: initialVariables(file.Variables),
+	}
+
+	names := make([]string, 0, len(file.ActionLists))
+	for name := range file.ActionLists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, rawName := range names {
+		name := normalizeName(rawName)
+		if name == "" {
+			return nil, fmt.Errorf("action list name missing")
+		}
+		if _, dup := compiled.Lists[name]; dup {
+			return nil, fmt.Errorf("action list '%s' defined twice", name)
+		}
+		var list []*Action
+		for idx, def := range file.ActionLists[rawName] {
+			action, err := compileAction(&def, vars)
+			if err != nil {
+				return nil, fmt.Errorf("action list '%s' entry %d: %w", name, idx, err)
+			}
+			if name == PrecombatList && action.Type != ActionCastSpell && !action.Type.IsVariableAction() {
+				return nil, fmt.Errorf("action list '%s' entry %d: precombat only supports cast_spell and variable actions", name, idx)
+			}
+			if name == PrecombatList && action.Interrupt != nil {
+				return nil, fmt.Errorf("action list '%s' entry %d: precombat casts cannot use interrupt_if", name, idx)
+			}
+			if name == PetList && action.Type != ActionPetCast && action.Type != ActionWait && !action.Type.IsVariableAction() {
+				return nil, fmt.Errorf("action list '%s' entry %d: pet only supports pet_cast, wait and variable actions", name, idx)
+			}
+			if name != PetList {
+				if err := checkPetCasts(action); err != nil {
+					return nil, fmt.Errorf("action list '%s' entry %d: %w", name, idx, err)
+				}
+			}
+			list = append(list, action)
+		}
+		switch name {
+		case PrecombatList:
+			compiled.Precombat = list
+			continue
+		case PetList:
+			compiled.Pet = list
+			continue
+		}
+		compiled.Lists[name] = list
+	}
+
+	if err := checkListReferences(compiled); err != nil {
+		return nil, err
+	}
+	phases, err := compilePhases(file.Phases, compiled.Lists, vars)
+	if err != nil {
+		return nil, err
+	}
+	compiled.Phases = phases
+	compilePrograms(compiled)
+	return compiled, nil
+}
+
+func compilePhases(defs []PhaseDefinition, lists map[string][]*Action, vars map[string]any) ([]*Phase, error) {
+	var phases []*Phase
+	seen := map[string]bool{DefaultPhase: true}
+	for idx, def := range defs {
+		name := normalizeName(def.Name)
+		if name == "" {
+			return nil, fmt.Errorf("phase %d: name missing", idx)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("phase %d: phase '%s' defined twice or reserved", idx, name)
+		}
+		seen[name] = true
+		phase := &Phase{Name: name, Marker: normalizeName(def.Marker), List: normalizeName(def.List)}
+		if phase.List == "" {
+			phase.List = name
+		}
+		actions, ok := lists[phase.List]
+		if !ok {
+			return nil, fmt.Errorf("phase '%s': unknown action list '%s'", name, phase.List)
+		}
+		phase.Actions = actions
+		var err error
+		if phase.Condition, err = compileCondition(def.When, vars); err != nil {
+			return nil, fmt.Errorf("phase '%s': %w", name, err)
+		}
+		if phase.Marker == "" && phase.Condition == nil {
+			return nil, fmt.Errorf("phase '%s' needs a marker or a when condition", name)
+		}
+		phases = append(phases, phase)
+	}
+	return phases, nil
+}
+
+// checkListReferences verifies every call/run target exists and that lists
+// do not call each other in a cycle.
+func checkListReferences(rot *CompiledRotation) error {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var visit func(name string, actions []*Action) error
+	visit = func(name string, actions []*Action) error {
+		for _, action := range collectListCalls(actions) {
+			target, ok := rot.Lists[action.List]
+			if !ok {
+				if action.List == PrecombatList || action.List == PetList {
+					return fmt.Errorf("action list '%s' cannot be called", action.List)
+				}
+				return fmt.Errorf("unknown action list '%s'", action.List)
+			}
+			switch state[action.List] {
+			case visiting:
+				return fmt.Errorf("action list cycle: '%s' calls '%s'", name, action.List)
+			case unvisited:
+				state[action.List] = visiting
+				if err := visit(action.List, target); err != nil {
+					return err
+				}
+				state[action.List] = done
+			}
+		}
+		return nil
+	}
+	if err := visit("rotation", rot.Actions); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(rot.Lists))
+	for name := range rot.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] != unvisited {
+			continue
+		}
+		state[name] = visiting
+		if err := visit(name, rot.Lists[name]); err != nil {
+			return err
+		}
+		state[name] = done
+	}
+	return nil
+}
+
+// checkPetCasts rejects pet_cast outside the pet list, including in steps.
+func checkPetCasts(action *Action) error {
+	if action.Type == ActionPetCast {
+		return fmt.Errorf("pet_cast is only allowed in the '%s' action list", PetList)
+	}
+	for _, step := range action.Steps {
+		if err := checkPetCasts(step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectListCalls(actions []*Action) []*Action {
+	var out []*Action
+	for _, action := range actions {
+		if action == nil {
+			continue
+		}
+		if action.Type == ActionCallList || action.Type == ActionRunList {
+			out = append(out, action)
+		}
+		out = append(out, collectListCalls(action.Steps)...)
+	}
+	return out
 }
 
 func compileAction(def *ActionDefinition, vars map[string]any) (*Action, error) {
@@ -66,6 +338,9 @@ func compileAction(def *ActionDefinition, vars map[string]any) (*Action, error)
 	action := &Action{
 		Tags: def.Tags,
 	}
+	if _, ok := actionSpecIndex[strings.ToLower(def.Action)]; !ok {
+		return nil, fmt.Errorf("unsupported action '%s'", def.Action)
+	}
 	var err error
 	action.Condition, err = compileCondition(def.When, vars)
 	if err != nil {
@@ -83,6 +358,21 @@ func compileAction(def *ActionDefinition, vars map[string]any) (*Action, error)
 		}
 		action.Type = ActionCastSpell
 		action.Spell = spellName
+		if def.InterruptIf != nil {
+			if action.Interrupt, err = compileCondition(def.InterruptIf, vars); err != nil {
+				return nil, fmt.Errorf("cast_spell interrupt_if: %w", err)
+			}
+		}
+	case "cancel_buff":
+		if def.Buff == "" {
+			return nil, fmt.Errorf("cancel_buff action requires 'buff'")
+		}
+		buff, err := validateBuffName(def.Buff)
+		if err != nil {
+			return nil, err
+		}
+		action.Type = ActionCancelBuff
+		action.Buff = buff
 	case "use_item":
 		if def.Item == "" {
 			return nil, fmt.Errorf("use_item action requires 'item'")
@@ -96,6 +386,86 @@ func compileAction(def *ActionDefinition, vars map[string]any) (*Action, error)
 		}
 		action.Type = ActionWait
 		action.Duration = time.Duration(def.DurationSeconds * float64(time.Second))
+	case "call_action_list", "run_action_list":
+		name := normalizeName(def.List)
+		if name == "" {
+			return nil, fmt.Errorf("%s action requires 'list'", def.Action)
+		}
+		action.Type = ActionCallList
+		if strings.ToLower(def.Action) == "run_action_list" {
+			action.Type = ActionRunList
+		}
+		action.List = name
+	case "set_variable", "increment_variable", "reset_variable":
+		name, err := validateRuntimeVariable(def.Variable, vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", def.Action, err)
+		}
+		action.Variable = name
+		switch strings.ToLower(def.Action) {
+		case "set_variable":
+			action.Type = ActionSetVariable
+			if def.Value == nil {
+				return nil, fmt.Errorf("set_variable requires 'value'")
+			}
+			if action.Value, err = numericValue(def.Value, vars); err != nil {
+				return nil, fmt.Errorf("set_variable '%s': %w", name, err)
+			}
+		case "increment_variable":
+			action.Type = ActionIncrementVariable
+			action.Value = 1
+			if def.Value != nil {
+				if action.Value, err = numericValue(def.Value, vars); err != nil {
+					return nil, fmt.Errorf("increment_variable '%s': %w", name, err)
+				}
+			}
+		default:
+			action.Type = ActionResetVariable
+		}
+	case "sequence":
+		action.Type = ActionSequence
+		if len(def.Steps) == 0 {
+			return nil, fmt.Errorf("sequence action requires 'steps'")
+		}
+		for stepIdx := range def.Steps {
+			step, err := compileAction(&def.Steps[stepIdx], vars)
+			if err != nil {
+				return nil, fmt.Errorf("sequence step %d: %w", stepIdx, err)
+			}
+			switch {
+			case step.Type == ActionCastSpell, step.Type == ActionWait, step.Type == ActionWaitUntil, step.Type == ActionCancelBuff, step.Type.IsVariableAction():
+			default:
+				return nil, fmt.Errorf("sequence step %d: only cast_spell, wait, wait_until, cancel_buff and variable actions are allowed", stepIdx)
+			}
+			action.Steps = append(action.Steps, step)
+		}
+		if def.ResetWhen != nil {
+			if action.Reset, err = compileCondition(def.ResetWhen, vars); err != nil {
+				return nil, fmt.Errorf("sequence reset_when: %w", err)
+			}
+		}
+	case "wait_until":
+		action.Type = ActionWaitUntil
+		if def.Until == nil || def.Until.Node() == nil {
+			return nil, fmt.Errorf("wait_until action requires 'until'")
+		}
+		if def.TimeoutSeconds <= 0 {
+			return nil, fmt.Errorf("wait_until action requires timeout_seconds > 0")
+		}
+		if action.Until, err = compileCondition(def.Until, vars); err != nil {
+			return nil, fmt.Errorf("wait_until until: %w", err)
+		}
+		action.Duration = time.Duration(def.TimeoutSeconds * float64(time.Second))
+	case "pet_cast":
+		if def.Spell == "" {
+			return nil, fmt.Errorf("pet_cast action requires 'spell'")
+		}
+		spellName, err := validatePetSpellName(def.Spell)
+		if err != nil {
+			return nil, err
+		}
+		action.Type = ActionPetCast
+		action.Spell = spellName
 	case "macro":
 		action.Type = ActionMacro
 		for stepIdx := range def.Steps {
@@ -138,6 +508,9 @@ func parseConditionNode(node *yaml.Node, vars map[string]any) (Condition, error)
 			}
 			return falseCondition{}, nil
 		}
+		if node.Tag == "!!str" && strings.TrimSpace(node.Value) != "" {
+			return compileExpression(node.Value, vars)
+		}
 		return nil, fmt.Errorf("unsupported scalar condition: %s", node.Value)
 	default:
 		return nil, fmt.Errorf("unsupported YAML node kind %d", node.Kind)
@@ -154,6 +527,10 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 
 	key := node.Content[0].Value
 	val := node.Content[1]
+	spec, ok := conditionSpecIndex[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown condition '%s'", key)
+	}
 
 	switch key {
 	case "all":
@@ -178,8 +555,13 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		return trueCondition{}, nil
 	case "false":
 		return falseCondition{}, nil
+	case "expr":
+		if val.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("expr: expected a string expression")
+		}
+		return compileExpression(val.Value, vars)
 	case "debuff_active":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -201,7 +583,7 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		}
 		return debuffActiveCondition{name: strings.ToLower(name), minRemaining: minDur, maxRemaining: maxDur}, nil
 	case "dot_remaining":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -228,7 +610,7 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		}
 		return cond, nil
 	case "buff_active":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -249,7 +631,7 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		}
 		return cond, nil
 	case "resource_percent":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -276,7 +658,7 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		}
 		return cond, nil
 	case "cooldown_ready":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -300,7 +682,7 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 		}
 		return cooldownReadyCondition{name: name}, nil
 	case "cooldown_remaining":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -336,8 +718,29 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 			return nil, err
 		}
 		return cond, nil
+	case "pet_cooldown_ready", "pet_cooldown_remaining":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := stringField(params, "spell", true, vars)
+		if err != nil {
+			return nil, err
+		}
+		spell, err := validatePetSpellName(raw)
+		if err != nil {
+			return nil, err
+		}
+		if key == "pet_cooldown_ready" {
+			return petCooldownReadyCondition{spell: spell}, nil
+		}
+		cond := petCooldownRemainingCondition{spell: spell}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = secondsComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
 	case "charges":
-		params, err := nodeToMap(val)
+		params, err := spec.params(val)
 		if err != nil {
 			return nil, err
 		}
@@ -363,11 +766,275 @@ func parseConditionMapping(node *yaml.Node, vars map[string]any) (Condition, err
 			return nil, err
 		}
 		return cond, nil
+	case "time_elapsed", "time_remaining":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		cond := fightTimeCondition{remaining: key == "time_remaining"}
+		if cond.lt, err = durationField(params, "lt_seconds", vars); err != nil {
+			return nil, err
+		}
+		if cond.lte, err = durationField(params, "lte_seconds", vars); err != nil {
+			return nil, err
+		}
+		if cond.gt, err = durationField(params, "gt_seconds", vars); err != nil {
+			return nil, err
+		}
+		if cond.gte, err = durationField(params, "gte_seconds", vars); err != nil {
+			return nil, err
+		}
+		if cond.lt == nil && cond.lte == nil && cond.gt == nil && cond.gte == nil {
+			return nil, fmt.Errorf("%s requires one of lt_seconds, lte_seconds, gt_seconds, gte_seconds", key)
+		}
+		return cond, nil
+	case "target_health_percent":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		cond := targetHealthCondition{}
+		if cond.lt, err = floatField(params, "lt", vars); err != nil {
+			return nil, err
+		}
+		if cond.lte, err = floatField(params, "lte", vars); err != nil {
+			return nil, err
+		}
+		if cond.gt, err = floatField(params, "gt", vars); err != nil {
+			return nil, err
+		}
+		if cond.gte, err = floatField(params, "gte", vars); err != nil {
+			return nil, err
+		}
+		if cond.lt == nil && cond.lte == nil && cond.gt == nil && cond.gte == nil {
+			return nil, fmt.Errorf("target_health_percent requires one of lt, lte, gt, gte")
+		}
+		for _, bound := range []*float64{cond.lt, cond.lte, cond.gt, cond.gte} {
+			if bound != nil && (*bound < 0 || *bound > 1) {
+				return nil, fmt.Errorf("target_health_percent bounds are fractions between 0 and 1, got %v", *bound)
+			}
+		}
+		return cond, nil
+	case "cast_time":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		spellRaw, err := stringField(params, "spell", true, vars)
+		if err != nil {
+			return nil, err
+		}
+		spell, err := validateSpellName(spellRaw)
+		if err != nil {
+			return nil, err
+		}
+		cond := castTimeCondition{spell: spell}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = secondsComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "gcd_remaining":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		cond := gcdRemainingCondition{}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = secondsComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "last_cast":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		spellRaw, err := stringField(params, "spell", true, vars)
+		if err != nil {
+			return nil, err
+		}
+		spell, err := validateSpellName(spellRaw)
+		if err != nil {
+			return nil, err
+		}
+		return lastCastCondition{spell: spell}, nil
+	case "casts_since":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		spellRaw, err := stringField(params, "spell", true, vars)
+		if err != nil {
+			return nil, err
+		}
+		spell, err := validateSpellName(spellRaw)
+		if err != nil {
+			return nil, err
+		}
+		cond := castsSinceCondition{spell: spell}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = countComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "ticks_remaining":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		debuffRaw, err := stringField(params, "debuff", true, vars)
+		if err != nil {
+			return nil, err
+		}
+		debuff, err := validateDebuffName(debuffRaw)
+		if err != nil {
+			return nil, err
+		}
+		cond := ticksRemainingCondition{debuff: debuff}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = countComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "aura_active":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		name, err := auraField(params, vars)
+		if err != nil {
+			return nil, err
+		}
+		cond := auraActiveCondition{name: name}
+		if cond.minRemaining, err = durationField(params, "min_remaining", vars); err != nil {
+			return nil, err
+		}
+		if cond.maxRemaining, err = durationField(params, "max_remaining", vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "aura_remaining":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		name, err := auraField(params, vars)
+		if err != nil {
+			return nil, err
+		}
+		cond := auraRemainingCondition{name: name}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = secondsComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "aura_stacks":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		name, err := auraField(params, vars)
+		if err != nil {
+			return nil, err
+		}
+		cond := auraStacksCondition{name: name}
+		if cond.lt, cond.lte, cond.gt, cond.gte, err = countComparators(key, params, vars); err != nil {
+			return nil, err
+		}
+		return cond, nil
+	case "aura_at_max_stacks":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		name, err := auraField(params, vars)
+		if err != nil {
+			return nil, err
+		}
+		return auraAtMaxStacksCondition{name: name}, nil
+	case "variable":
+		params, err := spec.params(val)
+		if err != nil {
+			return nil, err
+		}
+		nameRaw, err := stringField(params, "name", true, nil)
+		if err != nil {
+			return nil, err
+		}
+		name, err := validateRuntimeVariable(nameRaw, vars)
+		if err != nil {
+			return nil, err
+		}
+		cond := variableCondition{name: name}
+		if cond.eq, err = floatField(params, "eq", vars); err != nil {
+			return nil, err
+		}
+		if cond.lt, err = floatField(params, "lt", vars); err != nil {
+			return nil, err
+		}
+		if cond.lte, err = floatField(params, "lte", vars); err != nil {
+			return nil, err
+		}
+		if cond.gt, err = floatField(params, "gt", vars); err != nil {
+			return nil, err
+		}
+		if cond.gte, err = floatField(params, "gte", vars); err != nil {
+			return nil, err
+		}
+		if cond.eq == nil && cond.lt == nil && cond.lte == nil && cond.gt == nil && cond.gte == nil {
+			return nil, fmt.Errorf("variable requires one of eq, lt, lte, gt, gte")
+		}
+		return cond, nil
 	default:
 		return nil, fmt.Errorf("unknown condition '%s'", key)
 	}
 }
 
+// auraField reads and validates the aura name of an aura_* condition.
+func auraField(params map[string]*yaml.Node, vars map[string]any) (string, error) {
+	raw, err := stringField(params, "aura", true, vars)
+	if err != nil {
+		return "", err
+	}
+	return validateAuraName(raw)
+}
+
+// secondsComparators reads lt_seconds/lte_seconds/gt_seconds/gte_seconds and requires at least one.
+func secondsComparators(key string, params map[string]*yaml.Node, vars map[string]any) (lt, lte, gt, gte *time.Duration, err error) {
+	if lt, err = durationField(params, "lt_seconds", vars); err != nil {
+		return
+	}
+	if lte, err = durationField(params, "lte_seconds", vars); err != nil {
+		return
+	}
+	if gt, err = durationField(params, "gt_seconds", vars); err != nil {
+		return
+	}
+	if gte, err = durationField(params, "gte_seconds", vars); err != nil {
+		return
+	}
+	if lt == nil && lte == nil && gt == nil && gte == nil {
+		err = fmt.Errorf("%s requires one of lt_seconds, lte_seconds, gt_seconds, gte_seconds", key)
+	}
+	return
+}
+
+// countComparators reads integer lt/lte/gt/gte bounds and requires at least one.
+func countComparators(key string, params map[string]*yaml.Node, vars map[string]any) (lt, lte, gt, gte *int, err error) {
+	if lt, err = intField(params, "lt", vars); err != nil {
+		return
+	}
+	if lte, err = intField(params, "lte", vars); err != nil {
+		return
+	}
+	if gt, err = intField(params, "gt", vars); err != nil {
+		return
+	}
+	if gte, err = intField(params, "gte", vars); err != nil {
+		return
+	}
+	if lt == nil && lte == nil && gt == nil && gte == nil {
+		err = fmt.Errorf("%s requires one of lt, lte, gt, gte", key)
+	}
+	return
+}
+
 func parseConditionSequence(node *yaml.Node, vars map[string]any) ([]Condition, error) {
 	if node.Kind != yaml.SequenceNode {
 		return nil, fmt.Errorf("expected sequence, got %d", node.Kind)
@@ -490,6 +1157,107 @@ func intField(fields map[string]*yaml.Node, key string, vars map[string]any) (*i
 	}
 }
 
+// validateRuntimeVariable checks that name is declared under variables: with a
+// numeric or boolean value, which is what runtime variables start from.
+func validateRuntimeVariable(name string, vars map[string]any) (string, error) {
+	n := strings.TrimSpace(name)
+	if n == "" {
+		return n, fmt.Errorf("variable name missing")
+	}
+	val, ok := vars[n]
+	if !ok {
+		return "", fmt.Errorf("variable '%s' is not declared in variables", n)
+	}
+	if _, err := numericValue(val, nil); err != nil {
+		return "", fmt.Errorf("variable '%s': %w", n, err)
+	}
+	return n, nil
+}
+
+// numericValue converts a variable or action value to float64; booleans map to 1/0.
+func numericValue(raw any, vars map[string]any) (float64, error) {
+	switch v := raw.(type) {
+	case mutableVariable:
+		return numericValue(v.start, vars)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		str := strings.TrimSpace(v)
+		if strings.HasPrefix(str, "${") && strings.HasSuffix(str, "}") {
+			name := strings.TrimSpace(str[2 : len(str)-1])
+			val, ok := vars[name]
+			if !ok {
+				return 0, fmt.Errorf("variable '%s' not defined", name)
+			}
+			return numericValue(val, nil)
+		}
+		parsed, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected a number or bool, got %q", v)
+		}
+		return parsed, nil
+	default:
+		return 0, fmt.Errorf("expected a number or bool, got %T", raw)
+	}
+}
+
+// mutableVariable wraps the declared value of a variable that a
+// set_variable, increment_variable or reset_variable action writes. A bare
+// reference to it in an expression reads the runtime value instead of folding
+// in the declared one; ${name} still substitutes the declared value.
+type mutableVariable struct{ start any }
+
+// variableScope returns the variables conditions compile against: the
+// declared ones, with every variable some *_variable action targets wrapped
+// in mutableVariable.
+func variableScope(file *File) map[string]any {
+	scope := make(map[string]any, len(file.Variables))
+	for name, val := range file.Variables {
+		scope[name] = val
+	}
+	var mark func(defs []ActionDefinition)
+	mark = func(defs []ActionDefinition) {
+		for _, def := range defs {
+			switch strings.ToLower(def.Action) {
+			case "set_variable", "increment_variable", "reset_variable":
+				name := strings.TrimSpace(def.Variable)
+				if val, ok := scope[name]; ok {
+					if _, done := val.(mutableVariable); !done {
+						scope[name] = mutableVariable{start: val}
+					}
+				}
+			}
+			mark(def.Steps)
+		}
+	}
+	mark(file.Rotation)
+	for _, list := range file.ActionLists {
+		mark(list)
+	}
+	return scope
+}
+
+func initialVariables(vars map[string]any) map[string]float64 {
+	out := make(map[string]float64, len(vars))
+	for name, raw := range vars {
+		if val, err := numericValue(raw, nil); err == nil {
+			out[name] = val
+		}
+	}
+	return out
+}
+
 func resolveScalar(node *yaml.Node, vars map[string]any) (interface{}, error) {
 	if node == nil {
 		return nil, fmt.Errorf("nil scalar")
@@ -509,6 +1277,9 @@ func resolveScalar(node *yaml.Node, vars map[string]any) (interface{}, error) {
 			if !ok {
 				return nil, fmt.Errorf("variable '%s' not defined", name)
 			}
+			if mv, ok := val.(mutableVariable); ok {
+				return mv.start, nil
+			}
 			return val, nil
 		}
 	}
@@ -13,6 +13,24 @@ type EvaluationContext interface {
 	ResourcePercent(resource string) float64
 	CooldownReady(name string) bool
 	CooldownRemaining(name string) time.Duration
+	TimeElapsed() time.Duration
+	TimeRemaining() time.Duration
+	TargetHealthPercent() float64
+	CastTime(spell string) time.Duration
+	GCDRemaining() time.Duration
+	LastCast() string
+	CastsSince(spell string) int
+	TicksRemaining(debuff string) int
+	Variable(name string) float64
+	// Aura* accept any buff or debuff name; stacks are 1 for an active
+	// aura that does not stack.
+	AuraActive(name string) bool
+	AuraRemaining(name string) time.Duration
+	AuraStacks(name string) int
+	AuraMaxStacks(name string) int
+	// PetCooldownRemaining is 0 for abilities the active pet lacks and
+	// when no pet is out.
+	PetCooldownRemaining(spell string) time.Duration
 }
 
 // Condition evaluates to true/false for a given context.
@@ -163,6 +181,33 @@ func (c cooldownReadyCondition) Eval(ctx EvaluationContext) bool {
 	return ctx.CooldownReady(c.name)
 }
 
+// petCooldownReadyCondition checks if a pet ability is off cooldown.
+type petCooldownReadyCondition struct {
+	spell string
+}
+
+func (c petCooldownReadyCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return ctx.PetCooldownRemaining(c.spell) <= 0
+}
+
+type petCooldownRemainingCondition struct {
+	spell string
+	lt    *time.Duration
+	lte   *time.Duration
+	gt    *time.Duration
+	gte   *time.Duration
+}
+
+func (c petCooldownRemainingCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareDuration(ctx.PetCooldownRemaining(c.spell), c.lt, c.lte, c.gt, c.gte)
+}
+
 type cooldownRemainingCondition struct {
 	name string
 	lt   *time.Duration
@@ -241,3 +286,270 @@ func (c chargesCondition) Eval(ctx EvaluationContext) bool {
 	}
 	return true
 }
+
+// fightTimeCondition compares elapsed or remaining fight time.
+type fightTimeCondition struct {
+	remaining bool
+	lt        *time.Duration
+	lte       *time.Duration
+	gt        *time.Duration
+	gte       *time.Duration
+}
+
+func (c fightTimeCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	value := ctx.TimeElapsed()
+	if c.remaining {
+		value = ctx.TimeRemaining()
+	}
+	if c.lt != nil && !(value < *c.lt) {
+		return false
+	}
+	if c.lte != nil && !(value <= *c.lte) {
+		return false
+	}
+	if c.gt != nil && !(value > *c.gt) {
+		return false
+	}
+	if c.gte != nil && !(value >= *c.gte) {
+		return false
+	}
+	return true
+}
+
+// targetHealthCondition compares the target's health fraction (0-1).
+type targetHealthCondition struct {
+	lt  *float64
+	lte *float64
+	gt  *float64
+	gte *float64
+}
+
+func (c targetHealthCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	percent := ctx.TargetHealthPercent()
+	if c.lt != nil && !(percent < *c.lt) {
+		return false
+	}
+	if c.lte != nil && !(percent <= *c.lte) {
+		return false
+	}
+	if c.gt != nil && !(percent > *c.gt) {
+		return false
+	}
+	if c.gte != nil && !(percent >= *c.gte) {
+		return false
+	}
+	return true
+}
+
+// castTimeCondition compares the current haste/proc-adjusted cast time of a spell.
+type castTimeCondition struct {
+	spell string
+	lt    *time.Duration
+	lte   *time.Duration
+	gt    *time.Duration
+	gte   *time.Duration
+}
+
+func (c castTimeCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareDuration(ctx.CastTime(c.spell), c.lt, c.lte, c.gt, c.gte)
+}
+
+// gcdRemainingCondition compares the time left on the global cooldown.
+type gcdRemainingCondition struct {
+	lt  *time.Duration
+	lte *time.Duration
+	gt  *time.Duration
+	gte *time.Duration
+}
+
+func (c gcdRemainingCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareDuration(ctx.GCDRemaining(), c.lt, c.lte, c.gt, c.gte)
+}
+
+// lastCastCondition checks which spell was cast most recently.
+type lastCastCondition struct {
+	spell string
+}
+
+func (c lastCastCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return ctx.LastCast() == c.spell
+}
+
+// castsSinceCondition compares how many casts happened since a spell was last cast.
+type castsSinceCondition struct {
+	spell string
+	lt    *int
+	lte   *int
+	gt    *int
+	gte   *int
+}
+
+func (c castsSinceCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareInt(ctx.CastsSince(c.spell), c.lt, c.lte, c.gt, c.gte)
+}
+
+// ticksRemainingCondition compares the ticks left on a DoT.
+type ticksRemainingCondition struct {
+	debuff string
+	lt     *int
+	lte    *int
+	gt     *int
+	gte    *int
+}
+
+func (c ticksRemainingCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareInt(ctx.TicksRemaining(c.debuff), c.lt, c.lte, c.gt, c.gte)
+}
+
+// auraActiveCondition checks any buff or debuff, optionally within a
+// remaining-time window.
+type auraActiveCondition struct {
+	name         string
+	minRemaining *time.Duration
+	maxRemaining *time.Duration
+}
+
+func (c auraActiveCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil || !ctx.AuraActive(c.name) {
+		return false
+	}
+	remaining := ctx.AuraRemaining(c.name)
+	if c.minRemaining != nil && remaining < *c.minRemaining {
+		return false
+	}
+	if c.maxRemaining != nil && remaining > *c.maxRemaining {
+		return false
+	}
+	return true
+}
+
+// auraRemainingCondition compares an aura's remaining duration; 0 when down.
+type auraRemainingCondition struct {
+	name string
+	lt   *time.Duration
+	lte  *time.Duration
+	gt   *time.Duration
+	gte  *time.Duration
+}
+
+func (c auraRemainingCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareDuration(ctx.AuraRemaining(c.name), c.lt, c.lte, c.gt, c.gte)
+}
+
+// auraStacksCondition compares an aura's stack count; 0 when down.
+type auraStacksCondition struct {
+	name string
+	lt   *int
+	lte  *int
+	gt   *int
+	gte  *int
+}
+
+func (c auraStacksCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return compareInt(ctx.AuraStacks(c.name), c.lt, c.lte, c.gt, c.gte)
+}
+
+// auraAtMaxStacksCondition is true while an aura is up at its stack cap.
+type auraAtMaxStacksCondition struct {
+	name string
+}
+
+func (c auraAtMaxStacksCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	stacks := ctx.AuraStacks(c.name)
+	return stacks > 0 && stacks >= ctx.AuraMaxStacks(c.name)
+}
+
+func compareDuration(value time.Duration, lt, lte, gt, gte *time.Duration) bool {
+	if lt != nil && !(value < *lt) {
+		return false
+	}
+	if lte != nil && !(value <= *lte) {
+		return false
+	}
+	if gt != nil && !(value > *gt) {
+		return false
+	}
+	if gte != nil && !(value >= *gte) {
+		return false
+	}
+	return true
+}
+
+func compareInt(value int, lt, lte, gt, gte *int) bool {
+	if lt != nil && !(value < *lt) {
+		return false
+	}
+	if lte != nil && !(value <= *lte) {
+		return false
+	}
+	if gt != nil && !(value > *gt) {
+		return false
+	}
+	if gte != nil && !(value >= *gte) {
+		return false
+	}
+	return true
+}
+
+// variableCondition compares the live value of a runtime variable.
+type variableCondition struct {
+	name string
+	eq   *float64
+	lt   *float64
+	lte  *float64
+	gt   *float64
+	gte  *float64
+}
+
+func (c variableCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	value := ctx.Variable(c.name)
+	if c.eq != nil && value != *c.eq {
+		return false
+	}
+	if c.lt != nil && !(value < *c.lt) {
+		return false
+	}
+	if c.lte != nil && !(value <= *c.lte) {
+		return false
+	}
+	if c.gt != nil && !(value > *c.gt) {
+		return false
+	}
+	if c.gte != nil && !(value >= *c.gte) {
+		return false
+	}
+	return true
+}
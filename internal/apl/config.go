@@ -9,6 +9,21 @@ type File struct {
 	Imports     []string           `yaml:"imports"`
 	Variables   map[string]any     `yaml:"variables"`
 	Rotation    []ActionDefinition `yaml:"rotation"`
+	// ActionLists holds named sub-lists for call_action_list/run_action_list.
+	// The reserved "precombat" list runs once before the pull.
+	ActionLists map[string][]ActionDefinition `yaml:"action_lists,omitempty"`
+	// Phases pick an action list for parts of the encounter; the first phase
+	// that matches replaces the rotation list for that decision.
+	Phases []PhaseDefinition `yaml:"phases,omitempty"`
+}
+
+// PhaseDefinition selects an action list while an encounter phase marker is
+// active and/or a condition holds. At least one of marker and when is needed.
+type PhaseDefinition struct {
+	Name   string         `yaml:"name"`
+	Marker string         `yaml:"marker,omitempty"` // name from simulation.phases in player.yaml
+	When   *ConditionNode `yaml:"when,omitempty"`
+	List   string         `yaml:"list,omitempty"` // defaults to name
 }
 
 // ActionDefinition describes one entry in the priority list.
@@ -16,10 +31,18 @@ type ActionDefinition struct {
 	Action          string             `yaml:"action"`
 	Spell           string             `yaml:"spell,omitempty"`
 	Item            string             `yaml:"item,omitempty"`
+	Buff            string             `yaml:"buff,omitempty"` // cancel_buff
+	List            string             `yaml:"list,omitempty"`
+	Variable        string             `yaml:"variable,omitempty"`
+	Value           any                `yaml:"value,omitempty"`
 	DurationSeconds float64            `yaml:"duration_seconds,omitempty"`
 	Steps           []ActionDefinition `yaml:"steps,omitempty"`
 	Tags            []string           `yaml:"tags,omitempty"`
 	When            *ConditionNode     `yaml:"when,omitempty"`
+	Until           *ConditionNode     `yaml:"until,omitempty"`           // wait_until
+	TimeoutSeconds  float64            `yaml:"timeout_seconds,omitempty"` // wait_until
+	ResetWhen       *ConditionNode     `yaml:"reset_when,omitempty"`      // sequence
+	InterruptIf     *ConditionNode     `yaml:"interrupt_if,omitempty"`    // cast_spell
 }
 
 // ConditionNode captures the raw YAML tree for conditions.
@@ -42,6 +65,14 @@ func (c *ConditionNode) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// MarshalYAML writes the condition tree back out unchanged.
+func (c *ConditionNode) MarshalYAML() (any, error) {
+	if c == nil || c.raw == nil {
+		return nil, nil
+	}
+	return c.raw, nil
+}
+
 // NewConditionNode wraps a YAML condition node.
 func NewConditionNode(node *yaml.Node) *ConditionNode {
 	return &ConditionNode{raw: node}
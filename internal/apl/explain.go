@@ -0,0 +1,271 @@
+package apl
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Explain describes why c is false in ctx, naming the first sub-condition
+// that failed and its current value, e.g. "dot_remaining(immolate)=4.2s not < 0.5s".
+// It returns "" when c holds.
+func Explain(c Condition, ctx EvaluationContext) string {
+	if c == nil || c.Eval(ctx) {
+		return ""
+	}
+	switch v := c.(type) {
+	case falseCondition:
+		return "false"
+	case allCondition:
+		for _, child := range v.children {
+			if why := Explain(child, ctx); why != "" {
+				return why
+			}
+		}
+	case anyCondition:
+		reasons := make([]string, 0, len(v.children))
+		for _, child := range v.children {
+			reasons = append(reasons, Explain(child, ctx))
+		}
+		return "none of [" + strings.Join(reasons, "; ") + "]"
+	case notCondition:
+		return "not: " + describeTrue(v.child, ctx)
+	case exprCondition:
+		return explainExpr(v.root, ctx)
+	case buffActiveCondition:
+		return explainAura("buff", v.name, ctx.BuffActive(v.name), ctx.BuffRemaining(v.name), v.minRemaining, v.maxRemaining)
+	case debuffActiveCondition:
+		return explainAura("debuff", v.name, ctx.DebuffActive(v.name), ctx.DebuffRemaining(v.name), v.minRemaining, v.maxRemaining)
+	case auraActiveCondition:
+		return explainAura("aura", v.name, ctx.AuraActive(v.name), ctx.AuraRemaining(v.name), v.minRemaining, v.maxRemaining)
+	case auraRemainingCondition:
+		return explainDuration("aura_remaining("+v.name+")", ctx.AuraRemaining(v.name), v.lt, v.lte, v.gt, v.gte)
+	case auraStacksCondition:
+		return explainInt("aura_stacks("+v.name+")", ctx.AuraStacks(v.name), v.lt, v.lte, v.gt, v.gte)
+	case auraAtMaxStacksCondition:
+		return fmt.Sprintf("aura_stacks(%s)=%d not at max %d", v.name, ctx.AuraStacks(v.name), ctx.AuraMaxStacks(v.name))
+	case dotRemainingCondition:
+		return explainDuration("dot_remaining("+v.spell+")", ctx.DebuffRemaining(v.spell), v.lt, v.lte, v.gt, v.gte)
+	case resourcePercentCondition:
+		return explainFloat("resource_percent("+v.resource+")", ctx.ResourcePercent(v.resource), v.lt, v.lte, v.gt, v.gte)
+	case cooldownReadyCondition:
+		return fmt.Sprintf("cooldown_ready(%s) is false (%s left)", v.name, formatSeconds(ctx.CooldownRemaining(v.name)))
+	case cooldownRemainingCondition:
+		return explainDuration("cooldown_remaining("+v.name+")", ctx.CooldownRemaining(v.name), v.lt, v.lte, v.gt, v.gte)
+	case petCooldownReadyCondition:
+		return fmt.Sprintf("pet_cooldown_ready(%s) is false (%s left)", v.spell, formatSeconds(ctx.PetCooldownRemaining(v.spell)))
+	case petCooldownRemainingCondition:
+		return explainDuration("pet_cooldown_remaining("+v.spell+")", ctx.PetCooldownRemaining(v.spell), v.lt, v.lte, v.gt, v.gte)
+	case chargesCondition:
+		return explainInt("charges("+v.buff+")", ctx.BuffCharges(v.buff), v.lt, v.lte, v.gt, v.gte)
+	case fightTimeCondition:
+		if v.remaining {
+			return explainDuration("time_remaining", ctx.TimeRemaining(), v.lt, v.lte, v.gt, v.gte)
+		}
+		return explainDuration("time_elapsed", ctx.TimeElapsed(), v.lt, v.lte, v.gt, v.gte)
+	case targetHealthCondition:
+		return explainFloat("target_health_percent", ctx.TargetHealthPercent(), v.lt, v.lte, v.gt, v.gte)
+	case castTimeCondition:
+		return explainDuration("cast_time("+v.spell+")", ctx.CastTime(v.spell), v.lt, v.lte, v.gt, v.gte)
+	case gcdRemainingCondition:
+		return explainDuration("gcd_remaining", ctx.GCDRemaining(), v.lt, v.lte, v.gt, v.gte)
+	case lastCastCondition:
+		last := ctx.LastCast()
+		if last == "" {
+			last = "nothing"
+		}
+		return fmt.Sprintf("last_cast(%s) is false (last was %s)", v.spell, last)
+	case castsSinceCondition:
+		return explainInt("casts_since("+v.spell+")", ctx.CastsSince(v.spell), v.lt, v.lte, v.gt, v.gte)
+	case ticksRemainingCondition:
+		return explainInt("ticks_remaining("+v.debuff+")", ctx.TicksRemaining(v.debuff), v.lt, v.lte, v.gt, v.gte)
+	case variableCondition:
+		value := ctx.Variable(v.name)
+		if v.eq != nil && value != *v.eq {
+			return fmt.Sprintf("variable(%s)=%s not == %s", v.name, formatNumber(value), formatNumber(*v.eq))
+		}
+		return explainFloat("variable("+v.name+")", value, v.lt, v.lte, v.gt, v.gte)
+	}
+	return fmt.Sprintf("%T is false", c)
+}
+
+// describeTrue names the part of a negated condition that held.
+func describeTrue(c Condition, ctx EvaluationContext) string {
+	switch v := c.(type) {
+	case buffActiveCondition:
+		return fmt.Sprintf("buff_active(%s) is true (%s left)", v.name, formatSeconds(ctx.BuffRemaining(v.name)))
+	case debuffActiveCondition:
+		return fmt.Sprintf("debuff_active(%s) is true (%s left)", v.name, formatSeconds(ctx.DebuffRemaining(v.name)))
+	case auraActiveCondition:
+		return fmt.Sprintf("aura_active(%s) is true (%s left)", v.name, formatSeconds(ctx.AuraRemaining(v.name)))
+	case auraAtMaxStacksCondition:
+		return fmt.Sprintf("aura_at_max_stacks(%s) is true (%d stacks)", v.name, ctx.AuraStacks(v.name))
+	case cooldownReadyCondition:
+		return fmt.Sprintf("cooldown_ready(%s) is true", v.name)
+	case petCooldownReadyCondition:
+		return fmt.Sprintf("pet_cooldown_ready(%s) is true", v.spell)
+	case lastCastCondition:
+		return fmt.Sprintf("last_cast(%s) is true", v.spell)
+	case exprCondition:
+		return exprString(v.root) + " is true"
+	}
+	return fmt.Sprintf("%T is true", c)
+}
+
+func explainAura(kind, name string, active bool, remaining time.Duration, min, max *time.Duration) string {
+	if !active {
+		return fmt.Sprintf("%s_active(%s) is false", kind, name)
+	}
+	subject := kind + "_remaining(" + name + ")"
+	if min != nil && remaining < *min {
+		return fmt.Sprintf("%s=%s not >= %s", subject, formatSeconds(remaining), formatSeconds(*min))
+	}
+	if max != nil && remaining > *max {
+		return fmt.Sprintf("%s=%s not <= %s", subject, formatSeconds(remaining), formatSeconds(*max))
+	}
+	return kind + "_active(" + name + ") is false"
+}
+
+func explainDuration(subject string, value time.Duration, lt, lte, gt, gte *time.Duration) string {
+	check := func(op string, bound *time.Duration, ok bool) string {
+		if bound == nil || ok {
+			return ""
+		}
+		return fmt.Sprintf("%s=%s not %s %s", subject, formatSeconds(value), op, formatSeconds(*bound))
+	}
+	return firstReason(
+		check("<", lt, lt != nil && value < *lt),
+		check("<=", lte, lte != nil && value <= *lte),
+		check(">", gt, gt != nil && value > *gt),
+		check(">=", gte, gte != nil && value >= *gte),
+	)
+}
+
+func explainFloat(subject string, value float64, lt, lte, gt, gte *float64) string {
+	check := func(op string, bound *float64, ok bool) string {
+		if bound == nil || ok {
+			return ""
+		}
+		return fmt.Sprintf("%s=%s not %s %s", subject, formatNumber(value), op, formatNumber(*bound))
+	}
+	return firstReason(
+		check("<", lt, lt != nil && value < *lt),
+		check("<=", lte, lte != nil && value <= *lte),
+		check(">", gt, gt != nil && value > *gt),
+		check(">=", gte, gte != nil && value >= *gte),
+	)
+}
+
+func explainInt(subject string, value int, lt, lte, gt, gte *int) string {
+	check := func(op string, bound *int, ok bool) string {
+		if bound == nil || ok {
+			return ""
+		}
+		return fmt.Sprintf("%s=%d not %s %d", subject, value, op, *bound)
+	}
+	return firstReason(
+		check("<", lt, lt != nil && value < *lt),
+		check("<=", lte, lte != nil && value <= *lte),
+		check(">", gt, gt != nil && value > *gt),
+		check(">=", gte, gte != nil && value >= *gte),
+	)
+}
+
+func firstReason(reasons ...string) string {
+	for _, r := range reasons {
+		if r != "" {
+			return r
+		}
+	}
+	return ""
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 1, 64) + "s"
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// explainExpr finds the failing part of a false boolean expression.
+func explainExpr(node boolExpr, ctx EvaluationContext) string {
+	switch v := node.(type) {
+	case andExpr:
+		if !v.left.evalBool(ctx) {
+			return explainExpr(v.left, ctx)
+		}
+		return explainExpr(v.right, ctx)
+	case orExpr:
+		return "none of [" + explainExpr(v.left, ctx) + "; " + explainExpr(v.right, ctx) + "]"
+	case notExpr:
+		return "not: " + exprString(v.operand) + " is true"
+	case compareExpr:
+		format := formatNumber
+		if secondsExpr(v.left) || secondsExpr(v.right) {
+			format = func(f float64) string { return formatSeconds(time.Duration(f * float64(time.Second))) }
+		}
+		rightText := format(v.right.evalNum(ctx))
+		if _, literal := v.right.(numberLiteral); !literal {
+			rightText = exprString(v.right) + "=" + rightText
+		}
+		return fmt.Sprintf("%s=%s not %s %s", exprString(v.left), format(v.left.evalNum(ctx)), v.op, rightText)
+	}
+	return exprString(node) + " is false"
+}
+
+// exprString renders an expression tree back into the expression language.
+func exprString(node exprNode) string {
+	switch v := node.(type) {
+	case numberLiteral:
+		return formatNumber(float64(v))
+	case boolLiteral:
+		return strconv.FormatBool(bool(v))
+	case numCallExpr:
+		return callString(v.name, v.args)
+	case boolCallExpr:
+		return callString(v.name, v.args)
+	case negateExpr:
+		return "-" + exprString(v.operand)
+	case notExpr:
+		return "not " + exprString(v.operand)
+	case arithExpr:
+		return "(" + exprString(v.left) + " " + string(v.op) + " " + exprString(v.right) + ")"
+	case compareExpr:
+		return exprString(v.left) + " " + v.op + " " + exprString(v.right)
+	case boolEqualExpr:
+		op := "=="
+		if v.negate {
+			op = "!="
+		}
+		return "(" + exprString(v.left) + " " + op + " " + exprString(v.right) + ")"
+	case andExpr:
+		return "(" + exprString(v.left) + " and " + exprString(v.right) + ")"
+	case orExpr:
+		return "(" + exprString(v.left) + " or " + exprString(v.right) + ")"
+	}
+	return "?"
+}
+
+// secondsExpr reports whether a numeric expression is a duration in seconds.
+func secondsExpr(node exprNode) bool {
+	call, ok := node.(numCallExpr)
+	if !ok {
+		return false
+	}
+	switch call.name {
+	case "debuff_remaining", "dot_remaining", "buff_remaining", "cooldown_remaining", "cast_time",
+		"pet_cooldown_remaining", "time_elapsed", "time_remaining", "gcd_remaining":
+		return true
+	}
+	return false
+}
+
+func callString(name string, args []string) string {
+	if _, ok := exprIdentifiers[name]; ok {
+		return name
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
@@ -0,0 +1,908 @@
+package apl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expressions let a `when:` clause be written inline, e.g.
+//
+//	when: "debuff_remaining(immolate) < 1.5 and mana_pct > 0.2"
+//
+// They are parsed and type-checked once at compile time; evaluation only walks the tree.
+// Numbers are float64 (durations are seconds); booleans come from comparisons,
+// logical operators and boolean functions.
+
+type exprType int
+
+const (
+	exprNumber exprType = iota
+	exprBool
+)
+
+func (t exprType) String() string {
+	if t == exprBool {
+		return "bool"
+	}
+	return "number"
+}
+
+// exprArgKind tells the compiler how to validate an identifier argument.
+type exprArgKind int
+
+const (
+	argSpell exprArgKind = iota
+	argBuff
+	argDebuff
+	argResource
+	argCooldown
+	argVariable
+	argAura     // any buff or debuff
+	argPetSpell // pet ability
+)
+
+func (k exprArgKind) validate(name string, vars map[string]any) (string, error) {
+	switch k {
+	case argVariable:
+		return validateRuntimeVariable(name, vars)
+	case argSpell:
+		return validateSpellName(name)
+	case argBuff:
+		return validateBuffName(name)
+	case argDebuff:
+		return validateDebuffName(name)
+	case argResource:
+		return validateResourceName(name)
+	case argCooldown:
+		return validateCooldownName(name)
+	case argAura:
+		return validateAuraName(name)
+	case argPetSpell:
+		return validatePetSpellName(name)
+	default:
+		return "", fmt.Errorf("unknown argument kind")
+	}
+}
+
+// exprFunction describes a function callable from expressions.
+// Exactly one of num/boolean is set, matching result.
+type exprFunction struct {
+	args    []exprArgKind
+	result  exprType
+	num     func(ctx EvaluationContext, args []string) float64
+	boolean func(ctx EvaluationContext, args []string) bool
+}
+
+var exprFunctions = map[string]exprFunction{
+	"debuff_remaining": {
+		args:   []exprArgKind{argDebuff},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.DebuffRemaining(args[0]).Seconds()
+		},
+	},
+	"dot_remaining": {
+		args:   []exprArgKind{argDebuff},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.DebuffRemaining(args[0]).Seconds()
+		},
+	},
+	"debuff_active": {
+		args:   []exprArgKind{argDebuff},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.DebuffActive(args[0])
+		},
+	},
+	"buff_remaining": {
+		args:   []exprArgKind{argBuff},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.BuffRemaining(args[0]).Seconds()
+		},
+	},
+	"buff_active": {
+		args:   []exprArgKind{argBuff},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.BuffActive(args[0])
+		},
+	},
+	"buff_charges": {
+		args:   []exprArgKind{argBuff},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return float64(ctx.BuffCharges(args[0]))
+		},
+	},
+	"cooldown_remaining": {
+		args:   []exprArgKind{argCooldown},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.CooldownRemaining(args[0]).Seconds()
+		},
+	},
+	"cooldown_ready": {
+		args:   []exprArgKind{argCooldown},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.CooldownReady(args[0])
+		},
+	},
+	"pet_cooldown_remaining": {
+		args:   []exprArgKind{argPetSpell},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.PetCooldownRemaining(args[0]).Seconds()
+		},
+	},
+	"pet_cooldown_ready": {
+		args:   []exprArgKind{argPetSpell},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.PetCooldownRemaining(args[0]) <= 0
+		},
+	},
+	"resource_pct": {
+		args:   []exprArgKind{argResource},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.ResourcePercent(args[0])
+		},
+	},
+	"variable": {
+		args:   []exprArgKind{argVariable},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.Variable(args[0])
+		},
+	},
+	"cast_time": {
+		args:   []exprArgKind{argSpell},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.CastTime(args[0]).Seconds()
+		},
+	},
+	"last_cast": {
+		args:   []exprArgKind{argSpell},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.LastCast() == args[0]
+		},
+	},
+	"casts_since": {
+		args:   []exprArgKind{argSpell},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return float64(ctx.CastsSince(args[0]))
+		},
+	},
+	"ticks_remaining": {
+		args:   []exprArgKind{argDebuff},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return float64(ctx.TicksRemaining(args[0]))
+		},
+	},
+	"aura_active": {
+		args:   []exprArgKind{argAura},
+		result: exprBool,
+		boolean: func(ctx EvaluationContext, args []string) bool {
+			return ctx.AuraActive(args[0])
+		},
+	},
+	"aura_remaining": {
+		args:   []exprArgKind{argAura},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return ctx.AuraRemaining(args[0]).Seconds()
+		},
+	},
+	"aura_stacks": {
+		args:   []exprArgKind{argAura},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return float64(ctx.AuraStacks(args[0]))
+		},
+	},
+	"aura_max_stacks": {
+		args:   []exprArgKind{argAura},
+		result: exprNumber,
+		num: func(ctx EvaluationContext, args []string) float64 {
+			return float64(ctx.AuraMaxStacks(args[0]))
+		},
+	},
+}
+
+// ExpressionFunctions returns the names of functions usable in expressions.
+func ExpressionFunctions() []string {
+	out := make([]string, 0, len(exprFunctions))
+	for name := range exprFunctions {
+		out = append(out, name)
+	}
+	return out
+}
+
+// exprCondition adapts a boolean expression to the Condition interface.
+type exprCondition struct {
+	source string
+	root   boolExpr
+	refs   []exprRef // names referenced, for static analysis
+}
+
+// exprRef is one spell/buff/debuff/resource/variable name used by an expression.
+type exprRef struct {
+	kind exprArgKind
+	name string
+}
+
+func (c exprCondition) Eval(ctx EvaluationContext) bool {
+	if ctx == nil {
+		return false
+	}
+	return c.root.evalBool(ctx)
+}
+
+// compileExpression parses and type-checks an expression that must yield a boolean.
+func compileExpression(source string, vars map[string]any) (Condition, error) {
+	node, refs, err := parseExpression(source, vars)
+	if err != nil {
+		return nil, err
+	}
+	root, ok := node.(boolExpr)
+	if !ok {
+		return nil, fmt.Errorf("expression %q: column 1: condition must be boolean, got %s", source, node.kind())
+	}
+	return exprCondition{source: source, root: root, refs: refs}, nil
+}
+
+func parseExpression(source string, vars map[string]any) (exprNode, []exprRef, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, nil, fmt.Errorf("expression %q: %w", source, err)
+	}
+	p := &exprParser{tokens: tokens, vars: vars}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, nil, fmt.Errorf("expression %q: %w", source, err)
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, nil, fmt.Errorf("expression %q: column %d: unexpected %s", source, tok.col, tok)
+	}
+	return node, p.refs, nil
+}
+
+// --- lexer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+)
+
+type exprToken struct {
+	kind tokenKind
+	text string // identifiers are lowercased
+	raw  string // identifier as written, for matching declared variables
+	num  float64
+	col  int
+}
+
+func (t exprToken) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokNumber:
+		return fmt.Sprintf("number %s", t.text)
+	case tokIdent:
+		return fmt.Sprintf("identifier '%s'", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+func lexExpression(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		col := i + 1
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			text := src[start:i]
+			val, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, fmt.Errorf("column %d: invalid number '%s'", col, text)
+			}
+			tokens = append(tokens, exprToken{kind: tokNumber, text: text, num: val, col: col})
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && isIdentPart(src[i]) {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokIdent, text: strings.ToLower(src[start:i]), raw: src[start:i], col: col})
+		case c == '$' && i+1 < len(src) && src[i+1] == '{':
+			end := strings.IndexByte(src[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("column %d: unterminated variable reference", col)
+			}
+			name := strings.TrimSpace(src[i+2 : i+end])
+			tokens = append(tokens, exprToken{kind: tokIdent, text: name, raw: name, col: col})
+			i += end + 1
+		case c == '(':
+			tokens = append(tokens, exprToken{kind: tokLParen, text: "(", col: col})
+			i++
+		case c == ')':
+			tokens = append(tokens, exprToken{kind: tokRParen, text: ")", col: col})
+			i++
+		case c == ',':
+			tokens = append(tokens, exprToken{kind: tokComma, text: ",", col: col})
+			i++
+		default:
+			op := ""
+			if i+1 < len(src) {
+				switch src[i : i+2] {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					op = src[i : i+2]
+				}
+			}
+			if op == "" {
+				switch c {
+				case '<', '>', '+', '-', '*', '/', '!':
+					op = string(c)
+				default:
+					return nil, fmt.Errorf("column %d: unexpected character '%c'", col, c)
+				}
+			}
+			tokens = append(tokens, exprToken{kind: tokOp, text: op, col: col})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, exprToken{kind: tokEOF, col: len(src) + 1})
+	return tokens, nil
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool { return isIdentStart(c) || isDigit(c) }
+
+// --- parser ---
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	vars   map[string]any
+	refs   []exprRef
+}
+
+func (p *exprParser) peek() exprToken { return p.tokens[p.pos] }
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isOp reports whether the current token is one of the given operators or keywords.
+func (p *exprParser) isOp(ops ...string) bool {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("or", "||") {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := requireBools(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left: l, right: r}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("and", "&&") {
+		tok := p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := requireBools(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left: l, right: r}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (exprNode, error) {
+	if p.isOp("not", "!") {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		b, ok := operand.(boolExpr)
+		if !ok {
+			return nil, fmt.Errorf("column %d: '%s' needs a boolean operand, got %s", tok.col, tok.text, operand.kind())
+		}
+		return notExpr{operand: b}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("<", "<=", ">", ">=", "==", "!=") {
+		return left, nil
+	}
+	tok := p.next()
+	right, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if left.kind() != right.kind() {
+		return nil, fmt.Errorf("column %d: cannot compare %s with %s", tok.col, left.kind(), right.kind())
+	}
+	if left.kind() == exprBool {
+		if tok.text != "==" && tok.text != "!=" {
+			return nil, fmt.Errorf("column %d: '%s' needs numeric operands", tok.col, tok.text)
+		}
+		return boolEqualExpr{left: left.(boolExpr), right: right.(boolExpr), negate: tok.text == "!="}, nil
+	}
+	return compareExpr{op: tok.text, left: left.(numExpr), right: right.(numExpr)}, nil
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+", "-") {
+		tok := p.next()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := requireNumbers(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+		left = arithExpr{op: tok.text[0], left: l, right: r}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*", "/") {
+		tok := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l, r, err := requireNumbers(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if divisor, ok := constantValue(r); ok && tok.text == "/" && divisor == 0 {
+			return nil, fmt.Errorf("column %d: division by zero", tok.col)
+		}
+		left = arithExpr{op: tok.text[0], left: l, right: r}
+	}
+	return left, nil
+}
+
+// constantValue folds a numeric expression made only of literals (declared
+// variables are literals by now).
+func constantValue(node numExpr) (float64, bool) {
+	switch v := node.(type) {
+	case numberLiteral:
+		return float64(v), true
+	case negateExpr:
+		val, ok := constantValue(v.operand)
+		return -val, ok
+	case arithExpr:
+		_, lok := constantValue(v.left)
+		_, rok := constantValue(v.right)
+		if !lok || !rok {
+			return 0, false
+		}
+		return v.evalNum(nil), true
+	}
+	return 0, false
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if p.isOp("-") {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		n, ok := operand.(numExpr)
+		if !ok {
+			return nil, fmt.Errorf("column %d: unary '-' needs a number, got %s", tok.col, operand.kind())
+		}
+		return negateExpr{operand: n}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return numberLiteral(tok.num), nil
+	case tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("column %d: expected ')', got %s", closing.col, closing)
+		}
+		return inner, nil
+	case tokIdent:
+		if p.peek().kind == tokLParen {
+			return p.parseCall(tok)
+		}
+		return p.resolveIdentifier(tok)
+	default:
+		return nil, fmt.Errorf("column %d: unexpected %s", tok.col, tok)
+	}
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	fn, ok := exprFunctions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("column %d: unknown function '%s'", name.col, name.text)
+	}
+	p.next() // (
+	var args []exprToken
+	if p.peek().kind != tokRParen {
+		for {
+			arg := p.next()
+			if arg.kind != tokIdent {
+				return nil, fmt.Errorf("column %d: %s expects a name argument, got %s", arg.col, name.text, arg)
+			}
+			args = append(args, arg)
+			if p.peek().kind != tokComma {
+				break
+			}
+			p.next()
+		}
+	}
+	if closing := p.next(); closing.kind != tokRParen {
+		return nil, fmt.Errorf("column %d: expected ')' after arguments to %s, got %s", closing.col, name.text, closing)
+	}
+	if len(args) != len(fn.args) {
+		return nil, fmt.Errorf("column %d: %s takes %d argument(s), got %d", name.col, name.text, len(fn.args), len(args))
+	}
+	resolved := make([]string, len(args))
+	for i, arg := range args {
+		text := arg.text
+		if fn.args[i] == argVariable {
+			text = p.variableName(arg)
+		}
+		value, err := fn.args[i].validate(text, p.vars)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %s: %w", arg.col, name.text, err)
+		}
+		resolved[i] = value
+		p.refs = append(p.refs, exprRef{kind: fn.args[i], name: value})
+	}
+	if fn.result == exprBool {
+		return boolCallExpr{name: name.text, fn: fn.boolean, args: resolved}, nil
+	}
+	return numCallExpr{name: name.text, fn: fn.num, args: resolved}, nil
+}
+
+// variableName returns the declared variable tok names: its spelling as
+// written, else its lowercased form. Other identifiers are case-insensitive,
+// but variables keep the case they are declared with.
+func (p *exprParser) variableName(tok exprToken) string {
+	if _, ok := p.vars[tok.raw]; ok {
+		return tok.raw
+	}
+	return tok.text
+}
+
+// resolveIdentifier handles literals, declared variables (runtime reads for
+// those a *_variable action writes) and the `<resource>_pct` / `<buff>_charges` shorthands.
+func (p *exprParser) resolveIdentifier(tok exprToken) (exprNode, error) {
+	switch tok.text {
+	case "true":
+		return boolLiteral(true), nil
+	case "false":
+		return boolLiteral(false), nil
+	}
+	if val, ok := p.vars[p.variableName(tok)]; ok {
+		tok.text = p.variableName(tok)
+		p.refs = append(p.refs, exprRef{kind: argVariable, name: tok.text})
+		switch v := val.(type) {
+		case mutableVariable:
+			read := numCallExpr{name: "variable", fn: exprFunctions["variable"].num, args: []string{tok.text}}
+			if _, ok := v.start.(bool); ok {
+				return compareExpr{op: "!=", left: read, right: numberLiteral(0)}, nil
+			}
+			if _, err := numericValue(v.start, nil); err != nil {
+				return nil, fmt.Errorf("column %d: variable '%s' is %T, expected a number or bool", tok.col, tok.text, v.start)
+			}
+			return read, nil
+		case bool:
+			return boolLiteral(v), nil
+		case int:
+			return numberLiteral(float64(v)), nil
+		case int64:
+			return numberLiteral(float64(v)), nil
+		case uint64:
+			return numberLiteral(float64(v)), nil
+		case float64:
+			return numberLiteral(v), nil
+		default:
+			return nil, fmt.Errorf("column %d: variable '%s' is %T, expected a number or bool", tok.col, tok.text, val)
+		}
+	}
+	if fn, ok := exprIdentifiers[tok.text]; ok {
+		if fn.result == exprBool {
+			return boolCallExpr{name: tok.text, fn: fn.boolean}, nil
+		}
+		return numCallExpr{name: tok.text, fn: fn.num}, nil
+	}
+	if res, ok := strings.CutSuffix(tok.text, "_pct"); ok {
+		if name, err := validateResourceName(res); err == nil {
+			p.refs = append(p.refs, exprRef{kind: argResource, name: name})
+			return numCallExpr{name: "resource_pct", fn: exprFunctions["resource_pct"].num, args: []string{name}}, nil
+		}
+	}
+	if buff, ok := strings.CutSuffix(tok.text, "_charges"); ok {
+		if name, err := validateBuffName(buff); err == nil {
+			p.refs = append(p.refs, exprRef{kind: argBuff, name: name})
+			return numCallExpr{name: "buff_charges", fn: exprFunctions["buff_charges"].num, args: []string{name}}, nil
+		}
+	}
+	return nil, fmt.Errorf("column %d: unknown identifier '%s'", tok.col, tok.text)
+}
+
+// exprIdentifiers are zero-argument values referenced by bare name.
+var exprIdentifiers = map[string]exprFunction{
+	"time_elapsed": {
+		result: exprNumber,
+		num: func(ctx EvaluationContext, _ []string) float64 {
+			return ctx.TimeElapsed().Seconds()
+		},
+	},
+	"time_remaining": {
+		result: exprNumber,
+		num: func(ctx EvaluationContext, _ []string) float64 {
+			return ctx.TimeRemaining().Seconds()
+		},
+	},
+	"gcd_remaining": {
+		result: exprNumber,
+		num: func(ctx EvaluationContext, _ []string) float64 {
+			return ctx.GCDRemaining().Seconds()
+		},
+	},
+	"target_health_pct": {
+		result: exprNumber,
+		num: func(ctx EvaluationContext, _ []string) float64 {
+			return ctx.TargetHealthPercent()
+		},
+	},
+}
+
+func requireBools(op exprToken, left, right exprNode) (boolExpr, boolExpr, error) {
+	l, lok := left.(boolExpr)
+	r, rok := right.(boolExpr)
+	if !lok || !rok {
+		return nil, nil, fmt.Errorf("column %d: '%s' needs boolean operands, got %s and %s", op.col, op.text, left.kind(), right.kind())
+	}
+	return l, r, nil
+}
+
+func requireNumbers(op exprToken, left, right exprNode) (numExpr, numExpr, error) {
+	l, lok := left.(numExpr)
+	r, rok := right.(numExpr)
+	if !lok || !rok {
+		return nil, nil, fmt.Errorf("column %d: '%s' needs numeric operands, got %s and %s", op.col, op.text, left.kind(), right.kind())
+	}
+	return l, r, nil
+}
+
+// --- evaluation tree ---
+
+type exprNode interface {
+	kind() exprType
+}
+
+type numExpr interface {
+	exprNode
+	evalNum(ctx EvaluationContext) float64
+}
+
+type boolExpr interface {
+	exprNode
+	evalBool(ctx EvaluationContext) bool
+}
+
+type numberLiteral float64
+
+func (numberLiteral) kind() exprType                      { return exprNumber }
+func (n numberLiteral) evalNum(EvaluationContext) float64 { return float64(n) }
+
+type boolLiteral bool
+
+func (boolLiteral) kind() exprType                    { return exprBool }
+func (b boolLiteral) evalBool(EvaluationContext) bool { return bool(b) }
+
+type numCallExpr struct {
+	name string // function or identifier name, for exporters
+	fn   func(ctx EvaluationContext, args []string) float64
+	args []string
+}
+
+func (numCallExpr) kind() exprType { return exprNumber }
+func (c numCallExpr) evalNum(ctx EvaluationContext) float64 {
+	return c.fn(ctx, c.args)
+}
+
+type boolCallExpr struct {
+	name string
+	fn   func(ctx EvaluationContext, args []string) bool
+	args []string
+}
+
+func (boolCallExpr) kind() exprType { return exprBool }
+func (c boolCallExpr) evalBool(ctx EvaluationContext) bool {
+	return c.fn(ctx, c.args)
+}
+
+type negateExpr struct {
+	operand numExpr
+}
+
+func (negateExpr) kind() exprType { return exprNumber }
+func (e negateExpr) evalNum(ctx EvaluationContext) float64 {
+	return -e.operand.evalNum(ctx)
+}
+
+type arithExpr struct {
+	op          byte
+	left, right numExpr
+}
+
+func (arithExpr) kind() exprType { return exprNumber }
+func (e arithExpr) evalNum(ctx EvaluationContext) float64 {
+	l := e.left.evalNum(ctx)
+	r := e.right.evalNum(ctx)
+	switch e.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	case '/':
+		// Only a runtime divisor can be 0 here; the parser rejects constant
+		// ones. Yield 0 rather than ±Inf/NaN so comparisons stay defined.
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	}
+	return 0
+}
+
+type compareExpr struct {
+	op          string
+	left, right numExpr
+}
+
+func (compareExpr) kind() exprType { return exprBool }
+func (e compareExpr) evalBool(ctx EvaluationContext) bool {
+	l := e.left.evalNum(ctx)
+	r := e.right.evalNum(ctx)
+	switch e.op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	case ">=":
+		return l >= r
+	case "==":
+		return l == r
+	case "!=":
+		return l != r
+	}
+	return false
+}
+
+type boolEqualExpr struct {
+	left, right boolExpr
+	negate      bool
+}
+
+func (boolEqualExpr) kind() exprType { return exprBool }
+func (e boolEqualExpr) evalBool(ctx EvaluationContext) bool {
+	return (e.left.evalBool(ctx) == e.right.evalBool(ctx)) != e.negate
+}
+
+type andExpr struct {
+	left, right boolExpr
+}
+
+func (andExpr) kind() exprType { return exprBool }
+func (e andExpr) evalBool(ctx EvaluationContext) bool {
+	return e.left.evalBool(ctx) && e.right.evalBool(ctx)
+}
+
+type orExpr struct {
+	left, right boolExpr
+}
+
+func (orExpr) kind() exprType { return exprBool }
+func (e orExpr) evalBool(ctx EvaluationContext) bool {
+	return e.left.evalBool(ctx) || e.right.evalBool(ctx)
+}
+
+type notExpr struct {
+	operand boolExpr
+}
+
+func (notExpr) kind() exprType { return exprBool }
+func (e notExpr) evalBool(ctx EvaluationContext) bool {
+	return !e.operand.evalBool(ctx)
+}
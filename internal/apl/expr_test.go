@@ -0,0 +1,147 @@
+package apl
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func exprTestState() *State {
+	mana := 0.4
+	return &State{
+		Buffs:     map[string]AuraState{"backdraft": {Remaining: 10, Charges: 2}},
+		Debuffs:   map[string]AuraState{"immolate": {Remaining: 4.5}},
+		Cooldowns: map[string]float64{"chaos_bolt": 3},
+		Mana:      &mana,
+		Time:      12,
+		Variables: map[string]float64{"counter": 3},
+	}
+}
+
+var exprTestVars = map[string]any{
+	"threshold": 0.3,
+	"refresh":   2,
+	"enabled":   true,
+	"zero":      0,
+	"counter":   mutableVariable{start: 0},
+
+	"LifeTapThreshold": 0.35,
+}
+
+func TestExpressionValues(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64 // booleans are 1/0
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 3 / 2", 2},
+		{"-2 * -3", 6},
+		{"- (1 + 1)", -2},
+		{"1.25 * 4", 5},
+		{".5 + .25", 0.75},
+		{"mana_pct", 0.4},
+		{"resource_pct(mana) * 100", 40},
+		{"backdraft_charges", 2},
+		{"buff_charges(backdraft) + 1", 3},
+		{"debuff_remaining(immolate)", 4.5},
+		{"cooldown_remaining(chaos_bolt)", 3},
+		{"time_elapsed", 12},
+		{"threshold", 0.3},
+		{"${threshold} * 2", 0.6},
+		{"refresh + 1", 3},
+		{"counter", 3},
+		{"variable(counter)", 3},
+		{"mana_pct / backdraft_charges", 0.2},
+		{"time_elapsed / cooldown_remaining(conflagrate)", 0},
+		{"true", 1},
+		{"not false", 1},
+		{"enabled", 1},
+		{"enabled == true", 1},
+		{"true != false", 1},
+		{"1 < 2 and 2 < 3", 1},
+		{"1 < 2 && 3 < 2", 0},
+		{"1 > 2 or 2 > 1", 1},
+		{"1 > 2 || 2 > 3", 0},
+		{"!(1 > 2)", 1},
+		{"not 1 < 2 or true", 1},
+		{"true or false and false", 1},
+		{"(true or false) and false", 0},
+		{"mana_pct < threshold", 0},
+		{"debuff_remaining(immolate) < refresh + 3", 1},
+		{"buff_active(backdraft) and cooldown_ready(conflagrate)", 1},
+		{"cooldown_ready(chaos_bolt)", 0},
+		{"Mana_Pct >= 0.4", 1},
+		{"counter >= 1 and counter <= 3", 1},
+		{"LifeTapThreshold", 0.35},
+		{"${LifeTapThreshold}", 0.35},
+		{"Threshold", 0.3},
+	}
+	state := exprTestState()
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			node, _, err := parseExpression(tt.src, exprTestVars)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			var got float64
+			switch n := node.(type) {
+			case boolExpr:
+				if n.evalBool(state) {
+					got = 1
+				}
+			case numExpr:
+				got = n.evalNum(state)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"", "column 1: unexpected end of expression"},
+		{"mana_pct >", "column 11: unexpected end of expression"},
+		{"mana_pct > 0.2)", "column 15: unexpected ')'"},
+		{"(mana_pct > 0.2", "column 16: expected ')'"},
+		{"mana_pct @ 0.2", "column 10: unexpected character '@'"},
+		{"mana_pct = 0.2", "column 10: unexpected character '='"},
+		{"mana_pct & true", "column 10: unexpected character '&'"},
+		{"1..2 > 0", "column 1: invalid number"},
+		{"mana_pct", "column 1: condition must be boolean, got number"},
+		{"mana_pct and true", "column 10: 'and' needs boolean operands, got number and bool"},
+		{"not mana_pct", "column 1: 'not' needs a boolean"},
+		{"true + 1 > 0", "column 6: '+' needs numeric operands, got bool and number"},
+		{"-true", "column 1: unary '-' needs a number"},
+		{"true < 1", "column 6: cannot compare bool with number"},
+		{"true == 1", "column 6: cannot compare bool with number"},
+		{"mana_pct / 0 > 1", "column 10: division by zero"},
+		{"mana_pct / (1 - 1) > 1", "column 10: division by zero"},
+		{"mana_pct / zero > 1", "column 10: division by zero"},
+		{"bogus > 1", "column 1: unknown identifier 'bogus'"},
+		{"lifetapthreshold > 0", "column 1: unknown identifier 'lifetapthreshold'"},
+		{"frobnicate(mana) > 1", "column 1: unknown function 'frobnicate'"},
+		{"buff_active(not_a_buff)", "column 13: buff_active: unknown buff 'not_a_buff'"},
+		{"buff_active(backdraft, immolate)", "column 1: buff_active takes 1 argument(s), got 2"},
+		{"buff_active(1)", "column 13: buff_active expects a name argument, got number 1"},
+		{"variable(undeclared) > 1", "column 10: variable: variable 'undeclared' is not declared in variables"},
+		{"${missing} > 1", "column 1: unknown identifier 'missing'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := compileExpression(tt.src, exprTestVars)
+			if err == nil {
+				t.Fatalf("compiled, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
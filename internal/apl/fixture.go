@@ -0,0 +1,128 @@
+package apl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureSuffix names the test file kept next to a rotation:
+// destruction-default.yaml is tested by destruction-default.tests.yaml.
+const FixtureSuffix = ".tests.yaml"
+
+// Expectations that are not spell names.
+const (
+	ExpectWait = "wait" // a wait or wait_until entry acts
+	ExpectNone = "none" // nothing acts
+)
+
+// FixtureFile is the YAML layout of a rotation's test file.
+type FixtureFile struct {
+	Tests []Fixture `yaml:"tests"`
+}
+
+// Fixture pins the action a rotation picks for one synthetic state.
+type Fixture struct {
+	Name   string `yaml:"name"`
+	List   string `yaml:"list,omitempty"` // "pet" tests the pet list; default the rotation
+	State  State  `yaml:"state"`
+	Expect string `yaml:"expect"` // spell (or pet ability) name, "wait" or "none"
+}
+
+// FixtureResult is the outcome of one fixture.
+type FixtureResult struct {
+	Name     string `json:"name"`
+	Expected string `json:"expected"`
+	Got      string `json:"got"`
+	Location string `json:"location,omitempty"` // entry that acted
+	Passed   bool   `json:"passed"`
+}
+
+func (r FixtureResult) String() string {
+	if r.Passed {
+		return "PASS " + r.Name
+	}
+	got := r.Got
+	if r.Location != "" {
+		got += " (" + r.Location + ")"
+	}
+	return fmt.Sprintf("FAIL %s: expected %s, got %s", r.Name, r.Expected, got)
+}
+
+// FixturePath returns the test file path for a rotation file path.
+func FixturePath(rotationPath string) string {
+	return strings.TrimSuffix(rotationPath, filepath.Ext(rotationPath)) + FixtureSuffix
+}
+
+// LoadFixtures reads a fixture file and checks every expectation names a
+// known spell (a pet ability for the pet list), "wait" or "none".
+func LoadFixtures(path string) ([]Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file FixtureFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for idx := range file.Tests {
+		fx := &file.Tests[idx]
+		if fx.Name == "" {
+			fx.Name = fmt.Sprintf("tests[%d]", idx)
+		}
+		fx.List = normalizeName(fx.List)
+		spells := knownSpells
+		switch fx.List {
+		case "":
+		case PetList:
+			spells = knownPetSpells
+		default:
+			return nil, fmt.Errorf("%s: %s: list must be empty or '%s'", path, fx.Name, PetList)
+		}
+		fx.Expect = strings.ToLower(strings.TrimSpace(fx.Expect))
+		switch fx.Expect {
+		case "":
+			return nil, fmt.Errorf("%s: %s: expect is required", path, fx.Name)
+		case ExpectWait, ExpectNone:
+		default:
+			if _, ok := spells[fx.Expect]; !ok {
+				return nil, fmt.Errorf("%s: %s: unknown spell '%s' in expect", path, fx.Name, fx.Expect)
+			}
+		}
+	}
+	return file.Tests, nil
+}
+
+// RunFixtures evaluates each fixture's state against rot and compares the
+// selected action with the expectation.
+func RunFixtures(rot *CompiledRotation, fixtures []Fixture) []FixtureResult {
+	results := make([]FixtureResult, 0, len(fixtures))
+	for _, fx := range fixtures {
+		state := fx.State.WithDefaults(rot)
+		got := ExpectNone
+		var sel Selection
+		var ok bool
+		if fx.List == PetList {
+			sel, ok = rot.SelectPet(&state, state.PetCastable)
+		} else {
+			sel, ok = rot.Select(&state, state.Castable)
+		}
+		if ok {
+			got = sel.Spell()
+			if got == "" {
+				got = ExpectWait
+			}
+		}
+		results = append(results, FixtureResult{
+			Name:     fx.Name,
+			Expected: fx.Expect,
+			Got:      got,
+			Location: sel.Location,
+			Passed:   got == fx.Expect,
+		})
+	}
+	return results
+}
@@ -0,0 +1,291 @@
+package apl
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldKind says which values a condition or action field accepts.
+type FieldKind string
+
+const (
+	FieldSpell      FieldKind = "spell"      // name from KnownSpells
+	FieldBuff       FieldKind = "buff"       // name from KnownBuffs
+	FieldDebuff     FieldKind = "debuff"     // name from KnownDebuffs
+	FieldAura       FieldKind = "aura"       // name from KnownAuras
+	FieldResource   FieldKind = "resource"   // name from KnownResources
+	FieldPetSpell   FieldKind = "pet_spell"  // name from KnownPetSpells
+	FieldItem       FieldKind = "item"       // free-form item name
+	FieldList       FieldKind = "list"       // action list name
+	FieldVariable   FieldKind = "variable"   // runtime variable declared under variables:
+	FieldSeconds    FieldKind = "seconds"    // number of seconds
+	FieldNumber     FieldKind = "number"     // plain number
+	FieldFraction   FieldKind = "fraction"   // number between 0 and 1
+	FieldCount      FieldKind = "count"      // integer
+	FieldValue      FieldKind = "value"      // number or boolean
+	FieldExpression FieldKind = "expression" // expression string, see expr.go
+	FieldCondition  FieldKind = "condition"  // nested condition
+	FieldConditions FieldKind = "conditions" // list of conditions
+	FieldActions    FieldKind = "actions"    // list of actions
+	FieldFlag       FieldKind = "flag"       // any value; only the key matters
+)
+
+// Field is one named parameter of a condition or action.
+type Field struct {
+	Name     string
+	Kind     FieldKind
+	Required bool
+}
+
+// ConditionSpec describes one condition key of the YAML grammar. Conditions
+// whose Body is empty take a mapping of Fields; the others (all, any, not,
+// expr, true, false) take a single value of kind Body.
+type ConditionSpec struct {
+	Key        string
+	Doc        string
+	Body       FieldKind
+	Fields     []Field
+	AtLeastOne []string // at least one of these fields must be set
+}
+
+// ActionSpec describes one action name of the YAML grammar. Every action also
+// accepts the common when and tags fields.
+type ActionSpec struct {
+	Name   string
+	Doc    string
+	Fields []Field
+}
+
+var (
+	secondsBounds = []string{"lt_seconds", "lte_seconds", "gt_seconds", "gte_seconds"}
+	plainBounds   = []string{"lt", "lte", "gt", "gte"}
+)
+
+func boundFields(names []string, kind FieldKind) []Field {
+	out := make([]Field, len(names))
+	for i, name := range names {
+		out[i] = Field{Name: name, Kind: kind}
+	}
+	return out
+}
+
+func withBounds(fields []Field, names []string, kind FieldKind) []Field {
+	return append(fields, boundFields(names, kind)...)
+}
+
+// conditionSpecs is the condition grammar. parseConditionMapping rejects keys
+// and fields that are not listed here, so a new condition must be added to
+// this table before it can be parsed.
+var conditionSpecs = []ConditionSpec{
+	{Key: "all", Doc: "True when every nested condition holds.", Body: FieldConditions},
+	{Key: "any", Doc: "True when at least one nested condition holds.", Body: FieldConditions},
+	{Key: "not", Doc: "Negates the nested condition.", Body: FieldCondition},
+	{Key: "true", Doc: "Always true.", Body: FieldFlag},
+	{Key: "false", Doc: "Always false.", Body: FieldFlag},
+	{Key: "expr", Doc: "Expression such as 'debuff_remaining(immolate) < 1.5 and mana_pct > 0.2'.", Body: FieldExpression},
+	{
+		Key:    "debuff_active",
+		Doc:    "Debuff is on the target, optionally within a remaining-time window.",
+		Fields: []Field{{Name: "debuff", Kind: FieldDebuff, Required: true}, {Name: "min_remaining", Kind: FieldSeconds}, {Name: "max_remaining", Kind: FieldSeconds}},
+	},
+	{
+		Key:    "dot_remaining",
+		Doc:    "Remaining duration of a DoT; a missing DoT counts as 0s.",
+		Fields: withBounds([]Field{{Name: "spell", Kind: FieldDebuff, Required: true}}, secondsBounds, FieldSeconds),
+	},
+	{
+		Key:    "buff_active",
+		Doc:    "Buff is up, optionally within a remaining-time window.",
+		Fields: []Field{{Name: "buff", Kind: FieldBuff, Required: true}, {Name: "min_remaining", Kind: FieldSeconds}, {Name: "max_remaining", Kind: FieldSeconds}},
+	},
+	{
+		Key:    "resource_percent",
+		Doc:    "Resource as a fraction of its maximum (0.2 is 20%).",
+		Fields: withBounds([]Field{{Name: "resource", Kind: FieldResource, Required: true}}, plainBounds, FieldNumber),
+	},
+	{
+		Key:        "cooldown_ready",
+		Doc:        "Spell or item cooldown is off cooldown.",
+		Fields:     []Field{{Name: "spell", Kind: FieldSpell}, {Name: "item", Kind: FieldItem}},
+		AtLeastOne: []string{"spell", "item"},
+	},
+	{
+		Key:        "cooldown_remaining",
+		Doc:        "Remaining cooldown of a spell or item.",
+		Fields:     withBounds([]Field{{Name: "spell", Kind: FieldSpell}, {Name: "item", Kind: FieldItem}}, secondsBounds, FieldSeconds),
+		AtLeastOne: []string{"spell", "item"},
+	},
+	{
+		Key:    "pet_cooldown_ready",
+		Doc:    "Pet ability is off cooldown; true for abilities the pet lacks.",
+		Fields: []Field{{Name: "spell", Kind: FieldPetSpell, Required: true}},
+	},
+	{
+		Key:        "pet_cooldown_remaining",
+		Doc:        "Remaining cooldown of a pet ability; 0 for abilities the pet lacks.",
+		Fields:     withBounds([]Field{{Name: "spell", Kind: FieldPetSpell, Required: true}}, secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:    "charges",
+		Doc:    "Stacks or charges of a buff.",
+		Fields: withBounds([]Field{{Name: "buff", Kind: FieldBuff, Required: true}}, plainBounds, FieldCount),
+	},
+	{
+		Key:        "time_elapsed",
+		Doc:        "Time since the pull.",
+		Fields:     boundFields(secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:        "time_remaining",
+		Doc:        "Time left in the fight.",
+		Fields:     boundFields(secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:        "target_health_percent",
+		Doc:        "Target health as a fraction between 0 and 1.",
+		Fields:     boundFields(plainBounds, FieldFraction),
+		AtLeastOne: plainBounds,
+	},
+	{
+		Key:        "cast_time",
+		Doc:        "Current hasted cast time of a spell.",
+		Fields:     withBounds([]Field{{Name: "spell", Kind: FieldSpell, Required: true}}, secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:        "gcd_remaining",
+		Doc:        "Time until the global cooldown ends.",
+		Fields:     boundFields(secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:    "last_cast",
+		Doc:    "The most recent cast was this spell.",
+		Fields: []Field{{Name: "spell", Kind: FieldSpell, Required: true}},
+	},
+	{
+		Key:        "casts_since",
+		Doc:        "Casts since this spell was last cast.",
+		Fields:     withBounds([]Field{{Name: "spell", Kind: FieldSpell, Required: true}}, plainBounds, FieldCount),
+		AtLeastOne: plainBounds,
+	},
+	{
+		Key:        "ticks_remaining",
+		Doc:        "DoT ticks left on the target.",
+		Fields:     withBounds([]Field{{Name: "debuff", Kind: FieldDebuff, Required: true}}, plainBounds, FieldCount),
+		AtLeastOne: plainBounds,
+	},
+	{
+		Key:    "aura_active",
+		Doc:    "Any buff or debuff is up, optionally within a remaining-time window.",
+		Fields: []Field{{Name: "aura", Kind: FieldAura, Required: true}, {Name: "min_remaining", Kind: FieldSeconds}, {Name: "max_remaining", Kind: FieldSeconds}},
+	},
+	{
+		Key:        "aura_remaining",
+		Doc:        "Remaining duration of any buff or debuff; 0 when down.",
+		Fields:     withBounds([]Field{{Name: "aura", Kind: FieldAura, Required: true}}, secondsBounds, FieldSeconds),
+		AtLeastOne: secondsBounds,
+	},
+	{
+		Key:        "aura_stacks",
+		Doc:        "Stacks of any buff or debuff; 1 for an active aura that does not stack, 0 when down.",
+		Fields:     withBounds([]Field{{Name: "aura", Kind: FieldAura, Required: true}}, plainBounds, FieldCount),
+		AtLeastOne: plainBounds,
+	},
+	{
+		Key:    "aura_at_max_stacks",
+		Doc:    "Buff or debuff is up at its maximum stack count.",
+		Fields: []Field{{Name: "aura", Kind: FieldAura, Required: true}},
+	},
+	{
+		Key:        "variable",
+		Doc:        "Compares a runtime variable.",
+		Fields:     withBounds([]Field{{Name: "name", Kind: FieldVariable, Required: true}, {Name: "eq", Kind: FieldNumber}}, plainBounds, FieldNumber),
+		AtLeastOne: append([]string{"eq"}, plainBounds...),
+	},
+}
+
+// actionSpecs is the action grammar; compileAction rejects names not listed here.
+var actionSpecs = []ActionSpec{
+	{Name: "cast_spell", Doc: "Cast a spell; interrupt_if stops the cast when it holds.", Fields: []Field{{Name: "spell", Kind: FieldSpell, Required: true}, {Name: "interrupt_if", Kind: FieldCondition}}},
+	{Name: "cast", Doc: "Alias of cast_spell.", Fields: []Field{{Name: "spell", Kind: FieldSpell, Required: true}, {Name: "interrupt_if", Kind: FieldCondition}}},
+	{Name: "cancel_buff", Doc: "Remove one of your buffs; takes no time.", Fields: []Field{{Name: "buff", Kind: FieldBuff, Required: true}}},
+	{Name: "use_item", Doc: "Use an on-use item.", Fields: []Field{{Name: "item", Kind: FieldItem, Required: true}}},
+	{Name: "wait", Doc: "Idle for a fixed time.", Fields: []Field{{Name: "duration_seconds", Kind: FieldSeconds, Required: true}}},
+	{Name: "call_action_list", Doc: "Evaluate a named list; fall through if it casts nothing.", Fields: []Field{{Name: "list", Kind: FieldList, Required: true}}},
+	{Name: "run_action_list", Doc: "Evaluate a named list exclusively.", Fields: []Field{{Name: "list", Kind: FieldList, Required: true}}},
+	{Name: "set_variable", Doc: "Set a runtime variable.", Fields: []Field{{Name: "variable", Kind: FieldVariable, Required: true}, {Name: "value", Kind: FieldValue, Required: true}}},
+	{Name: "increment_variable", Doc: "Add value (default 1) to a runtime variable.", Fields: []Field{{Name: "variable", Kind: FieldVariable, Required: true}, {Name: "value", Kind: FieldValue}}},
+	{Name: "reset_variable", Doc: "Restore a runtime variable to its declared value.", Fields: []Field{{Name: "variable", Kind: FieldVariable, Required: true}}},
+	{Name: "sequence", Doc: "Run steps strictly in order across decisions.", Fields: []Field{{Name: "steps", Kind: FieldActions, Required: true}, {Name: "reset_when", Kind: FieldCondition}}},
+	{Name: "wait_until", Doc: "Idle until a condition holds or the timeout expires.", Fields: []Field{{Name: "until", Kind: FieldCondition, Required: true}, {Name: "timeout_seconds", Kind: FieldSeconds, Required: true}}},
+	{Name: "macro", Doc: "Run every step at once.", Fields: []Field{{Name: "steps", Kind: FieldActions}}},
+	{Name: "pet_cast", Doc: "Have the pet use an ability; only in the pet action list.", Fields: []Field{{Name: "spell", Kind: FieldPetSpell, Required: true}}},
+}
+
+var (
+	conditionSpecIndex = indexConditionSpecs()
+	actionSpecIndex    = indexActionSpecs()
+)
+
+func indexConditionSpecs() map[string]*ConditionSpec {
+	out := make(map[string]*ConditionSpec, len(conditionSpecs))
+	for i := range conditionSpecs {
+		out[conditionSpecs[i].Key] = &conditionSpecs[i]
+	}
+	return out
+}
+
+func indexActionSpecs() map[string]*ActionSpec {
+	out := make(map[string]*ActionSpec, len(actionSpecs))
+	for i := range actionSpecs {
+		out[actionSpecs[i].Name] = &actionSpecs[i]
+	}
+	return out
+}
+
+// ConditionSpecs returns the condition grammar in documentation order.
+func ConditionSpecs() []ConditionSpec {
+	return append([]ConditionSpec(nil), conditionSpecs...)
+}
+
+// ActionSpecs returns the action grammar in documentation order.
+func ActionSpecs() []ActionSpec {
+	return append([]ActionSpec(nil), actionSpecs...)
+}
+
+// params reads a condition's mapping and rejects fields the spec does not list.
+func (spec *ConditionSpec) params(node *yaml.Node) (map[string]*yaml.Node, error) {
+	params, err := nodeToMap(node)
+	if err != nil {
+		return nil, err
+	}
+	for name := range params {
+		if !spec.hasField(name) {
+			return nil, fmt.Errorf("%s: unknown field '%s' (expected %s)", spec.Key, name, spec.fieldNames())
+		}
+	}
+	return params, nil
+}
+
+func (spec *ConditionSpec) hasField(name string) bool {
+	for _, f := range spec.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (spec *ConditionSpec) fieldNames() string {
+	names := make([]string, len(spec.Fields))
+	for i, f := range spec.Fields {
+		names[i] = f.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -35,17 +35,37 @@ func loadRecursive(baseDir, relPath string, seen map[string]bool) (*File, error)
 		return nil, fmt.Errorf("parse %s: %w", relPath, err)
 	}
 
-	// Resolve imports depth-first.
+	// Resolve imports depth-first. Named lists from imports are merged in;
+	// a list defined by the importing file (or a later import) wins. Phases
+	// are taken whole from the importing file, else from the last import
+	// that declares any.
 	var compiledRotation []ActionDefinition
+	var phases []PhaseDefinition
+	lists := map[string][]ActionDefinition{}
 	for _, imp := range file.Imports {
 		child, err := loadRecursive(baseDir, imp, seen)
 		if err != nil {
 			return nil, err
 		}
 		compiledRotation = append(compiledRotation, child.Rotation...)
+		for name, actions := range child.ActionLists {
+			lists[name] = actions
+		}
+		if len(child.Phases) > 0 {
+			phases = child.Phases
+		}
 	}
 	compiledRotation = append(compiledRotation, file.Rotation...)
 	file.Rotation = compiledRotation
+	for name, actions := range file.ActionLists {
+		lists[name] = actions
+	}
+	if len(lists) > 0 {
+		file.ActionLists = lists
+	}
+	if len(file.Phases) == 0 {
+		file.Phases = phases
+	}
 
 	seen[normalized] = false
 	return &file, nil
@@ -23,6 +23,9 @@ var (
 		"curse_of_doom":         {},
 		"curse_of_the_elements": {},
 		"shadow_crash":          {},
+		"metamorphosis":         {},
+		"demonic_empowerment":   {},
+		"immolation_aura":       {},
 	}
 	knownBuffs = map[string]struct{}{
 		"pyroclasm":           {},
@@ -37,6 +40,22 @@ var (
 		"life_tap_buff":       {},
 		"shadow_trance":       {},
 		"demonic_soul":        {},
+		"metamorphosis":       {},
+		"molten_core":         {},
+		"decimation":          {},
+		"demonic_empowerment": {},
+		"demonic_pact":        {},
+		"demonic_sacrifice":   {},
+		"empowered_imp":       {},
+		"cursed_shadows":      {},
+		"inner_flame":         {},
+		"pure_shadow":         {},
+		"immolation_aura":     {},
+		"fire_shield":         {},
+		"phase_shift":         {},
+
+		"chaos_manifesting_fire":   {},
+		"chaos_manifesting_shadow": {},
 	}
 	knownDebuffs = map[string]struct{}{
 		"immolate":              {},
@@ -49,6 +68,17 @@ var (
 		"mana":        {},
 		"health":      {},
 		"soul_shards": {},
+		"pet_mana":    {},
+	}
+	// knownPetSpells are the abilities a pet_cast action can name. A pet that
+	// lacks the ability cannot cast it.
+	knownPetSpells = map[string]struct{}{
+		"firebolt":     {},
+		"fire_shield":  {},
+		"phase_shift":  {},
+		"cleave":       {},
+		"shadow_bite":  {},
+		"lash_of_pain": {},
 	}
 )
 
@@ -67,11 +97,26 @@ func KnownDebuffs() map[string]struct{} {
 	return copySet(knownDebuffs)
 }
 
+// KnownAuras returns every buff and debuff name. Buffs and debuffs share one
+// namespace, so the aura_* predicates accept either.
+func KnownAuras() map[string]struct{} {
+	out := copySet(knownBuffs)
+	for name := range knownDebuffs {
+		out[name] = struct{}{}
+	}
+	return out
+}
+
 // KnownResources returns the set of valid resource identifiers.
 func KnownResources() map[string]struct{} {
 	return copySet(knownResources)
 }
 
+// KnownPetSpells returns the set of valid pet ability identifiers.
+func KnownPetSpells() map[string]struct{} {
+	return copySet(knownPetSpells)
+}
+
 func copySet(src map[string]struct{}) map[string]struct{} {
 	out := make(map[string]struct{}, len(src))
 	for k, v := range src {
@@ -117,6 +162,19 @@ func validateDebuffName(name string) (string, error) {
 	return n, nil
 }
 
+func validateAuraName(name string) (string, error) {
+	n := normalizeName(name)
+	if n == "" {
+		return n, fmt.Errorf("aura name missing")
+	}
+	_, buff := knownBuffs[n]
+	_, debuff := knownDebuffs[n]
+	if !buff && !debuff {
+		return "", fmt.Errorf("unknown aura '%s'", name)
+	}
+	return n, nil
+}
+
 func validateResourceName(name string) (string, error) {
 	n := normalizeName(name)
 	if n == "" {
@@ -132,3 +190,14 @@ func validateCooldownName(name string) (string, error) {
 	// cooldown names map to spells for now
 	return validateSpellName(name)
 }
+
+func validatePetSpellName(name string) (string, error) {
+	n := normalizeName(name)
+	if n == "" {
+		return n, fmt.Errorf("pet spell name missing")
+	}
+	if _, ok := knownPetSpells[n]; !ok {
+		return "", fmt.Errorf("unknown pet spell '%s'", name)
+	}
+	return n, nil
+}
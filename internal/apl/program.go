@@ -0,0 +1,735 @@
+package apl
+
+import (
+	"sort"
+	"time"
+)
+
+// Programs are the fast path for evaluating conditions. Compile resolves every
+// spell/buff/debuff/resource/variable name a rotation mentions to an index in
+// its Symbols, and flattens each condition tree into a short stack-machine
+// program over those indices. The engine binds each index to its state once per
+// iteration, so evaluation needs no string switches and no allocation. The
+// Condition trees stay on Action for Explain, static analysis and State.
+
+// Symbols lists the names a compiled rotation refers to, by kind. Programs
+// and Action.SpellSym refer to names by their index in these slices.
+type Symbols struct {
+	Buffs     []string
+	Debuffs   []string
+	Cooldowns []string
+	Spells    []string
+	Resources []string
+	Variables []string
+	Auras     []string
+	PetSpells []string
+}
+
+// IndexedContext is an EvaluationContext that also answers by symbol index.
+type IndexedContext interface {
+	EvaluationContext
+	BuffActiveAt(id int) bool
+	BuffRemainingAt(id int) time.Duration
+	BuffChargesAt(id int) int
+	DebuffActiveAt(id int) bool
+	DebuffRemainingAt(id int) time.Duration
+	TicksRemainingAt(id int) int
+	CooldownReadyAt(id int) bool
+	CooldownRemainingAt(id int) time.Duration
+	ResourcePercentAt(id int) float64
+	CastTimeAt(id int) time.Duration
+	CastsSinceAt(id int) int
+	LastCastIs(id int) bool
+	VariableAt(id int) float64
+	AuraActiveAt(id int) bool
+	AuraRemainingAt(id int) time.Duration
+	AuraStacksAt(id int) int
+	AuraMaxStacksAt(id int) int
+	PetCooldownRemainingAt(id int) time.Duration
+}
+
+// Indexed adapts a name-based context to IndexedContext by looking indices up
+// in syms. Engines should implement IndexedContext directly; this is for
+// snapshots such as State.
+func Indexed(ctx EvaluationContext, syms *Symbols) IndexedContext {
+	return namedContext{EvaluationContext: ctx, syms: syms}
+}
+
+type namedContext struct {
+	EvaluationContext
+	syms *Symbols
+}
+
+func (c namedContext) BuffActiveAt(id int) bool { return c.BuffActive(c.syms.Buffs[id]) }
+func (c namedContext) BuffRemainingAt(id int) time.Duration {
+	return c.BuffRemaining(c.syms.Buffs[id])
+}
+func (c namedContext) BuffChargesAt(id int) int    { return c.BuffCharges(c.syms.Buffs[id]) }
+func (c namedContext) DebuffActiveAt(id int) bool  { return c.DebuffActive(c.syms.Debuffs[id]) }
+func (c namedContext) TicksRemainingAt(id int) int { return c.TicksRemaining(c.syms.Debuffs[id]) }
+func (c namedContext) CooldownReadyAt(id int) bool { return c.CooldownReady(c.syms.Cooldowns[id]) }
+func (c namedContext) CastsSinceAt(id int) int     { return c.CastsSince(c.syms.Spells[id]) }
+func (c namedContext) LastCastIs(id int) bool      { return c.LastCast() == c.syms.Spells[id] }
+func (c namedContext) VariableAt(id int) float64   { return c.Variable(c.syms.Variables[id]) }
+func (c namedContext) ResourcePercentAt(id int) float64 {
+	return c.ResourcePercent(c.syms.Resources[id])
+}
+func (c namedContext) DebuffRemainingAt(id int) time.Duration {
+	return c.DebuffRemaining(c.syms.Debuffs[id])
+}
+func (c namedContext) CooldownRemainingAt(id int) time.Duration {
+	return c.CooldownRemaining(c.syms.Cooldowns[id])
+}
+func (c namedContext) CastTimeAt(id int) time.Duration { return c.CastTime(c.syms.Spells[id]) }
+func (c namedContext) AuraActiveAt(id int) bool        { return c.AuraActive(c.syms.Auras[id]) }
+func (c namedContext) AuraRemainingAt(id int) time.Duration {
+	return c.AuraRemaining(c.syms.Auras[id])
+}
+func (c namedContext) AuraStacksAt(id int) int    { return c.AuraStacks(c.syms.Auras[id]) }
+func (c namedContext) AuraMaxStacksAt(id int) int { return c.AuraMaxStacks(c.syms.Auras[id]) }
+func (c namedContext) PetCooldownRemainingAt(id int) time.Duration {
+	return c.PetCooldownRemaining(c.syms.PetSpells[id])
+}
+
+type opcode uint8
+
+const (
+	opConst opcode = iota // push consts[arg]
+	opBuffActive
+	opBuffRemaining
+	opBuffCharges
+	opDebuffActive
+	opDebuffRemaining
+	opTicks
+	opCooldownReady
+	opCooldownRemaining
+	opResource
+	opCastTime
+	opCastsSince
+	opLastCast
+	opVariable
+	opTimeElapsed
+	opTimeRemaining
+	opGCD
+	opTargetHealth
+	opAuraActive
+	opAuraRemaining
+	opAuraStacks
+	opAuraMaxStacks
+	opPetCooldownReady
+	opPetCooldownRemaining
+	opCheck // replace top with bounds[arg].holds(top)
+	opNot   // boolean not
+	opNeg   // numeric negate
+	opAdd   // binary arithmetic and comparisons pop two, push one
+	opSub
+	opMul
+	opDiv
+	opLt
+	opLte
+	opGt
+	opGte
+	opEq
+	opNe
+	opBoolEq
+	opBoolNe
+	opJumpFalse // if top is false jump to arg keeping it, else pop it
+	opJumpTrue  // if top is true jump to arg keeping it, else pop it
+	opCondition // push fallback[arg].Eval(ctx)
+	opNumber    // push numFallback[arg].evalNum(ctx)
+)
+
+type instr struct {
+	op  opcode
+	arg int32
+}
+
+// bounds holds the lt/lte/gt/gte/eq limits of one predicate.
+type bounds struct {
+	lt, lte, gt, gte, eq float64
+	mask                 uint8
+}
+
+const (
+	boundLt uint8 = 1 << iota
+	boundLte
+	boundGt
+	boundGte
+	boundEq
+)
+
+func (b *bounds) holds(v float64) bool {
+	return (b.mask&boundLt == 0 || v < b.lt) &&
+		(b.mask&boundLte == 0 || v <= b.lte) &&
+		(b.mask&boundGt == 0 || v > b.gt) &&
+		(b.mask&boundGte == 0 || v >= b.gte) &&
+		(b.mask&boundEq == 0 || v == b.eq)
+}
+
+// maxProgramStack bounds the evaluation stack so Eval can keep it in a
+// fixed-size array. Conditions nested deeper fall back to tree evaluation.
+const maxProgramStack = 32
+
+// Program is a flattened condition. Booleans are 1/0 on a float64 stack.
+type Program struct {
+	code        []instr
+	consts      []float64
+	bounds      []bounds
+	fallback    []Condition
+	numFallback []numExpr
+}
+
+// Len returns the number of instructions, for diagnostics and benchmarks.
+func (p *Program) Len() int { return len(p.code) }
+
+// Eval runs the program. A nil program is true, like a missing when:.
+func (p *Program) Eval(ctx IndexedContext) bool {
+	if p == nil {
+		return true
+	}
+	var stack [maxProgramStack]float64
+	sp := 0
+	code := p.code
+	for pc := 0; pc < len(code); pc++ {
+		in := code[pc]
+		switch in.op {
+		case opConst:
+			stack[sp] = p.consts[in.arg]
+			sp++
+		case opBuffActive:
+			stack[sp] = truth(ctx.BuffActiveAt(int(in.arg)))
+			sp++
+		case opBuffRemaining:
+			stack[sp] = ctx.BuffRemainingAt(int(in.arg)).Seconds()
+			sp++
+		case opBuffCharges:
+			stack[sp] = float64(ctx.BuffChargesAt(int(in.arg)))
+			sp++
+		case opDebuffActive:
+			stack[sp] = truth(ctx.DebuffActiveAt(int(in.arg)))
+			sp++
+		case opDebuffRemaining:
+			stack[sp] = ctx.DebuffRemainingAt(int(in.arg)).Seconds()
+			sp++
+		case opTicks:
+			stack[sp] = float64(ctx.TicksRemainingAt(int(in.arg)))
+			sp++
+		case opCooldownReady:
+			stack[sp] = truth(ctx.CooldownReadyAt(int(in.arg)))
+			sp++
+		case opCooldownRemaining:
+			stack[sp] = ctx.CooldownRemainingAt(int(in.arg)).Seconds()
+			sp++
+		case opResource:
+			stack[sp] = ctx.ResourcePercentAt(int(in.arg))
+			sp++
+		case opCastTime:
+			stack[sp] = ctx.CastTimeAt(int(in.arg)).Seconds()
+			sp++
+		case opCastsSince:
+			stack[sp] = float64(ctx.CastsSinceAt(int(in.arg)))
+			sp++
+		case opLastCast:
+			stack[sp] = truth(ctx.LastCastIs(int(in.arg)))
+			sp++
+		case opVariable:
+			stack[sp] = ctx.VariableAt(int(in.arg))
+			sp++
+		case opTimeElapsed:
+			stack[sp] = ctx.TimeElapsed().Seconds()
+			sp++
+		case opTimeRemaining:
+			stack[sp] = ctx.TimeRemaining().Seconds()
+			sp++
+		case opGCD:
+			stack[sp] = ctx.GCDRemaining().Seconds()
+			sp++
+		case opTargetHealth:
+			stack[sp] = ctx.TargetHealthPercent()
+			sp++
+		case opAuraActive:
+			stack[sp] = truth(ctx.AuraActiveAt(int(in.arg)))
+			sp++
+		case opAuraRemaining:
+			stack[sp] = ctx.AuraRemainingAt(int(in.arg)).Seconds()
+			sp++
+		case opAuraStacks:
+			stack[sp] = float64(ctx.AuraStacksAt(int(in.arg)))
+			sp++
+		case opAuraMaxStacks:
+			stack[sp] = float64(ctx.AuraMaxStacksAt(int(in.arg)))
+			sp++
+		case opPetCooldownReady:
+			stack[sp] = truth(ctx.PetCooldownRemainingAt(int(in.arg)) <= 0)
+			sp++
+		case opPetCooldownRemaining:
+			stack[sp] = ctx.PetCooldownRemainingAt(int(in.arg)).Seconds()
+			sp++
+		case opCheck:
+			stack[sp-1] = truth(p.bounds[in.arg].holds(stack[sp-1]))
+		case opNot:
+			stack[sp-1] = truth(stack[sp-1] == 0)
+		case opNeg:
+			stack[sp-1] = -stack[sp-1]
+		case opJumpFalse:
+			if stack[sp-1] == 0 {
+				pc = int(in.arg) - 1
+				continue
+			}
+			sp--
+		case opJumpTrue:
+			if stack[sp-1] != 0 {
+				pc = int(in.arg) - 1
+				continue
+			}
+			sp--
+		case opCondition:
+			stack[sp] = truth(p.fallback[in.arg].Eval(ctx))
+			sp++
+		case opNumber:
+			stack[sp] = p.numFallback[in.arg].evalNum(ctx)
+			sp++
+		default:
+			sp--
+			l, r := stack[sp-1], stack[sp]
+			stack[sp-1] = binary(in.op, l, r)
+		}
+	}
+	return stack[0] != 0
+}
+
+func binary(op opcode, l, r float64) float64 {
+	switch op {
+	case opAdd:
+		return l + r
+	case opSub:
+		return l - r
+	case opMul:
+		return l * r
+	case opDiv:
+		if r == 0 {
+			return 0
+		}
+		return l / r
+	case opLt:
+		return truth(l < r)
+	case opLte:
+		return truth(l <= r)
+	case opGt:
+		return truth(l > r)
+	case opGte:
+		return truth(l >= r)
+	case opEq:
+		return truth(l == r)
+	case opNe:
+		return truth(l != r)
+	case opBoolEq:
+		return truth((l != 0) == (r != 0))
+	case opBoolNe:
+		return truth((l != 0) != (r != 0))
+	}
+	return 0
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// symbolTable assigns indices to names as programs are built.
+type symbolTable struct {
+	syms  *Symbols
+	index map[*[]string]map[string]int
+}
+
+func newSymbolTable(syms *Symbols) *symbolTable {
+	return &symbolTable{syms: syms, index: map[*[]string]map[string]int{}}
+}
+
+func (t *symbolTable) id(list *[]string, name string) int32 {
+	byName := t.index[list]
+	if byName == nil {
+		byName = map[string]int{}
+		t.index[list] = byName
+	}
+	if idx, ok := byName[name]; ok {
+		return int32(idx)
+	}
+	byName[name] = len(*list)
+	*list = append(*list, name)
+	return int32(byName[name])
+}
+
+// programBuilder flattens one condition tree.
+type programBuilder struct {
+	table *symbolTable
+	prog  *Program
+	depth int
+	max   int
+}
+
+// compileProgram flattens c against table, or returns nil for a nil condition.
+func compileProgram(c Condition, table *symbolTable) *Program {
+	if c == nil {
+		return nil
+	}
+	b := &programBuilder{table: table, prog: &Program{}}
+	b.condition(c)
+	if b.max > maxProgramStack {
+		b = &programBuilder{table: table, prog: &Program{}}
+		b.fallbackCondition(c)
+	}
+	return b.prog
+}
+
+func (b *programBuilder) emit(op opcode, arg int32, delta int) {
+	b.prog.code = append(b.prog.code, instr{op: op, arg: arg})
+	b.depth += delta
+	if b.depth > b.max {
+		b.max = b.depth
+	}
+}
+
+func (b *programBuilder) push(op opcode, arg int32) { b.emit(op, arg, 1) }
+
+func (b *programBuilder) constant(v float64) {
+	b.prog.consts = append(b.prog.consts, v)
+	b.push(opConst, int32(len(b.prog.consts)-1))
+}
+
+func (b *programBuilder) check(lt, lte, gt, gte, eq *float64) {
+	var bd bounds
+	set := func(dst *float64, src *float64, bit uint8) {
+		if src != nil {
+			*dst = *src
+			bd.mask |= bit
+		}
+	}
+	set(&bd.lt, lt, boundLt)
+	set(&bd.lte, lte, boundLte)
+	set(&bd.gt, gt, boundGt)
+	set(&bd.gte, gte, boundGte)
+	set(&bd.eq, eq, boundEq)
+	// With no limits set the check holds for any value, like the tree.
+	b.prog.bounds = append(b.prog.bounds, bd)
+	b.emit(opCheck, int32(len(b.prog.bounds)-1), 0)
+}
+
+func floatPtr(i *int) *float64 {
+	if i == nil {
+		return nil
+	}
+	v := float64(*i)
+	return &v
+}
+
+func (b *programBuilder) durationCheck(lt, lte, gt, gte *time.Duration) {
+	b.check(secondsPtr(lt), secondsPtr(lte), secondsPtr(gt), secondsPtr(gte), nil)
+}
+
+func (b *programBuilder) intCheck(lt, lte, gt, gte *int) {
+	b.check(floatPtr(lt), floatPtr(lte), floatPtr(gt), floatPtr(gte), nil)
+}
+
+// jump emits a conditional jump and returns its index for patching.
+func (b *programBuilder) jump(op opcode) int {
+	b.emit(op, 0, -1)
+	return len(b.prog.code) - 1
+}
+
+func (b *programBuilder) patch(jumps []int) {
+	for _, at := range jumps {
+		b.prog.code[at].arg = int32(len(b.prog.code))
+	}
+}
+
+// chain emits children joined by short-circuit jumps; empty yields empty.
+func (b *programBuilder) chain(children []Condition, op opcode, empty bool) {
+	if len(children) == 0 {
+		b.constant(truth(empty))
+		return
+	}
+	var jumps []int
+	for idx, child := range children {
+		b.condition(child)
+		if idx < len(children)-1 {
+			jumps = append(jumps, b.jump(op))
+		}
+	}
+	b.patch(jumps)
+}
+
+// aura emits active(id) and, when limits are set, remaining(id) in range.
+func (b *programBuilder) aura(active, remaining opcode, id int32, min, max *time.Duration) {
+	b.push(active, id)
+	if min == nil && max == nil {
+		return
+	}
+	end := b.jump(opJumpFalse)
+	b.push(remaining, id)
+	b.check(nil, secondsPtr(max), nil, secondsPtr(min), nil)
+	b.patch([]int{end})
+}
+
+func (b *programBuilder) condition(c Condition) {
+	syms := b.table.syms
+	switch v := c.(type) {
+	case trueCondition:
+		b.constant(1)
+	case falseCondition:
+		b.constant(0)
+	case allCondition:
+		b.chain(v.children, opJumpFalse, true)
+	case anyCondition:
+		b.chain(v.children, opJumpTrue, false)
+	case notCondition:
+		if v.child == nil {
+			b.constant(1)
+			return
+		}
+		b.condition(v.child)
+		b.emit(opNot, 0, 0)
+	case buffActiveCondition:
+		b.aura(opBuffActive, opBuffRemaining, b.table.id(&syms.Buffs, v.name), v.minRemaining, v.maxRemaining)
+	case debuffActiveCondition:
+		b.aura(opDebuffActive, opDebuffRemaining, b.table.id(&syms.Debuffs, v.name), v.minRemaining, v.maxRemaining)
+	case dotRemainingCondition:
+		b.push(opDebuffRemaining, b.table.id(&syms.Debuffs, v.spell))
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case resourcePercentCondition:
+		b.push(opResource, b.table.id(&syms.Resources, v.resource))
+		b.check(v.lt, v.lte, v.gt, v.gte, nil)
+	case cooldownReadyCondition:
+		b.push(opCooldownReady, b.table.id(&syms.Cooldowns, v.name))
+	case cooldownRemainingCondition:
+		b.push(opCooldownRemaining, b.table.id(&syms.Cooldowns, v.name))
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case chargesCondition:
+		b.push(opBuffCharges, b.table.id(&syms.Buffs, v.buff))
+		b.intCheck(v.lt, v.lte, v.gt, v.gte)
+	case fightTimeCondition:
+		if v.remaining {
+			b.push(opTimeRemaining, 0)
+		} else {
+			b.push(opTimeElapsed, 0)
+		}
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case targetHealthCondition:
+		b.push(opTargetHealth, 0)
+		b.check(v.lt, v.lte, v.gt, v.gte, nil)
+	case castTimeCondition:
+		b.push(opCastTime, b.table.id(&syms.Spells, v.spell))
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case gcdRemainingCondition:
+		b.push(opGCD, 0)
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case lastCastCondition:
+		b.push(opLastCast, b.table.id(&syms.Spells, v.spell))
+	case castsSinceCondition:
+		b.push(opCastsSince, b.table.id(&syms.Spells, v.spell))
+		b.intCheck(v.lt, v.lte, v.gt, v.gte)
+	case ticksRemainingCondition:
+		b.push(opTicks, b.table.id(&syms.Debuffs, v.debuff))
+		b.intCheck(v.lt, v.lte, v.gt, v.gte)
+	case variableCondition:
+		b.push(opVariable, b.table.id(&syms.Variables, v.name))
+		b.check(v.lt, v.lte, v.gt, v.gte, v.eq)
+	case auraActiveCondition:
+		b.aura(opAuraActive, opAuraRemaining, b.table.id(&syms.Auras, v.name), v.minRemaining, v.maxRemaining)
+	case auraRemainingCondition:
+		b.push(opAuraRemaining, b.table.id(&syms.Auras, v.name))
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case auraStacksCondition:
+		b.push(opAuraStacks, b.table.id(&syms.Auras, v.name))
+		b.intCheck(v.lt, v.lte, v.gt, v.gte)
+	case auraAtMaxStacksCondition:
+		id := b.table.id(&syms.Auras, v.name)
+		zero := 0.0
+		b.push(opAuraStacks, id)
+		b.check(nil, nil, &zero, nil, nil)
+		end := b.jump(opJumpFalse)
+		b.push(opAuraStacks, id)
+		b.push(opAuraMaxStacks, id)
+		b.emit(opGte, 0, -1)
+		b.patch([]int{end})
+	case petCooldownReadyCondition:
+		b.push(opPetCooldownReady, b.table.id(&syms.PetSpells, v.spell))
+	case petCooldownRemainingCondition:
+		b.push(opPetCooldownRemaining, b.table.id(&syms.PetSpells, v.spell))
+		b.durationCheck(v.lt, v.lte, v.gt, v.gte)
+	case exprCondition:
+		b.boolExpr(v.root)
+	default:
+		b.fallbackCondition(c)
+	}
+}
+
+func (b *programBuilder) fallbackCondition(c Condition) {
+	b.prog.fallback = append(b.prog.fallback, c)
+	b.push(opCondition, int32(len(b.prog.fallback)-1))
+}
+
+// callOps maps expression functions to their load instruction and symbol kind.
+var callOps = map[string]struct {
+	op   opcode
+	list func(*Symbols) *[]string
+}{
+	"debuff_remaining":       {opDebuffRemaining, func(s *Symbols) *[]string { return &s.Debuffs }},
+	"dot_remaining":          {opDebuffRemaining, func(s *Symbols) *[]string { return &s.Debuffs }},
+	"debuff_active":          {opDebuffActive, func(s *Symbols) *[]string { return &s.Debuffs }},
+	"ticks_remaining":        {opTicks, func(s *Symbols) *[]string { return &s.Debuffs }},
+	"buff_remaining":         {opBuffRemaining, func(s *Symbols) *[]string { return &s.Buffs }},
+	"buff_active":            {opBuffActive, func(s *Symbols) *[]string { return &s.Buffs }},
+	"buff_charges":           {opBuffCharges, func(s *Symbols) *[]string { return &s.Buffs }},
+	"cooldown_remaining":     {opCooldownRemaining, func(s *Symbols) *[]string { return &s.Cooldowns }},
+	"cooldown_ready":         {opCooldownReady, func(s *Symbols) *[]string { return &s.Cooldowns }},
+	"resource_pct":           {opResource, func(s *Symbols) *[]string { return &s.Resources }},
+	"variable":               {opVariable, func(s *Symbols) *[]string { return &s.Variables }},
+	"cast_time":              {opCastTime, func(s *Symbols) *[]string { return &s.Spells }},
+	"last_cast":              {opLastCast, func(s *Symbols) *[]string { return &s.Spells }},
+	"casts_since":            {opCastsSince, func(s *Symbols) *[]string { return &s.Spells }},
+	"aura_active":            {opAuraActive, func(s *Symbols) *[]string { return &s.Auras }},
+	"aura_remaining":         {opAuraRemaining, func(s *Symbols) *[]string { return &s.Auras }},
+	"aura_stacks":            {opAuraStacks, func(s *Symbols) *[]string { return &s.Auras }},
+	"aura_max_stacks":        {opAuraMaxStacks, func(s *Symbols) *[]string { return &s.Auras }},
+	"pet_cooldown_remaining": {opPetCooldownRemaining, func(s *Symbols) *[]string { return &s.PetSpells }},
+	"pet_cooldown_ready":     {opPetCooldownReady, func(s *Symbols) *[]string { return &s.PetSpells }},
+	"time_elapsed":           {opTimeElapsed, nil},
+	"time_remaining":         {opTimeRemaining, nil},
+	"gcd_remaining":          {opGCD, nil},
+	"target_health_pct":      {opTargetHealth, nil},
+}
+
+// call emits a function or identifier load; it reports false if the name
+// has no instruction.
+func (b *programBuilder) call(name string, args []string) bool {
+	entry, ok := callOps[name]
+	if !ok {
+		return false
+	}
+	if entry.list == nil {
+		b.push(entry.op, 0)
+		return true
+	}
+	if len(args) != 1 {
+		return false
+	}
+	b.push(entry.op, b.table.id(entry.list(b.table.syms), args[0]))
+	return true
+}
+
+var compareOps = map[string]opcode{"<": opLt, "<=": opLte, ">": opGt, ">=": opGte, "==": opEq, "!=": opNe}
+
+var arithOps = map[byte]opcode{'+': opAdd, '-': opSub, '*': opMul, '/': opDiv}
+
+func (b *programBuilder) boolExpr(node boolExpr) {
+	switch v := node.(type) {
+	case boolLiteral:
+		b.constant(truth(bool(v)))
+	case boolCallExpr:
+		if !b.call(v.name, v.args) {
+			b.fallbackCondition(exprCondition{root: v})
+		}
+	case notExpr:
+		b.boolExpr(v.operand)
+		b.emit(opNot, 0, 0)
+	case andExpr:
+		b.boolExpr(v.left)
+		end := b.jump(opJumpFalse)
+		b.boolExpr(v.right)
+		b.patch([]int{end})
+	case orExpr:
+		b.boolExpr(v.left)
+		end := b.jump(opJumpTrue)
+		b.boolExpr(v.right)
+		b.patch([]int{end})
+	case compareExpr:
+		b.numExpr(v.left)
+		b.numExpr(v.right)
+		b.emit(compareOps[v.op], 0, -1)
+	case boolEqualExpr:
+		b.boolExpr(v.left)
+		b.boolExpr(v.right)
+		op := opBoolEq
+		if v.negate {
+			op = opBoolNe
+		}
+		b.emit(op, 0, -1)
+	default:
+		b.fallbackCondition(exprCondition{root: node})
+	}
+}
+
+func (b *programBuilder) numExpr(node numExpr) {
+	switch v := node.(type) {
+	case numberLiteral:
+		b.constant(float64(v))
+	case numCallExpr:
+		if !b.call(v.name, v.args) {
+			b.numFallback(v)
+		}
+	case negateExpr:
+		b.numExpr(v.operand)
+		b.emit(opNeg, 0, 0)
+	case arithExpr:
+		b.numExpr(v.left)
+		b.numExpr(v.right)
+		b.emit(arithOps[v.op], 0, -1)
+	default:
+		b.numFallback(node)
+	}
+}
+
+func (b *programBuilder) numFallback(node numExpr) {
+	b.prog.numFallback = append(b.prog.numFallback, node)
+	b.push(opNumber, int32(len(b.prog.numFallback)-1))
+}
+
+// compilePrograms builds the Program of every condition in rot and fills
+// rot.Symbols. Runtime variables are listed first in name order so their
+// indices are stable.
+func compilePrograms(rot *CompiledRotation) {
+	table := newSymbolTable(&rot.Symbols)
+	varNames := make([]string, 0, len(rot.RuntimeVariables))
+	for name := range rot.RuntimeVariables {
+		varNames = append(varNames, name)
+	}
+	sort.Strings(varNames)
+	for _, name := range varNames {
+		table.id(&rot.Symbols.Variables, name)
+	}
+
+	var visit func(actions []*Action)
+	visit = func(actions []*Action) {
+		for _, action := range actions {
+			if action == nil {
+				continue
+			}
+			action.Program = compileProgram(action.Condition, table)
+			action.UntilProgram = compileProgram(action.Until, table)
+			action.ResetProgram = compileProgram(action.Reset, table)
+			action.InterruptProgram = compileProgram(action.Interrupt, table)
+			action.SpellSym = -1
+			if action.Type == ActionCastSpell && action.Spell != "" {
+				action.SpellSym = int(table.id(&rot.Symbols.Spells, action.Spell))
+			}
+			visit(action.Steps)
+		}
+	}
+	visit(rot.Actions)
+	names := make([]string, 0, len(rot.Lists))
+	for name := range rot.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		visit(rot.Lists[name])
+	}
+	visit(rot.Precombat)
+	visit(rot.Pet)
+	for _, phase := range rot.Phases {
+		phase.Program = compileProgram(phase.Condition, table)
+	}
+}
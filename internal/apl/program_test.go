@@ -0,0 +1,130 @@
+package apl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureSet is a shipped rotation with its fixture states.
+type fixtureSet struct {
+	rot    *CompiledRotation
+	states []State
+}
+
+// fixtureSets loads every shipped rotation that has fixtures, keyed by
+// rotation file name.
+func fixtureSets(tb testing.TB) map[string]fixtureSet {
+	tb.Helper()
+	entries, err := os.ReadDir(testRotationDir)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	out := map[string]fixtureSet{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, FixtureSuffix) {
+			continue
+		}
+		rotName := strings.TrimSuffix(name, FixtureSuffix) + ".yaml"
+		file, err := LoadRotation(testRotationDir, rotName)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		rot, err := Compile(file)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		fixtures, err := LoadFixtures(filepath.Join(testRotationDir, name))
+		if err != nil {
+			tb.Fatal(err)
+		}
+		states := make([]State, 0, len(fixtures))
+		for _, fx := range fixtures {
+			states = append(states, fx.State.WithDefaults(rot))
+		}
+		out[rotName] = fixtureSet{rot: rot, states: states}
+	}
+	return out
+}
+
+// rotationPrograms lists every condition of rot next to its program.
+func rotationPrograms(rot *CompiledRotation) (trees []Condition, programs []*Program) {
+	add := func(tree Condition, program *Program) {
+		if tree != nil {
+			trees = append(trees, tree)
+			programs = append(programs, program)
+		}
+	}
+	var visit func(actions []*Action)
+	visit = func(actions []*Action) {
+		for _, action := range actions {
+			add(action.Condition, action.Program)
+			add(action.Until, action.UntilProgram)
+			add(action.Reset, action.ResetProgram)
+			add(action.Interrupt, action.InterruptProgram)
+			visit(action.Steps)
+		}
+	}
+	visit(rot.Actions)
+	for _, list := range rot.Lists {
+		visit(list)
+	}
+	visit(rot.Precombat)
+	visit(rot.Pet)
+	for _, phase := range rot.Phases {
+		add(phase.Condition, phase.Program)
+	}
+	return trees, programs
+}
+
+func TestProgramsMatchTreesOnFixtures(t *testing.T) {
+	for name, fx := range fixtureSets(t) {
+		trees, programs := rotationPrograms(fx.rot)
+		for s := range fx.states {
+			state := &fx.states[s]
+			indexed := Indexed(state, &fx.rot.Symbols)
+			for i := range trees {
+				if trees[i].Eval(state) != programs[i].Eval(indexed) {
+					t.Errorf("%s: state %d, condition %d: program and tree disagree", name, s, i)
+				}
+			}
+		}
+	}
+}
+
+// On State snapshots both forms cost about the same, since Indexed looks
+// every name up again; the engine binds names once (see the engine's
+// BenchmarkConditions*).
+func BenchmarkStateConditionsTree(b *testing.B) {
+	fx := fixtureSets(b)["destruction-default.yaml"]
+	trees, _ := rotationPrograms(fx.rot)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for s := range fx.states {
+			for _, tree := range trees {
+				tree.Eval(&fx.states[s])
+			}
+		}
+	}
+}
+
+func BenchmarkStateConditionsProgram(b *testing.B) {
+	fx := fixtureSets(b)["destruction-default.yaml"]
+	_, programs := rotationPrograms(fx.rot)
+	indexed := make([]IndexedContext, len(fx.states))
+	for s := range fx.states {
+		indexed[s] = Indexed(&fx.states[s], &fx.rot.Symbols)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ctx := range indexed {
+			for _, program := range programs {
+				program.Eval(ctx)
+			}
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package apl
+
+import (
+	"fmt"
+	"time"
+)
+
+// Selection is the entry a rotation acts on at one decision point.
+type Selection struct {
+	Action   *Action // a cast_spell, wait or wait_until (possibly a macro/sequence step)
+	Location string  // e.g. "rotation[4]", "action_lists.aoe[0].steps[1]"
+}
+
+// Spell returns the spell or pet ability the selection casts, or "" for waits.
+func (s Selection) Spell() string {
+	if s.Action == nil || (s.Action.Type != ActionCastSpell && s.Action.Type != ActionPetCast) {
+		return ""
+	}
+	return s.Action.Spell
+}
+
+// Select walks the rotation the way the engine does at a decision point and
+// returns the entry that would act, without casting anything. castable
+// reports whether a spell could be cast right now; variable actions and
+// cancel_buff update a private overlay so later conditions see their effect.
+// Sequences are assumed not yet started. ok is false when nothing would act.
+func (r *CompiledRotation) Select(ctx EvaluationContext, castable func(spell string) bool) (Selection, bool) {
+	sel := &selector{rot: r, castable: castable, ctx: newOverlayContext(ctx)}
+	if phase := r.ActivePhase(ctx); phase != nil {
+		return sel.list("action_lists."+phase.List, phase.Actions)
+	}
+	return sel.list("rotation", r.Actions)
+}
+
+// SelectPet is Select for the pet action list; castable reports whether the
+// pet could use an ability right now.
+func (r *CompiledRotation) SelectPet(ctx EvaluationContext, castable func(spell string) bool) (Selection, bool) {
+	sel := &selector{rot: r, castable: castable, ctx: newOverlayContext(ctx)}
+	return sel.list("action_lists."+PetList, r.Pet)
+}
+
+// MarkerContext is implemented by contexts that know which encounter phase
+// markers are active. Without it, phases that name a marker never match.
+type MarkerContext interface {
+	MarkerActive(name string) bool
+}
+
+// ActivePhase returns the first phase whose marker and condition hold, or nil
+// when the rotation list applies.
+func (r *CompiledRotation) ActivePhase(ctx EvaluationContext) *Phase {
+	markers, _ := ctx.(MarkerContext)
+	for _, phase := range r.Phases {
+		if phase.Marker != "" && (markers == nil || !markers.MarkerActive(phase.Marker)) {
+			continue
+		}
+		if phase.Condition != nil && !phase.Condition.Eval(ctx) {
+			continue
+		}
+		return phase
+	}
+	return nil
+}
+
+type selector struct {
+	rot      *CompiledRotation
+	castable func(spell string) bool
+	ctx      *overlayContext
+}
+
+func (s *selector) list(prefix string, actions []*Action) (Selection, bool) {
+	for idx, action := range actions {
+		if action == nil {
+			continue
+		}
+		loc := fmt.Sprintf("%s[%d]", prefix, idx)
+		if action.Condition != nil && !action.Condition.Eval(s.ctx) {
+			continue
+		}
+		if action.Type.IsVariableAction() || action.Type == ActionCancelBuff {
+			s.ctx.apply(action, s.rot)
+			continue
+		}
+		switch action.Type {
+		case ActionCastSpell, ActionPetCast:
+			if s.castable(action.Spell) {
+				return Selection{Action: action, Location: loc}, true
+			}
+		case ActionWait:
+			if action.Duration > 0 {
+				return Selection{Action: action, Location: loc}, true
+			}
+		case ActionWaitUntil:
+			if action.Until != nil && !action.Until.Eval(s.ctx) {
+				return Selection{Action: action, Location: loc}, true
+			}
+		case ActionCallList:
+			if sel, ok := s.list("action_lists."+action.List, s.rot.Lists[action.List]); ok {
+				return sel, true
+			}
+		case ActionRunList:
+			return s.list("action_lists."+action.List, s.rot.Lists[action.List])
+		case ActionMacro, ActionSequence:
+			if sel, ok := s.steps(loc, action); ok {
+				return sel, true
+			}
+		}
+	}
+	return Selection{}, false
+}
+
+// steps picks the first step of a macro or (unstarted) sequence that acts.
+// A sequence whose first castable step is blocked does not act; once a step
+// has been skipped the engine holds on the blocked spell instead.
+func (s *selector) steps(loc string, action *Action) (Selection, bool) {
+	for idx, step := range action.Steps {
+		if step == nil {
+			continue
+		}
+		stepLoc := fmt.Sprintf("%s.steps[%d]", loc, idx)
+		if step.Condition != nil && !step.Condition.Eval(s.ctx) {
+			continue
+		}
+		if step.Type.IsVariableAction() || step.Type == ActionCancelBuff {
+			s.ctx.apply(step, s.rot)
+			continue
+		}
+		switch step.Type {
+		case ActionCastSpell:
+			if s.castable(step.Spell) {
+				return Selection{Action: step, Location: stepLoc}, true
+			}
+			if action.Type == ActionSequence {
+				return Selection{Action: step, Location: stepLoc}, idx > 0
+			}
+		case ActionWait:
+			if step.Duration > 0 || action.Type == ActionSequence {
+				return Selection{Action: step, Location: stepLoc}, true
+			}
+		case ActionWaitUntil:
+			if step.Until != nil && !step.Until.Eval(s.ctx) {
+				return Selection{Action: step, Location: stepLoc}, true
+			}
+		}
+	}
+	return Selection{}, false
+}
+
+// overlayContext layers variable writes and cancelled buffs made during
+// selection over ctx.
+type overlayContext struct {
+	EvaluationContext
+	vars      map[string]float64
+	cancelled map[string]bool
+}
+
+func newOverlayContext(ctx EvaluationContext) *overlayContext {
+	return &overlayContext{EvaluationContext: ctx, vars: map[string]float64{}, cancelled: map[string]bool{}}
+}
+
+func (o *overlayContext) BuffActive(name string) bool {
+	return !o.cancelled[name] && o.EvaluationContext.BuffActive(name)
+}
+
+func (o *overlayContext) BuffRemaining(name string) time.Duration {
+	if o.cancelled[name] {
+		return 0
+	}
+	return o.EvaluationContext.BuffRemaining(name)
+}
+
+func (o *overlayContext) BuffCharges(name string) int {
+	if o.cancelled[name] {
+		return 0
+	}
+	return o.EvaluationContext.BuffCharges(name)
+}
+
+func (o *overlayContext) AuraActive(name string) bool {
+	return !o.cancelled[name] && o.EvaluationContext.AuraActive(name)
+}
+
+func (o *overlayContext) AuraRemaining(name string) time.Duration {
+	if o.cancelled[name] {
+		return 0
+	}
+	return o.EvaluationContext.AuraRemaining(name)
+}
+
+func (o *overlayContext) AuraStacks(name string) int {
+	if o.cancelled[name] {
+		return 0
+	}
+	return o.EvaluationContext.AuraStacks(name)
+}
+
+func (o *overlayContext) Variable(name string) float64 {
+	if v, ok := o.vars[name]; ok {
+		return v
+	}
+	return o.EvaluationContext.Variable(name)
+}
+
+func (o *overlayContext) apply(action *Action, rot *CompiledRotation) {
+	switch action.Type {
+	case ActionSetVariable:
+		o.vars[action.Variable] = action.Value
+	case ActionIncrementVariable:
+		o.vars[action.Variable] = o.Variable(action.Variable) + action.Value
+	case ActionResetVariable:
+		o.vars[action.Variable] = rot.RuntimeVariables[action.Variable]
+	case ActionCancelBuff:
+		o.cancelled[action.Buff] = true
+	}
+}
@@ -0,0 +1,1159 @@
+package apl
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SimulationCraft-style text APLs, as shared by the community:
+//
+//	actions.precombat=life_tap
+//	actions=immolate,if=!dot.immolate.ticking|dot.immolate.remains<2
+//	actions+=/conflagrate,if=cooldown.conflagrate.ready
+//	actions+=/call_action_list,name=filler
+//	actions.filler=incinerate
+//
+// ParseSimC turns such text into a File whose conditions are expressions;
+// ExportSimC renders a compiled rotation back. Other lines (character profile
+// keys, comments) are ignored, except that a leading "# Name" comment names
+// the rotation.
+//
+// Phases are conditional run_action_list entries at the top of "actions",
+// with the encounter marker as a leading raid_event.<marker>.up term:
+//
+//	actions=run_action_list,name=movement,if=raid_event.movement.up
+//	actions+=/run_action_list,name=execute,if=fight_remains<15
+//
+// The pet list is "actions.pet"; its entries are pet abilities and its
+// conditions may read pet.mana.pct and pet.cooldown.<ability>.ready/remains.
+
+// SimCError reports a problem at a position in SimC text. Columns are 1-based.
+type SimCError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SimCError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// ParseSimC converts SimC action lines into a rotation file.
+func ParseSimC(src string) (*File, error) {
+	p := &simcParser{
+		file:  &File{Variables: map[string]any{}},
+		lists: map[string][]ActionDefinition{},
+	}
+	for idx, line := range strings.Split(src, "\n") {
+		if err := p.parseLine(idx+1, strings.TrimRight(line, "\r")); err != nil {
+			return nil, err
+		}
+	}
+	p.file.Rotation = p.lists["actions"]
+	delete(p.lists, "actions")
+	if len(p.lists) > 0 {
+		p.file.ActionLists = p.lists
+	}
+	if len(p.file.Variables) == 0 {
+		p.file.Variables = nil
+	}
+	return p.file, nil
+}
+
+type simcParser struct {
+	file  *File
+	lists map[string][]ActionDefinition // "actions" is the default list
+	line  int
+	// leading is true while "actions" holds nothing but phases, so another
+	// conditional run_action_list still becomes a phase.
+	leading bool
+}
+
+func (p *simcParser) errorf(col int, format string, args ...any) error {
+	return &SimCError{Line: p.line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *simcParser) parseLine(lineNo int, line string) error {
+	p.line = lineNo
+	trimmed := strings.TrimLeft(line, " \t")
+	offset := len(line) - len(trimmed) // 0-based column of trimmed[0]
+	if comment, ok := strings.CutPrefix(trimmed, "#"); ok {
+		// A leading "# Name" comment (as written by ExportSimC) names the rotation.
+		if p.file.Name == "" && len(p.lists) == 0 {
+			p.file.Name = strings.TrimSpace(comment)
+		}
+		return nil
+	}
+	if trimmed == "" || !strings.HasPrefix(trimmed, "actions") {
+		return nil
+	}
+	eq := strings.IndexByte(trimmed, '=')
+	if eq < 0 {
+		return p.errorf(offset+1, "expected 'actions=' or 'actions+='")
+	}
+	key, value := trimmed[:eq], trimmed[eq+1:]
+	valueCol := offset + eq + 2
+	appending := strings.HasSuffix(key, "+")
+	key = strings.TrimSuffix(key, "+")
+
+	list := "actions"
+	if key != "actions" {
+		name, ok := strings.CutPrefix(key, "actions.")
+		if !ok || name == "" {
+			return p.errorf(offset+1, "unknown key '%s'", key)
+		}
+		list = name
+	}
+	if appending {
+		if !strings.HasPrefix(value, "/") {
+			return p.errorf(valueCol, "'+=' must be followed by '/'")
+		}
+	} else {
+		p.lists[list] = nil
+		if list == "actions" {
+			p.file.Phases = nil
+			p.leading = true
+		}
+	}
+	if strings.HasPrefix(value, "/") {
+		value = value[1:]
+		valueCol++
+	}
+
+	// Several actions may share a line, separated by '/'.
+	for _, entry := range strings.Split(value, "/") {
+		if strings.TrimSpace(entry) != "" {
+			if list == "actions" && p.leading {
+				phase, err := p.parsePhase(entry, valueCol)
+				if err != nil {
+					return err
+				}
+				if phase != nil {
+					p.file.Phases = append(p.file.Phases, *phase)
+					valueCol += len(entry) + 1
+					continue
+				}
+			}
+			def, err := p.parseEntry(list, entry, valueCol)
+			if err != nil {
+				return err
+			}
+			if def != nil {
+				p.lists[list] = append(p.lists[list], *def)
+				if list == "actions" {
+					p.leading = false
+				}
+			}
+		}
+		valueCol += len(entry) + 1
+	}
+	return nil
+}
+
+type simcOption struct {
+	key, value string
+	col        int // column of the value
+}
+
+// splitEntry separates an entry into its action name and key=value options.
+func (p *simcParser) splitEntry(entry string, col int) (string, map[string]simcOption, error) {
+	fields := strings.Split(entry, ",")
+	opts := map[string]simcOption{}
+	fieldCol := col + len(fields[0]) + 1
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return "", nil, p.errorf(fieldCol, "expected key=value, got '%s'", field)
+		}
+		opts[key] = simcOption{key: key, value: value, col: fieldCol + len(key) + 1}
+		fieldCol += len(field) + 1
+	}
+	return strings.TrimSpace(fields[0]), opts, nil
+}
+
+// parsePhase converts a conditional run_action_list at the top of "actions"
+// into a phase. It returns nil for any other entry.
+func (p *simcParser) parsePhase(entry string, col int) (*PhaseDefinition, error) {
+	name, opts, err := p.splitEntry(entry, col)
+	if err != nil || name != "run_action_list" || len(opts) != 2 || opts["name"].value == "" || opts["if"].value == "" {
+		return nil, nil
+	}
+	list, cond := opts["name"].value, opts["if"]
+	phase := &PhaseDefinition{Name: p.phaseName(list)}
+	if phase.Name != list {
+		phase.List = list
+	}
+	expr, exprCol := cond.value, cond.col
+	if marker, rest, ok := simcMarker(expr); ok {
+		phase.Marker = marker
+		exprCol += len(expr) - len(rest)
+		expr = rest
+	}
+	if expr != "" {
+		translated, err := p.translateExpression(expr, exprCol)
+		if err != nil {
+			return nil, err
+		}
+		phase.When = NewConditionNode(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: translated})
+	}
+	return phase, nil
+}
+
+// phaseName names a phase after its list, adding a suffix when another
+// phase already runs that list or the name is reserved.
+func (p *simcParser) phaseName(list string) string {
+	taken := func(name string) bool {
+		if normalizeName(name) == DefaultPhase {
+			return true
+		}
+		for _, phase := range p.file.Phases {
+			if phase.Name == name {
+				return true
+			}
+		}
+		return false
+	}
+	name := list
+	for n := 2; taken(name); n++ {
+		name = fmt.Sprintf("%s_%d", list, n)
+	}
+	return name
+}
+
+// simcMarker splits a leading raid_event.<marker>.up term off an if=
+// expression. rest is what follows the '&', or "" when the marker is the
+// whole condition. A rest with a top-level '|' would not bind to the marker,
+// so the term is then left in place.
+func simcMarker(expr string) (marker, rest string, ok bool) {
+	end := 0
+	for end < len(expr) && (isIdentPart(expr[end]) || expr[end] == '.') {
+		end++
+	}
+	parts := strings.Split(strings.ToLower(expr[:end]), ".")
+	if len(parts) != 3 || parts[0] != "raid_event" || parts[2] != "up" || parts[1] == "" {
+		return "", "", false
+	}
+	switch {
+	case end == len(expr):
+		return parts[1], "", true
+	case expr[end] != '&':
+		return "", "", false
+	}
+	rest = expr[end+1:]
+	depth := 0
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '|':
+			if depth == 0 {
+				return "", "", false
+			}
+		}
+	}
+	return parts[1], rest, true
+}
+
+// parseEntry converts one action of list; it returns nil for pure variable
+// declarations.
+func (p *simcParser) parseEntry(list, entry string, col int) (*ActionDefinition, error) {
+	name, opts, err := p.splitEntry(entry, col)
+	if err != nil {
+		return nil, err
+	}
+	nameCol := col
+
+	def := &ActionDefinition{}
+	used := map[string]bool{"if": true}
+	require := func(key string) (simcOption, error) {
+		used[key] = true
+		opt, ok := opts[key]
+		if !ok || opt.value == "" {
+			return opt, p.errorf(nameCol, "%s needs %s=", name, key)
+		}
+		return opt, nil
+	}
+	switch name {
+	case "wait":
+		opt, err := require("sec")
+		if err != nil {
+			return nil, err
+		}
+		secs, err := strconv.ParseFloat(opt.value, 64)
+		if err != nil {
+			return nil, p.errorf(opt.col, "wait sec= must be a number, got '%s'", opt.value)
+		}
+		def.Action = "wait"
+		def.DurationSeconds = secs
+	case "call_action_list", "run_action_list":
+		opt, err := require("name")
+		if err != nil {
+			return nil, err
+		}
+		def.Action = name
+		def.List = opt.value
+	case "use_item":
+		opt, err := require("name")
+		if err != nil {
+			return nil, err
+		}
+		def.Action = "use_item"
+		def.Item = opt.value
+	case "cancel_buff":
+		opt, err := require("name")
+		if err != nil {
+			return nil, err
+		}
+		buff, err := validateBuffName(opt.value)
+		if err != nil {
+			return nil, p.errorf(opt.col, "%v", err)
+		}
+		def.Action = "cancel_buff"
+		def.Buff = buff
+	case "variable":
+		declOnly, err := p.parseVariable(def, opts, used, require)
+		if err != nil {
+			return nil, err
+		}
+		if declOnly {
+			return nil, nil
+		}
+	default:
+		if list == PetList {
+			spell, err := validatePetSpellName(name)
+			if err != nil {
+				return nil, p.errorf(nameCol, "unsupported pet action: %v", err)
+			}
+			def.Action = "pet_cast"
+			def.Spell = spell
+			break
+		}
+		spell, err := validateSpellName(name)
+		if err != nil {
+			return nil, p.errorf(nameCol, "unsupported action: %v", err)
+		}
+		def.Action = "cast_spell"
+		def.Spell = spell
+		if opt, ok := opts["interrupt_if"]; ok {
+			used["interrupt_if"] = true
+			expr, err := p.translateExpression(opt.value, opt.col)
+			if err != nil {
+				return nil, err
+			}
+			def.InterruptIf = NewConditionNode(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: expr})
+		}
+	}
+
+	for _, key := range sortedOptionKeys(opts) {
+		if !used[key] {
+			return nil, p.errorf(opts[key].col-len(key)-1, "unsupported option '%s' for %s", key, name)
+		}
+	}
+	if opt, ok := opts["if"]; ok {
+		expr, err := p.translateExpression(opt.value, opt.col)
+		if err != nil {
+			return nil, err
+		}
+		def.When = NewConditionNode(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: expr})
+	}
+	return def, nil
+}
+
+// parseVariable fills a set/increment/reset_variable action and declares the
+// variable. It reports true when the entry only declares a default.
+func (p *simcParser) parseVariable(def *ActionDefinition, opts map[string]simcOption, used map[string]bool, require func(string) (simcOption, error)) (bool, error) {
+	nameOpt, err := require("name")
+	if err != nil {
+		return false, err
+	}
+	def.Variable = nameOpt.value
+	used["default"], used["op"], used["value"] = true, true, true
+
+	initial := 0.0
+	if opt, ok := opts["default"]; ok {
+		initial, err = strconv.ParseFloat(opt.value, 64)
+		if err != nil {
+			return false, p.errorf(opt.col, "variable default= must be a number, got '%s'", opt.value)
+		}
+	}
+	if _, declared := p.file.Variables[def.Variable]; !declared || opts["default"].value != "" {
+		p.file.Variables[def.Variable] = initial
+	}
+
+	op := "set"
+	if opt, ok := opts["op"]; ok {
+		op = opt.value
+	}
+	switch op {
+	case "set", "add":
+		def.Action = "set_variable"
+		if op == "add" {
+			def.Action = "increment_variable"
+		}
+		opt, err := require("value")
+		if err != nil {
+			return false, err
+		}
+		value, err := strconv.ParseFloat(opt.value, 64)
+		if err != nil {
+			return false, p.errorf(opt.col, "only numeric variable values are supported, got '%s'", opt.value)
+		}
+		def.Value = value
+	case "reset":
+		// "default=...,op=reset" is how ExportSimC declares a variable.
+		if opts["default"].value != "" {
+			return true, nil
+		}
+		def.Action = "reset_variable"
+	default:
+		return false, p.errorf(opts["op"].col, "unsupported variable op '%s' (use set, add or reset)", op)
+	}
+	return false, nil
+}
+
+func sortedOptionKeys(opts map[string]simcOption) []string {
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return opts[keys[i]].col < opts[keys[j]].col })
+	return keys
+}
+
+// translateExpression rewrites a SimC if= expression into the APL expression
+// language. col is the column of src[0].
+func (p *simcParser) translateExpression(src string, col int) (string, error) {
+	var out []string
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		at := col + i
+		switch {
+		case c == ' ':
+			i++
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			out = append(out, src[start:i])
+		case isIdentStart(c):
+			start := i
+			for i < len(src) && (isIdentPart(src[i]) || src[i] == '.') {
+				i++
+			}
+			ident, err := p.translateIdentifier(src[start:i])
+			if err != nil {
+				return "", p.errorf(at, "%v", err)
+			}
+			out = append(out, ident)
+		default:
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch two {
+			case "<=", ">=", "!=", "==":
+				out = append(out, two)
+				i += 2
+				continue
+			case "<?", ">?":
+				return "", p.errorf(at, "unsupported operator '%s' (min/max)", two)
+			case "&&", "||":
+				return "", p.errorf(at, "unsupported operator '%s' (use '%c')", two, c)
+			}
+			switch c {
+			case '&':
+				out = append(out, "and")
+			case '|':
+				out = append(out, "or")
+			case '!':
+				out = append(out, "not")
+			case '=':
+				out = append(out, "==")
+			case '%':
+				out = append(out, "/")
+			case '<', '>', '+', '-', '*', '(', ')':
+				out = append(out, string(c))
+			case '@':
+				return "", p.errorf(at, "unsupported operator '@' (absolute value)")
+			case '^':
+				return "", p.errorf(at, "unsupported operator '^' (xor)")
+			default:
+				return "", p.errorf(at, "unexpected character '%c'", c)
+			}
+			i++
+		}
+	}
+	if len(out) == 0 {
+		return "", p.errorf(col, "empty if= expression")
+	}
+	expr := strings.Join(out, " ")
+	return strings.ReplaceAll(strings.ReplaceAll(expr, "( ", "("), " )", ")"), nil
+}
+
+// translateIdentifier maps one dotted SimC expression name to the APL language.
+// Names are case-insensitive except variable names, which keep their case.
+func (p *simcParser) translateIdentifier(raw string) (string, error) {
+	ident := strings.ToLower(raw)
+	parts := strings.Split(ident, ".")
+	unsupported := fmt.Errorf("unsupported expression '%s'", ident)
+	call := func(fn string, validate func(string) (string, error), name string) (string, error) {
+		n, err := validate(name)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", ident, err)
+		}
+		return fn + "(" + n + ")", nil
+	}
+	switch len(parts) {
+	case 1:
+		switch ident {
+		case "time":
+			return "time_elapsed", nil
+		case "fight_remains":
+			return "time_remaining", nil
+		}
+	case 2:
+		switch {
+		case parts[0] == "gcd" && parts[1] == "remains":
+			return "gcd_remaining", nil
+		case parts[0] == "target" && parts[1] == "time_to_die":
+			return "time_remaining", nil
+		case parts[0] == "prev":
+			return call("last_cast", validateSpellName, parts[1])
+		case parts[0] == "variable":
+			name := raw[len("variable."):]
+			if _, ok := p.file.Variables[name]; !ok {
+				p.file.Variables[name] = 0.0
+			}
+			return "variable(" + name + ")", nil
+		case parts[1] == "pct":
+			res, err := validateResourceName(parts[0])
+			if err != nil {
+				return "", fmt.Errorf("%s: %v", ident, err)
+			}
+			return "( " + res + "_pct * 100 )", nil
+		}
+	case 3:
+		kind, name, field := parts[0], parts[1], parts[2]
+		switch kind {
+		case "buff":
+			switch field {
+			case "up", "react":
+				return call("buff_active", validateBuffName, name)
+			case "down":
+				s, err := call("buff_active", validateBuffName, name)
+				return "( not " + s + " )", err
+			case "remains":
+				return call("buff_remaining", validateBuffName, name)
+			case "stack", "charges":
+				return call("buff_charges", validateBuffName, name)
+			case "max_stack":
+				return call("aura_max_stacks", validateAuraName, name)
+			}
+		case "debuff", "dot":
+			switch field {
+			case "up", "ticking":
+				return call("debuff_active", validateDebuffName, name)
+			case "down":
+				s, err := call("debuff_active", validateDebuffName, name)
+				return "( not " + s + " )", err
+			case "remains":
+				if kind == "dot" {
+					return call("dot_remaining", validateDebuffName, name)
+				}
+				return call("debuff_remaining", validateDebuffName, name)
+			case "ticks_remain":
+				return call("ticks_remaining", validateDebuffName, name)
+			case "stack":
+				return call("aura_stacks", validateAuraName, name)
+			case "max_stack":
+				return call("aura_max_stacks", validateAuraName, name)
+			}
+		case "cooldown":
+			switch field {
+			case "ready", "up":
+				return call("cooldown_ready", validateCooldownName, name)
+			case "remains":
+				return call("cooldown_remaining", validateCooldownName, name)
+			}
+		case "action":
+			if field == "cast_time" {
+				return call("cast_time", validateSpellName, name)
+			}
+		case "target":
+			if name == "health" && field == "pct" {
+				return "( target_health_pct * 100 )", nil
+			}
+		case "prev_gcd":
+			if name == "1" {
+				return call("last_cast", validateSpellName, field)
+			}
+		case "pet":
+			if field == "pct" {
+				res, err := validateResourceName("pet_" + name)
+				if err != nil {
+					return "", fmt.Errorf("%s: %v", ident, err)
+				}
+				return "( " + res + "_pct * 100 )", nil
+			}
+		}
+	case 4:
+		if parts[0] == "pet" && parts[1] == "cooldown" {
+			switch parts[3] {
+			case "ready", "up":
+				return call("pet_cooldown_ready", validatePetSpellName, parts[2])
+			case "remains":
+				return call("pet_cooldown_remaining", validatePetSpellName, parts[2])
+			}
+		}
+	}
+	return "", unsupported
+}
+
+// --- export ---
+
+// ExportSimC renders a compiled rotation as SimC action lines. Actions with
+// no SimC equivalent (macro, sequence, wait_until, casts_since) are errors.
+// Phases become the leading run_action_list entries of "actions".
+func ExportSimC(rot *CompiledRotation) (string, error) {
+	if rot == nil {
+		return "", fmt.Errorf("nil rotation")
+	}
+	e := &simcExporter{vars: map[string]bool{}, up: map[string]bool{}}
+	type list struct {
+		key   string
+		lines []string
+	}
+	render := func(key string, actions []*Action) (list, error) {
+		out := list{key: key}
+		for idx, action := range actions {
+			if action == nil {
+				continue
+			}
+			line, err := e.simcAction(action)
+			if err != nil {
+				return out, fmt.Errorf("%s[%d]: %w", key, idx, err)
+			}
+			out.lines = append(out.lines, line)
+		}
+		return out, nil
+	}
+
+	var lists []list
+	precombat, err := render("actions."+PrecombatList, rot.Precombat)
+	if err != nil {
+		return "", err
+	}
+	main, err := render("actions", rot.Actions)
+	if err != nil {
+		return "", err
+	}
+	var phaseLines []string
+	for _, phase := range rot.Phases {
+		line, err := e.simcPhase(phase)
+		if err != nil {
+			return "", fmt.Errorf("phase '%s': %w", phase.Name, err)
+		}
+		phaseLines = append(phaseLines, line)
+	}
+	main.lines = append(phaseLines, main.lines...)
+	lists = append(lists, precombat, main)
+	listNames := make([]string, 0, len(rot.Lists))
+	for name := range rot.Lists {
+		listNames = append(listNames, name)
+	}
+	sort.Strings(listNames)
+	for _, name := range listNames {
+		named, err := render("actions."+name, rot.Lists[name])
+		if err != nil {
+			return "", err
+		}
+		lists = append(lists, named)
+	}
+	if len(rot.Pet) > 0 {
+		pet, err := render("actions."+PetList, rot.Pet)
+		if err != nil {
+			return "", err
+		}
+		lists = append(lists, pet)
+	}
+
+	// Declare every numeric variable before anything else so the variables
+	// block survives a re-import; ${name} references are already inlined.
+	for name := range rot.RuntimeVariables {
+		e.vars[name] = true
+	}
+	varNames := make([]string, 0, len(e.vars))
+	for name := range e.vars {
+		varNames = append(varNames, name)
+	}
+	sort.Strings(varNames)
+	var declarations []string
+	for _, name := range varNames {
+		declarations = append(declarations, fmt.Sprintf("variable,name=%s,default=%s,op=reset", name, simcNumber(rot.RuntimeVariables[name])))
+	}
+	lists[0].lines = append(declarations, lists[0].lines...)
+
+	var b strings.Builder
+	if rot.Name != "" {
+		fmt.Fprintf(&b, "# %s\n", rot.Name)
+	}
+	for _, l := range lists {
+		for idx, line := range l.lines {
+			if idx == 0 {
+				fmt.Fprintf(&b, "%s=%s\n", l.key, line)
+			} else {
+				fmt.Fprintf(&b, "%s+=/%s\n", l.key, line)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// simcExporter collects the runtime variables referenced while rendering and
+// tracks the auras an enclosing condition already knows are up.
+type simcExporter struct {
+	vars map[string]bool
+	up   map[string]bool
+}
+
+// simcPhase renders a phase as a run_action_list whose condition starts with
+// the marker, if any.
+func (e *simcExporter) simcPhase(phase *Phase) (string, error) {
+	var terms []string
+	if phase.Marker != "" {
+		terms = append(terms, "raid_event."+phase.Marker+".up")
+	}
+	if _, always := phase.Condition.(trueCondition); phase.Condition != nil && !always {
+		cond, prec, err := e.simcCondition(phase.Condition)
+		if err != nil {
+			return "", err
+		}
+		if phase.Marker != "" {
+			cond = simcWrap(cond, prec, simcPrecAnd)
+		}
+		terms = append(terms, cond)
+	}
+	return "run_action_list,name=" + phase.List + ",if=" + strings.Join(terms, "&"), nil
+}
+
+func (e *simcExporter) simcAction(action *Action) (string, error) {
+	var line string
+	if action.Type.IsVariableAction() {
+		e.vars[action.Variable] = true
+	}
+	switch action.Type {
+	case ActionCastSpell:
+		line = action.Spell
+		if action.Interrupt != nil {
+			cond, _, err := e.simcCondition(action.Interrupt)
+			if err != nil {
+				return "", err
+			}
+			line += ",interrupt_if=" + cond
+		}
+	case ActionPetCast:
+		line = action.Spell
+	case ActionCancelBuff:
+		line = "cancel_buff,name=" + action.Buff
+	case ActionUseItem:
+		line = "use_item,name=" + action.Item
+	case ActionWait:
+		line = "wait,sec=" + simcNumber(action.Duration.Seconds())
+	case ActionCallList, ActionRunList:
+		line = action.Type.String() + ",name=" + action.List
+	case ActionSetVariable:
+		line = fmt.Sprintf("variable,name=%s,op=set,value=%s", action.Variable, simcNumber(action.Value))
+	case ActionIncrementVariable:
+		line = fmt.Sprintf("variable,name=%s,op=add,value=%s", action.Variable, simcNumber(action.Value))
+	case ActionResetVariable:
+		line = fmt.Sprintf("variable,name=%s,op=reset", action.Variable)
+	default:
+		return "", fmt.Errorf("%s has no SimC equivalent", action.Type)
+	}
+	if action.Condition == nil {
+		return line, nil
+	}
+	if _, ok := action.Condition.(trueCondition); ok {
+		return line, nil
+	}
+	cond, _, err := e.simcCondition(action.Condition)
+	if err != nil {
+		return "", err
+	}
+	return line + ",if=" + cond, nil
+}
+
+// Operator precedence levels, loosest first; SimC's '!' binds tighter than
+// comparisons, so its operand must be an atom.
+const (
+	simcPrecOr = iota + 1
+	simcPrecAnd
+	simcPrecCompare
+	simcPrecAdd
+	simcPrecMul
+	simcPrecUnary
+	simcPrecAtom
+)
+
+func simcWrap(text string, prec, min int) string {
+	if prec < min {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+func simcNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e6)/1e6, 'f', -1, 64)
+}
+
+// simcCondition renders a compiled condition and reports its precedence.
+func (e *simcExporter) simcCondition(c Condition) (string, int, error) {
+	switch v := c.(type) {
+	case trueCondition:
+		return "1", simcPrecAtom, nil
+	case falseCondition:
+		return "0", simcPrecAtom, nil
+	case allCondition:
+		return e.simcJoin(v.children, "&", simcPrecAnd)
+	case anyCondition:
+		// In "!X.up|..." every other branch may assume X is up.
+		for _, child := range v.children {
+			if prefix := simcDownPrefix(child); prefix != "" && !e.up[prefix] {
+				e.up[prefix] = true
+				defer delete(e.up, prefix)
+			}
+		}
+		return e.simcJoin(v.children, "|", simcPrecOr)
+	case notCondition:
+		if v.child == nil {
+			return "1", simcPrecAtom, nil
+		}
+		text, prec, err := e.simcCondition(v.child)
+		if err != nil {
+			return "", 0, err
+		}
+		return "!" + simcWrap(text, prec, simcPrecAtom), simcPrecUnary, nil
+	case exprCondition:
+		return e.simcExpr(v.root)
+	case buffActiveCondition:
+		return e.simcActive("buff."+v.name, v.minRemaining, v.maxRemaining)
+	case debuffActiveCondition:
+		return e.simcActive("debuff."+v.name, v.minRemaining, v.maxRemaining)
+	case auraActiveCondition:
+		return e.simcActive(simcAuraPrefix(v.name), v.minRemaining, v.maxRemaining)
+	case auraRemainingCondition:
+		return simcTerms(simcDurationComparisons(simcAuraPrefix(v.name)+".remains", v.lt, v.lte, v.gt, v.gte))
+	case auraStacksCondition:
+		return simcTerms(simcIntComparisons(simcAuraPrefix(v.name)+".stack", v.lt, v.lte, v.gt, v.gte))
+	case auraAtMaxStacksCondition:
+		prefix := simcAuraPrefix(v.name)
+		return prefix + ".stack>=" + prefix + ".max_stack", simcPrecCompare, nil
+	case dotRemainingCondition:
+		return simcTerms(simcDurationComparisons("dot."+v.spell+".remains", v.lt, v.lte, v.gt, v.gte))
+	case resourcePercentCondition:
+		return simcTerms(simcComparisons(simcResource(v.resource)+".pct", v.lt, v.lte, v.gt, v.gte, 100))
+	case cooldownReadyCondition:
+		return "cooldown." + v.name + ".ready", simcPrecAtom, nil
+	case cooldownRemainingCondition:
+		return simcTerms(simcDurationComparisons("cooldown."+v.name+".remains", v.lt, v.lte, v.gt, v.gte))
+	case petCooldownReadyCondition:
+		return "pet.cooldown." + v.spell + ".ready", simcPrecAtom, nil
+	case petCooldownRemainingCondition:
+		return simcTerms(simcDurationComparisons("pet.cooldown."+v.spell+".remains", v.lt, v.lte, v.gt, v.gte))
+	case chargesCondition:
+		return simcTerms(simcIntComparisons("buff."+v.buff+".stack", v.lt, v.lte, v.gt, v.gte))
+	case fightTimeCondition:
+		subject := "time"
+		if v.remaining {
+			subject = "fight_remains"
+		}
+		return simcTerms(simcDurationComparisons(subject, v.lt, v.lte, v.gt, v.gte))
+	case targetHealthCondition:
+		return simcTerms(simcComparisons("target.health.pct", v.lt, v.lte, v.gt, v.gte, 100))
+	case castTimeCondition:
+		return simcTerms(simcDurationComparisons("action."+v.spell+".cast_time", v.lt, v.lte, v.gt, v.gte))
+	case gcdRemainingCondition:
+		return simcTerms(simcDurationComparisons("gcd.remains", v.lt, v.lte, v.gt, v.gte))
+	case lastCastCondition:
+		return "prev." + v.spell, simcPrecAtom, nil
+	case ticksRemainingCondition:
+		return simcTerms(simcIntComparisons("dot."+v.debuff+".ticks_remain", v.lt, v.lte, v.gt, v.gte))
+	case variableCondition:
+		e.vars[v.name] = true
+		subject := "variable." + v.name
+		var terms []string
+		if v.eq != nil {
+			terms = append(terms, subject+"="+simcNumber(*v.eq))
+		}
+		terms = append(terms, simcComparisons(subject, v.lt, v.lte, v.gt, v.gte, 1)...)
+		return simcTerms(terms)
+	case castsSinceCondition:
+		return "", 0, fmt.Errorf("casts_since has no SimC equivalent")
+	}
+	return "", 0, fmt.Errorf("condition %T has no SimC equivalent", c)
+}
+
+// simcActive renders an ".up" check with optional remains bounds. The ".up"
+// term is left out when the rest already implies it: remains>=N (N>0) only
+// holds while the aura is up, and an enclosing "!X.up|..." covers the rest.
+func (e *simcExporter) simcActive(prefix string, minRemaining, maxRemaining *time.Duration) (string, int, error) {
+	terms := simcComparisons(prefix+".remains", nil, secondsPtr(maxRemaining), nil, secondsPtr(minRemaining), 1)
+	implied := e.up[prefix] || (minRemaining != nil && *minRemaining > 0)
+	if len(terms) == 0 || !implied {
+		terms = append([]string{prefix + ".up"}, terms...)
+	}
+	return simcTerms(terms)
+}
+
+// simcDownPrefix returns "buff.x" for a plain "not buff x active" condition
+// (likewise for debuffs and auras), or "".
+func simcDownPrefix(c Condition) string {
+	not, ok := c.(notCondition)
+	if !ok {
+		return ""
+	}
+	switch v := not.child.(type) {
+	case buffActiveCondition:
+		if v.minRemaining == nil && v.maxRemaining == nil {
+			return "buff." + v.name
+		}
+	case debuffActiveCondition:
+		if v.minRemaining == nil && v.maxRemaining == nil {
+			return "debuff." + v.name
+		}
+	case auraActiveCondition:
+		if v.minRemaining == nil && v.maxRemaining == nil {
+			return simcAuraPrefix(v.name)
+		}
+	}
+	return ""
+}
+
+func (e *simcExporter) simcJoin(children []Condition, op string, prec int) (string, int, error) {
+	if len(children) == 0 {
+		if op == "&" {
+			return "1", simcPrecAtom, nil
+		}
+		return "0", simcPrecAtom, nil
+	}
+	if len(children) == 1 {
+		return e.simcCondition(children[0])
+	}
+	parts := make([]string, 0, len(children))
+	for _, child := range children {
+		text, childPrec, err := e.simcCondition(child)
+		if err != nil {
+			return "", 0, err
+		}
+		parts = append(parts, simcWrap(text, childPrec, prec+1))
+	}
+	return strings.Join(parts, op), prec, nil
+}
+
+// simcTerms joins comparator terms of one predicate with '&'.
+func simcTerms(terms []string) (string, int, error) {
+	switch len(terms) {
+	case 0:
+		return "1", simcPrecAtom, nil
+	case 1:
+		if strings.ContainsAny(terms[0], "<>=") {
+			return terms[0], simcPrecCompare, nil
+		}
+		return terms[0], simcPrecAtom, nil
+	}
+	return strings.Join(terms, "&"), simcPrecAnd, nil
+}
+
+func simcComparisons(subject string, lt, lte, gt, gte *float64, scale float64) []string {
+	var terms []string
+	add := func(op string, v *float64) {
+		if v != nil {
+			terms = append(terms, subject+op+simcNumber(*v*scale))
+		}
+	}
+	add("<", lt)
+	add("<=", lte)
+	add(">", gt)
+	add(">=", gte)
+	return terms
+}
+
+func simcDurationComparisons(subject string, lt, lte, gt, gte *time.Duration) []string {
+	return simcComparisons(subject, secondsPtr(lt), secondsPtr(lte), secondsPtr(gt), secondsPtr(gte), 1)
+}
+
+func simcIntComparisons(subject string, lt, lte, gt, gte *int) []string {
+	toFloat := func(v *int) *float64 {
+		if v == nil {
+			return nil
+		}
+		f := float64(*v)
+		return &f
+	}
+	return simcComparisons(subject, toFloat(lt), toFloat(lte), toFloat(gt), toFloat(gte), 1)
+}
+
+// simcExpr renders an expression tree; variables were already inlined by the compiler.
+func (e *simcExporter) simcExpr(node exprNode) (string, int, error) {
+	binary := func(op string, prec int, left, right exprNode) (string, int, error) {
+		l, lp, err := e.simcExpr(left)
+		if err != nil {
+			return "", 0, err
+		}
+		r, rp, err := e.simcExpr(right)
+		if err != nil {
+			return "", 0, err
+		}
+		return simcWrap(l, lp, prec) + op + simcWrap(r, rp, prec+1), prec, nil
+	}
+	switch v := node.(type) {
+	case numberLiteral:
+		text := simcNumber(float64(v))
+		if v < 0 {
+			return "(" + text + ")", simcPrecAtom, nil
+		}
+		return text, simcPrecAtom, nil
+	case boolLiteral:
+		if v {
+			return "1", simcPrecAtom, nil
+		}
+		return "0", simcPrecAtom, nil
+	case numCallExpr:
+		return e.simcCall(v.name, v.args)
+	case boolCallExpr:
+		return e.simcCall(v.name, v.args)
+	case negateExpr:
+		text, prec, err := e.simcExpr(v.operand)
+		if err != nil {
+			return "", 0, err
+		}
+		return "-" + simcWrap(text, prec, simcPrecAtom), simcPrecUnary, nil
+	case notExpr:
+		text, prec, err := e.simcExpr(v.operand)
+		if err != nil {
+			return "", 0, err
+		}
+		return "!" + simcWrap(text, prec, simcPrecAtom), simcPrecUnary, nil
+	case arithExpr:
+		// "( mana_pct * 100 )" is how the importer spells mana.pct.
+		if call, ok := v.left.(numCallExpr); ok && v.op == '*' {
+			if lit, ok := v.right.(numberLiteral); ok && lit == 100 {
+				switch call.name {
+				case "resource_pct":
+					return simcResource(call.args[0]) + ".pct", simcPrecAtom, nil
+				case "target_health_pct":
+					return "target.health.pct", simcPrecAtom, nil
+				}
+			}
+		}
+		switch v.op {
+		case '+', '-':
+			return binary(string(v.op), simcPrecAdd, v.left, v.right)
+		case '*':
+			return binary("*", simcPrecMul, v.left, v.right)
+		default:
+			return binary("%", simcPrecMul, v.left, v.right)
+		}
+	case compareExpr:
+		op := v.op
+		if op == "==" {
+			op = "="
+		}
+		return binary(op, simcPrecCompare, v.left, v.right)
+	case boolEqualExpr:
+		op := "="
+		if v.negate {
+			op = "!="
+		}
+		return binary(op, simcPrecCompare, v.left, v.right)
+	case andExpr:
+		return binary("&", simcPrecAnd, v.left, v.right)
+	case orExpr:
+		return binary("|", simcPrecOr, v.left, v.right)
+	}
+	return "", 0, fmt.Errorf("expression node %T has no SimC equivalent", node)
+}
+
+func (e *simcExporter) simcCall(name string, args []string) (string, int, error) {
+	arg := ""
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	switch name {
+	case "debuff_remaining":
+		return "debuff." + arg + ".remains", simcPrecAtom, nil
+	case "dot_remaining":
+		return "dot." + arg + ".remains", simcPrecAtom, nil
+	case "debuff_active":
+		return "debuff." + arg + ".up", simcPrecAtom, nil
+	case "buff_remaining":
+		return "buff." + arg + ".remains", simcPrecAtom, nil
+	case "buff_active":
+		return "buff." + arg + ".up", simcPrecAtom, nil
+	case "buff_charges":
+		return "buff." + arg + ".stack", simcPrecAtom, nil
+	case "aura_active":
+		return simcAuraPrefix(arg) + ".up", simcPrecAtom, nil
+	case "aura_remaining":
+		return simcAuraPrefix(arg) + ".remains", simcPrecAtom, nil
+	case "aura_stacks":
+		return simcAuraPrefix(arg) + ".stack", simcPrecAtom, nil
+	case "aura_max_stacks":
+		return simcAuraPrefix(arg) + ".max_stack", simcPrecAtom, nil
+	case "cooldown_remaining":
+		return "cooldown." + arg + ".remains", simcPrecAtom, nil
+	case "cooldown_ready":
+		return "cooldown." + arg + ".ready", simcPrecAtom, nil
+	case "resource_pct":
+		return simcResource(arg) + ".pct%100", simcPrecMul, nil
+	case "pet_cooldown_ready":
+		return "pet.cooldown." + arg + ".ready", simcPrecAtom, nil
+	case "pet_cooldown_remaining":
+		return "pet.cooldown." + arg + ".remains", simcPrecAtom, nil
+	case "cast_time":
+		return "action." + arg + ".cast_time", simcPrecAtom, nil
+	case "last_cast":
+		return "prev." + arg, simcPrecAtom, nil
+	case "ticks_remaining":
+		return "dot." + arg + ".ticks_remain", simcPrecAtom, nil
+	case "variable":
+		e.vars[arg] = true
+		return "variable." + arg, simcPrecAtom, nil
+	case "time_elapsed":
+		return "time", simcPrecAtom, nil
+	case "time_remaining":
+		return "fight_remains", simcPrecAtom, nil
+	case "gcd_remaining":
+		return "gcd.remains", simcPrecAtom, nil
+	case "target_health_pct":
+		return "target.health.pct%100", simcPrecMul, nil
+	}
+	return "", 0, fmt.Errorf("%s has no SimC equivalent", name)
+}
+
+// simcResource spells a resource name as a SimC expression prefix; pet
+// resources live under "pet.".
+func simcResource(name string) string {
+	if res, ok := strings.CutPrefix(name, "pet_"); ok {
+		return "pet." + res
+	}
+	return name
+}
+
+// simcAuraPrefix picks the SimC namespace of an aura_* name.
+func simcAuraPrefix(name string) string {
+	if _, ok := knownDebuffs[name]; ok {
+		return "debuff." + name
+	}
+	return "buff." + name
+}
@@ -0,0 +1,201 @@
+package apl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRotationDir = "../../configs/rotations"
+
+// simcRoundTrip exports rot, parses the text back and exports that again.
+func simcRoundTrip(t *testing.T, rot *CompiledRotation) (first, second string, back *CompiledRotation) {
+	t.Helper()
+	first, err := ExportSimC(rot)
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	file, err := ParseSimC(first)
+	if err != nil {
+		t.Fatalf("parse exported text: %v\n%s", err, first)
+	}
+	back, err = Compile(file)
+	if err != nil {
+		t.Fatalf("compile parsed text: %v\n%s", err, first)
+	}
+	second, err = ExportSimC(back)
+	if err != nil {
+		t.Fatalf("export parsed text: %v", err)
+	}
+	return first, second, back
+}
+
+func TestSimCRoundTripShippedRotations(t *testing.T) {
+	for _, name := range []string{
+		"demonology-default.yaml",
+		"destruction-cataclysmic.yaml",
+		"destruction-cataclysmic-2.yaml",
+		"destruction-decisive.yaml",
+		"destruction-default.yaml",
+		"destruction-default-guldans.yaml",
+		"destruction-empowered-imp.yaml",
+		"destruction-imp-control.yaml",
+		"destruction-phases.yaml",
+		"destruction-shadowbolt.yaml",
+		"destruction-shadowbolt-void.yaml",
+		"destructuin-decisivfe-2.yaml",
+	} {
+		t.Run(name, func(t *testing.T) {
+			file, err := LoadRotation(testRotationDir, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rot, err := Compile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, second, back := simcRoundTrip(t, rot)
+			if first != second {
+				t.Fatalf("export is not stable:\n--- first\n%s\n--- second\n%s", first, second)
+			}
+
+			// The parsed rotation must make the same choices as the original.
+			fixtures, err := LoadFixtures(FixturePath(filepath.Join(testRotationDir, name)))
+			if os.IsNotExist(err) {
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, res := range RunFixtures(back, fixtures) {
+				if !res.Passed {
+					t.Errorf("after round trip: %s", res)
+				}
+			}
+		})
+	}
+}
+
+func TestSimCRoundTripText(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+	}{
+		// Sources are in the exporter's canonical spelling, so the export
+		// must reproduce every line.
+		{"simple", `actions=immolate,if=!debuff.immolate.up|dot.immolate.remains<2
+actions+=/conflagrate,if=cooldown.conflagrate.ready
+actions+=/incinerate
+`},
+		{"lists and precombat", `actions.precombat=life_tap
+actions=call_action_list,name=aoe,if=buff.backdraft.stack>=2
+actions+=/incinerate
+actions.aoe=chaos_bolt
+`},
+		{"variables", `actions.precombat=variable,name=bursts,default=0,op=reset
+actions=variable,name=bursts,op=add,value=1
+actions+=/chaos_bolt,if=variable.bursts>=2
+actions+=/incinerate
+`},
+		{"variable case", `actions.precombat=variable,name=BurstCount,default=0,op=reset
+actions=variable,name=BurstCount,op=add,value=1
+actions+=/chaos_bolt,if=variable.BurstCount>=2
+`},
+		{"phases", `actions=run_action_list,name=move,if=raid_event.movement.up&(fight_remains<15|mana.pct<20)
+actions+=/run_action_list,name=execute,if=target.health.pct<20
+actions+=/incinerate
+actions.execute=chaos_bolt
+actions.move=conflagrate
+`},
+		{"pet list", `actions=incinerate
+actions.pet=fire_shield,if=!buff.fire_shield.up
+actions.pet+=/firebolt,if=pet.mana.pct>15&pet.cooldown.firebolt.ready
+actions.pet+=/wait,sec=1
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseSimC(tt.src)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			rot, err := Compile(file)
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+			first, second, _ := simcRoundTrip(t, rot)
+			if first != second {
+				t.Fatalf("export is not stable:\n--- first\n%s\n--- second\n%s", first, second)
+			}
+			for _, line := range strings.Split(strings.TrimSpace(tt.src), "\n") {
+				if !strings.Contains(first, line) {
+					t.Errorf("export lost %q:\n%s", line, first)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSimCPhases(t *testing.T) {
+	file, err := ParseSimC(`actions=run_action_list,name=move,if=raid_event.movement.up
+actions+=/run_action_list,name=move,if=raid_event.movement.up&time>5
+actions+=/run_action_list,name=execute,if=fight_remains<15
+actions+=/incinerate
+actions+=/run_action_list,name=execute,if=fight_remains<5
+actions.move=conflagrate
+actions.execute=chaos_bolt
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ name, marker, list string }{
+		{"move", "movement", ""},
+		{"move_2", "movement", "move"},
+		{"execute", "", ""},
+	}
+	if len(file.Phases) != len(want) {
+		t.Fatalf("got %d phases, want %d: %+v", len(file.Phases), len(want), file.Phases)
+	}
+	for i, w := range want {
+		got := file.Phases[i]
+		if got.Name != w.name || got.Marker != w.marker || got.List != w.list {
+			t.Errorf("phase %d = {%s %s %s}, want {%s %s %s}", i, got.Name, got.Marker, got.List, w.name, w.marker, w.list)
+		}
+	}
+	if file.Phases[0].When != nil || file.Phases[1].When == nil {
+		t.Errorf("when: got %v and %v, want only the second phase conditional", file.Phases[0].When, file.Phases[1].When)
+	}
+	// Past the first ordinary action a run_action_list is an action again.
+	if len(file.Rotation) != 2 || file.Rotation[1].Action != "run_action_list" {
+		t.Errorf("rotation = %+v, want incinerate then run_action_list", file.Rotation)
+	}
+	if _, err := Compile(file); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseSimCErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"actions=incinerate,if=", "line 1, column 23: empty if= expression"},
+		{"actions=frobnicate", "line 1, column 9: unsupported action: unknown spell 'frobnicate'"},
+		{"actions=incinerate,bogus=1", "line 1, column 20: unsupported option 'bogus' for incinerate"},
+		{"actions=immolate\nactions+=/incinerate,if=buff.backdraft.up@1", "line 2, column 42: unsupported operator '@'"},
+		{"actions=run_action_list,name=aoe,if=raid_event.movement.up|time>5", "line 1, column 37: unsupported expression 'raid_event.movement.up'"},
+		{"actions.pet=incinerate", "line 1, column 13: unsupported pet action: unknown pet spell 'incinerate'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := ParseSimC(tt.src)
+			if err == nil {
+				t.Fatalf("parsed, want error containing %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
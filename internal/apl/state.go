@@ -0,0 +1,269 @@
+package apl
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AuraState is a buff or debuff in a State snapshot. In YAML and JSON a bare
+// number is shorthand for {remaining: <seconds>}.
+type AuraState struct {
+	Remaining float64 `yaml:"remaining" json:"remaining"`                       // seconds left
+	Charges   int     `yaml:"charges,omitempty" json:"charges,omitempty"`       // charges or stacks
+	Ticks     int     `yaml:"ticks,omitempty" json:"ticks,omitempty"`           // DoT ticks left
+	MaxStacks int     `yaml:"max_stacks,omitempty" json:"max_stacks,omitempty"` // stack cap; default 1
+}
+
+func (a *AuraState) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&a.Remaining)
+	}
+	type plain AuraState
+	return node.Decode((*plain)(a))
+}
+
+func (a *AuraState) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Remaining); err == nil {
+		return nil
+	}
+	type plain AuraState
+	return json.Unmarshal(data, (*plain)(a))
+}
+
+// State is a static snapshot of everything a rotation can query. It implements
+// EvaluationContext, so a compiled rotation can be evaluated without running
+// the engine. Names are matched case-insensitively; times are in seconds and
+// mana/target health are fractions like resource_percent (0.45 = 45%).
+type State struct {
+	Buffs        map[string]AuraState `yaml:"buffs,omitempty" json:"buffs,omitempty"`
+	Debuffs      map[string]AuraState `yaml:"debuffs,omitempty" json:"debuffs,omitempty"`
+	Cooldowns    map[string]float64   `yaml:"cooldowns,omitempty" json:"cooldowns,omitempty"` // seconds until ready; absent = ready
+	Mana         *float64             `yaml:"mana,omitempty" json:"mana,omitempty"`           // default 1
+	TargetHealth *float64             `yaml:"target_health,omitempty" json:"target_health,omitempty"`
+	Time         float64              `yaml:"time,omitempty" json:"time,omitempty"`           // seconds into the fight
+	Remaining    *float64             `yaml:"remaining,omitempty" json:"remaining,omitempty"` // fight time left; default one hour
+	GCD          float64              `yaml:"gcd,omitempty" json:"gcd,omitempty"`
+	PrevCast     string               `yaml:"last_cast,omitempty" json:"last_cast,omitempty"`
+	CastCounts   map[string]int       `yaml:"casts_since,omitempty" json:"casts_since,omitempty"` // casts since spell was last cast
+	CastTimes    map[string]float64   `yaml:"cast_times,omitempty" json:"cast_times,omitempty"`   // default 0 (instant)
+	Variables    map[string]float64   `yaml:"variables,omitempty" json:"variables,omitempty"`     // default: the rotation's starting values
+	Markers      []string             `yaml:"markers,omitempty" json:"markers,omitempty"`         // active encounter phase markers
+	PetMana      *float64             `yaml:"pet_mana,omitempty" json:"pet_mana,omitempty"`       // default 1
+	PetCooldowns map[string]float64   `yaml:"pet_cooldowns,omitempty" json:"pet_cooldowns,omitempty"`
+	// Unavailable lists spells that cannot be cast right now for reasons the
+	// snapshot does not model (out of mana, missing talent or pet).
+	Unavailable []string `yaml:"unavailable,omitempty" json:"unavailable,omitempty"`
+}
+
+func seconds(v float64) time.Duration {
+	return time.Duration(v * float64(time.Second))
+}
+
+func lookup[V any](m map[string]V, name string) (V, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	lower := strings.ToLower(name)
+	for key, v := range m {
+		if strings.ToLower(key) == lower {
+			return v, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+func (s *State) BuffActive(name string) bool {
+	aura, ok := lookup(s.Buffs, name)
+	return ok && aura.Remaining > 0
+}
+
+func (s *State) BuffRemaining(name string) time.Duration {
+	aura, _ := lookup(s.Buffs, name)
+	return seconds(aura.Remaining)
+}
+
+func (s *State) BuffCharges(name string) int {
+	aura, _ := lookup(s.Buffs, name)
+	return aura.Charges
+}
+
+func (s *State) DebuffActive(name string) bool {
+	aura, ok := lookup(s.Debuffs, name)
+	return ok && aura.Remaining > 0
+}
+
+func (s *State) DebuffRemaining(name string) time.Duration {
+	aura, _ := lookup(s.Debuffs, name)
+	return seconds(aura.Remaining)
+}
+
+func (s *State) ResourcePercent(resource string) float64 {
+	var value *float64
+	switch strings.ToLower(resource) {
+	case "mana":
+		value = s.Mana
+	case "pet_mana":
+		value = s.PetMana
+	default:
+		return 0
+	}
+	if value == nil {
+		return 1
+	}
+	return *value
+}
+
+func (s *State) CooldownReady(name string) bool {
+	return s.CooldownRemaining(name) <= 0
+}
+
+func (s *State) CooldownRemaining(name string) time.Duration {
+	remaining, _ := lookup(s.Cooldowns, name)
+	if remaining < 0 {
+		return 0
+	}
+	return seconds(remaining)
+}
+
+func (s *State) PetCooldownRemaining(spell string) time.Duration {
+	remaining, _ := lookup(s.PetCooldowns, spell)
+	if remaining < 0 {
+		return 0
+	}
+	return seconds(remaining)
+}
+
+func (s *State) TimeElapsed() time.Duration {
+	return seconds(s.Time)
+}
+
+func (s *State) TimeRemaining() time.Duration {
+	if s.Remaining == nil {
+		return time.Hour
+	}
+	return seconds(*s.Remaining)
+}
+
+func (s *State) TargetHealthPercent() float64 {
+	if s.TargetHealth == nil {
+		return 1
+	}
+	return *s.TargetHealth
+}
+
+func (s *State) CastTime(spell string) time.Duration {
+	v, _ := lookup(s.CastTimes, spell)
+	return seconds(v)
+}
+
+func (s *State) GCDRemaining() time.Duration {
+	return seconds(s.GCD)
+}
+
+func (s *State) LastCast() string {
+	return strings.ToLower(s.PrevCast)
+}
+
+func (s *State) CastsSince(spell string) int {
+	v, _ := lookup(s.CastCounts, spell)
+	return v
+}
+
+func (s *State) TicksRemaining(debuff string) int {
+	aura, _ := lookup(s.Debuffs, debuff)
+	if aura.Remaining <= 0 {
+		return 0
+	}
+	return aura.Ticks
+}
+
+// aura looks name up among the buffs, then the debuffs.
+func (s *State) aura(name string) (AuraState, bool) {
+	if aura, ok := lookup(s.Buffs, name); ok {
+		return aura, true
+	}
+	return lookup(s.Debuffs, name)
+}
+
+func (s *State) AuraActive(name string) bool {
+	aura, ok := s.aura(name)
+	return ok && aura.Remaining > 0
+}
+
+func (s *State) AuraRemaining(name string) time.Duration {
+	aura, _ := s.aura(name)
+	return seconds(aura.Remaining)
+}
+
+func (s *State) AuraStacks(name string) int {
+	aura, ok := s.aura(name)
+	if !ok || aura.Remaining <= 0 {
+		return 0
+	}
+	if aura.Charges > 0 {
+		return aura.Charges
+	}
+	return 1
+}
+
+func (s *State) AuraMaxStacks(name string) int {
+	aura, _ := s.aura(name)
+	if aura.MaxStacks > 0 {
+		return aura.MaxStacks
+	}
+	return 1
+}
+
+// MarkerActive reports whether the snapshot lists an encounter phase marker.
+func (s *State) MarkerActive(name string) bool {
+	for _, marker := range s.Markers {
+		if normalizeName(marker) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) Variable(name string) float64 {
+	return s.Variables[name]
+}
+
+// Castable reports whether the snapshot allows casting spell: it is off
+// cooldown and not listed as unavailable.
+func (s *State) Castable(spell string) bool {
+	for _, name := range s.Unavailable {
+		if strings.EqualFold(name, spell) {
+			return false
+		}
+	}
+	return s.CooldownReady(spell)
+}
+
+// PetCastable is Castable for pet abilities, read from pet_cooldowns.
+func (s *State) PetCastable(spell string) bool {
+	for _, name := range s.Unavailable {
+		if strings.EqualFold(name, spell) {
+			return false
+		}
+	}
+	return s.PetCooldownRemaining(spell) <= 0
+}
+
+// WithDefaults returns a copy whose unset variables take the rotation's
+// starting values.
+func (s *State) WithDefaults(rot *CompiledRotation) State {
+	out := *s
+	vars := make(map[string]float64, len(rot.RuntimeVariables)+len(s.Variables))
+	for name, v := range rot.RuntimeVariables {
+		vars[name] = v
+	}
+	for name, v := range s.Variables {
+		vars[name] = v
+	}
+	out.Variables = vars
+	return out
+}
@@ -85,11 +85,21 @@ type Character struct {
 	}
 	GuldansChosen *effects.Aura
 
+	// Demonology
+	Metamorphosis      Buff
+	MoltenCore         Buff // Charges consumed by Incinerate/Soul Fire
+	Decimation         Buff
+	DemonicEmpowerment Buff // Empowers the active demon
+	DemonicPact        Buff // Value holds the spell power granted
+	DemonicSacrifice   Buff // Lasts the whole fight once the demon is sacrificed
+	ImmolationAura     Debuff
+
 	// Debuffs on target
 	Immolate        Debuff
 	Corruption      Debuff
 	CurseOfAgony    Debuff
 	CurseOfElements Debuff
+	CurseOfDoom     Debuff // Single delayed hit; TickDamage holds the snapshot
 
 	// Cooldowns
 	ChaosBolt   Cooldown
@@ -97,6 +107,12 @@ type Character struct {
 	Shadowburn  Cooldown
 	Shadowfury  Cooldown
 
+	MetamorphosisCooldown      Cooldown
+	DemonicEmpowermentCooldown Cooldown
+	ImmolationAuraCooldown     Cooldown
+	CurseOfDoomCooldown        Cooldown
+	InfernoCooldown            Cooldown
+
 	// GCD
 	GCD effects.Timer
 
@@ -105,6 +121,11 @@ type Character struct {
 	IsCasting   bool
 	CastEndsAt  time.Duration
 
+	// Cast history (APL last_cast / casts_since), keyed by APL spell name
+	LastCast    string
+	CastCount   int
+	CastIndexOf map[string]int // CastCount value when the spell was last cast
+
 	// Soul Leech tracking (for HoT ticks)
 	SoulLeechLastTick time.Duration
 
@@ -172,3 +193,19 @@ func (c *Character) GainMana(amount float64) {
 func (c *Character) AdvanceTime(duration time.Duration) {
 	c.CurrentTime += duration
 }
+
+// RecordCast appends a successful cast to the cast history.
+func (c *Character) RecordCast(name string) {
+	c.CastCount++
+	c.LastCast = name
+	if c.CastIndexOf == nil {
+		c.CastIndexOf = make(map[string]int)
+	}
+	c.CastIndexOf[name] = c.CastCount
+}
+
+// CastsSince returns how many casts happened after the last cast of name.
+// A spell that was never cast counts every cast since the pull.
+func (c *Character) CastsSince(name string) int {
+	return c.CastCount - c.CastIndexOf[name]
+}
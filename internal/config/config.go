@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -125,6 +127,52 @@ type Spells struct {
 		ManaCost      float64 `yaml:"mana_cost"`
 		SPCoefficient float64 `yaml:"sp_coefficient"`
 	} `yaml:"shadow_crash"`
+	ImmolationAura struct {
+		TickDamage        float64 `yaml:"tick_damage"`
+		Duration          float64 `yaml:"duration"`
+		Ticks             int     `yaml:"ticks"`
+		Cooldown          float64 `yaml:"cooldown"`
+		ManaCost          float64 `yaml:"mana_cost"`
+		SPCoefficientTick float64 `yaml:"sp_coefficient_tick"`
+	} `yaml:"immolation_aura"`
+	CurseOfDoom struct {
+		Damage                float64 `yaml:"damage"`
+		Duration              float64 `yaml:"duration"`
+		Cooldown              float64 `yaml:"cooldown"`
+		ManaCost              float64 `yaml:"mana_cost"`
+		SPCoefficient         float64 `yaml:"sp_coefficient"`
+		DoomguardSummonChance float64 `yaml:"doomguard_summon_chance"`
+	} `yaml:"curse_of_doom"`
+	Inferno struct {
+		BaseDamageMin float64 `yaml:"base_damage_min"`
+		BaseDamageMax float64 `yaml:"base_damage_max"`
+		CastTime      float64 `yaml:"cast_time"`
+		Cooldown      float64 `yaml:"cooldown"`
+		ManaCost      float64 `yaml:"mana_cost"`
+		SPCoefficient float64 `yaml:"sp_coefficient"`
+	} `yaml:"inferno"`
+	Infernal struct {
+		Duration                 float64 `yaml:"duration"`
+		BaseAttackPower          float64 `yaml:"base_attack_power"`
+		AttackPowerPerSpellPower float64 `yaml:"attack_power_per_spell_power"`
+		WeaponDamageMin          float64 `yaml:"weapon_damage_min"`
+		WeaponDamageMax          float64 `yaml:"weapon_damage_max"`
+		SwingSpeed               float64 `yaml:"swing_speed"`
+		ImmolationDamage         float64 `yaml:"immolation_damage"`
+		ImmolationInterval       float64 `yaml:"immolation_interval"`
+		ImmolationSPCoefficient  float64 `yaml:"immolation_sp_coefficient"`
+	} `yaml:"infernal"`
+	Doomguard struct {
+		Duration              float64 `yaml:"duration"`
+		DoomBoltDamageMin     float64 `yaml:"doom_bolt_damage_min"`
+		DoomBoltDamageMax     float64 `yaml:"doom_bolt_damage_max"`
+		DoomBoltCastTime      float64 `yaml:"doom_bolt_cast_time"`
+		DoomBoltSPCoefficient float64 `yaml:"doom_bolt_sp_coefficient"`
+	} `yaml:"doomguard"`
+	FelArmor struct {
+		SpellPower         float64 `yaml:"spell_power"`
+		SpiritToSpellPower float64 `yaml:"spirit_to_spell_power"`
+	} `yaml:"fel_armor"`
 }
 
 // Talents holds talent modifiers
@@ -184,6 +232,7 @@ type Talents struct {
 	DemonicPower struct {
 		Points                int     `yaml:"points"`
 		FireboltCastReduction float64 `yaml:"firebolt_cast_reduction"`
+		LashOfPainCooldownCut float64 `yaml:"lash_of_pain_cooldown_reduction"`
 	} `yaml:"demonic_power"`
 	EmpoweredImp struct {
 		Points             int     `yaml:"points"`
@@ -191,8 +240,96 @@ type Talents struct {
 		ProcChancePerPoint float64 `yaml:"proc_chance_per_point"`
 		BuffDuration       float64 `yaml:"buff_duration"`
 	} `yaml:"empowered_imp"`
+	MasterDemonologist struct {
+		Points int `yaml:"points"`
+		Imp    struct {
+			FireDamagePerPoint float64 `yaml:"fire_damage_per_point"`
+			FireCritPerPoint   float64 `yaml:"fire_crit_per_point"`
+		} `yaml:"imp"`
+		Felguard struct {
+			DamagePerPoint float64 `yaml:"damage_per_point"`
+			CritPerPoint   float64 `yaml:"crit_per_point"`
+		} `yaml:"felguard"`
+		Succubus struct {
+			ShadowDamagePerPoint float64 `yaml:"shadow_damage_per_point"`
+			ShadowCritPerPoint   float64 `yaml:"shadow_crit_per_point"`
+		} `yaml:"succubus"`
+	} `yaml:"master_demonologist"`
+	UnholyPower struct {
+		Points              int     `yaml:"points"`
+		MeleeDamagePerPoint float64 `yaml:"melee_damage_per_point"`
+	} `yaml:"unholy_power"`
+	ImprovedFelhunter struct {
+		Points                     int     `yaml:"points"`
+		ShadowBiteCooldownPerPoint float64 `yaml:"shadow_bite_cooldown_per_point"`
+		ManaReturnPerPoint         float64 `yaml:"mana_return_per_point"`
+	} `yaml:"improved_felhunter"`
+	MoltenCore struct {
+		Points                          int      `yaml:"points"`
+		ProcChancePerPoint              float64  `yaml:"proc_chance_per_point"`
+		ProcSpells                      []string `yaml:"proc_spells"`
+		Charges                         int      `yaml:"charges"`
+		Duration                        float64  `yaml:"duration"`
+		DamagePerPoint                  float64  `yaml:"damage_per_point"`
+		IncinerateCastReductionPerPoint float64  `yaml:"incinerate_cast_reduction_per_point"`
+		SoulFireCritPerPoint            float64  `yaml:"soul_fire_crit_per_point"`
+	} `yaml:"molten_core"`
+	DemonicEmpowerment struct {
+		Points       int     `yaml:"points"`
+		Cooldown     float64 `yaml:"cooldown"`
+		ManaCost     float64 `yaml:"mana_cost"`
+		ImpCritBonus float64 `yaml:"imp_crit_bonus"`
+		ImpDuration  float64 `yaml:"imp_duration"`
+
+		FelguardAttackSpeedBonus float64 `yaml:"felguard_attack_speed_bonus"`
+		FelguardDuration         float64 `yaml:"felguard_duration"`
+	} `yaml:"demonic_empowerment"`
+	Decimation struct {
+		Points                        int      `yaml:"points"`
+		ExecuteThreshold              float64  `yaml:"execute_threshold"`
+		Duration                      float64  `yaml:"duration"`
+		SoulFireCastReductionPerPoint float64  `yaml:"soul_fire_cast_reduction_per_point"`
+		TriggerSpells                 []string `yaml:"trigger_spells"`
+	} `yaml:"decimation"`
+	DemonicPact struct {
+		Points             int     `yaml:"points"`
+		SpellPowerFraction float64 `yaml:"spell_power_fraction"`
+		Duration           float64 `yaml:"duration"`
+	} `yaml:"demonic_pact"`
+	DemonicSacrifice struct {
+		Points int `yaml:"points"`
+		Imp    struct {
+			FireDamageBonus float64 `yaml:"fire_damage_bonus"`
+		} `yaml:"imp"`
+		Succubus struct {
+			ShadowDamageBonus float64 `yaml:"shadow_damage_bonus"`
+		} `yaml:"succubus"`
+		Felguard struct {
+			ShadowDamageBonus float64 `yaml:"shadow_damage_bonus"`
+			ManaReturnPercent float64 `yaml:"mana_return_percent"`
+		} `yaml:"felguard"`
+		Felhunter struct {
+			ManaReturnPercent float64 `yaml:"mana_return_percent"`
+		} `yaml:"felhunter"`
+		ManaReturnInterval float64 `yaml:"mana_return_interval"`
+	} `yaml:"demonic_sacrifice"`
+	Metamorphosis struct {
+		Points           int     `yaml:"points"`
+		Duration         float64 `yaml:"duration"`
+		Cooldown         float64 `yaml:"cooldown"`
+		DamageMultiplier float64 `yaml:"damage_multiplier"`
+	} `yaml:"metamorphosis"`
 }
 
+// Pet modes and self-buff armors accepted in player.yaml.
+const (
+	PetModeActive     = "active"
+	PetModeSacrificed = "sacrificed"
+
+	ArmorNone     = "none"
+	ArmorFelArmor = "fel_armor"
+)
+
 // Player holds player character configuration
 type Player struct {
 	Character struct {
@@ -201,7 +338,11 @@ type Player struct {
 	} `yaml:"character"`
 	Pet struct {
 		Summon string `yaml:"summon"`
+		Mode   string `yaml:"mode,omitempty"` // "" / active, or sacrificed
 	} `yaml:"pet"`
+	SelfBuffs struct {
+		Armor string `yaml:"armor,omitempty"` // "" / none, fel_armor
+	} `yaml:"self_buffs"`
 	Stats struct {
 		Intellect    float64 `yaml:"intellect"`
 		SpellPower   float64 `yaml:"spell_power"`
@@ -219,13 +360,23 @@ type Player struct {
 		} `yaml:"debuffs"`
 	} `yaml:"target"`
 	Rotation   string `yaml:"rotation"`
+	Talents    string `yaml:"talents,omitempty"` // talent profile under configs; "" = talents.yaml
 	Simulation struct {
-		DurationSeconds int `yaml:"duration_seconds"`
-		Iterations      int `yaml:"iterations"`
+		DurationSeconds int              `yaml:"duration_seconds"`
+		Iterations      int              `yaml:"iterations"`
+		Phases          []EncounterPhase `yaml:"phases,omitempty"`
 	} `yaml:"simulation"`
 	MysticEnchants MysticEnchantConfig `yaml:"mystic_enchants"`
 }
 
+// EncounterPhase marks a window of the fight (e.g. a movement phase) that
+// rotation phases can select on by name.
+type EncounterPhase struct {
+	Name         string  `yaml:"name"`
+	StartSeconds float64 `yaml:"start_seconds"`
+	EndSeconds   float64 `yaml:"end_seconds,omitempty"` // 0 runs to the end of the fight
+}
+
 // MysticEnchantConfig captures rune/ME selection and slot limits.
 type MysticEnchantConfig struct {
 	Limits struct {
@@ -271,23 +422,21 @@ func LoadConfig(configDir string) (*Config, error) {
 		return nil, err
 	}
 
-	// Load talents
-	data, err = os.ReadFile(configDir + "/talents.yaml")
+	// Load player
+	data, err = os.ReadFile(configDir + "/player.yaml")
 	if err != nil {
 		return nil, err
 	}
-	if err := yaml.Unmarshal(data, &cfg.Talents); err != nil {
+	if err := yaml.Unmarshal(data, &cfg.Player); err != nil {
 		return nil, err
 	}
 
-	// Load player
-	data, err = os.ReadFile(configDir + "/player.yaml")
+	// Load talents (the player may pick a profile)
+	talents, err := LoadTalents(configDir, cfg.Player.Talents)
 	if err != nil {
 		return nil, err
 	}
-	if err := yaml.Unmarshal(data, &cfg.Player); err != nil {
-		return nil, err
-	}
+	cfg.Talents = *talents
 
 	if err := cfg.validate(); err != nil {
 		return nil, err
@@ -295,3 +444,49 @@ func LoadConfig(configDir string) (*Config, error) {
 
 	return cfg, nil
 }
+
+// LoadTalents reads talents.yaml from configDir. A non-empty profile names a
+// second file in configDir (e.g. talents-demonology.yaml) whose entries
+// override talents.yaml, so it only needs to list what differs.
+func LoadTalents(configDir, profile string) (*Talents, error) {
+	data, err := os.ReadFile(configDir + "/talents.yaml")
+	if err != nil {
+		return nil, err
+	}
+	var talents Talents
+	if err := yaml.Unmarshal(data, &talents); err != nil {
+		return nil, err
+	}
+	if profile == "" || profile == "talents.yaml" {
+		return &talents, nil
+	}
+	data, err = os.ReadFile(configDir + "/" + profile)
+	if err != nil {
+		return nil, fmt.Errorf("talent profile: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &talents); err != nil {
+		return nil, fmt.Errorf("talent profile %s: %w", profile, err)
+	}
+	return &talents, nil
+}
+
+// LoadPlayer reads and validates a player profile in the player.yaml format.
+func LoadPlayer(path string) (*Player, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var player Player
+	if err := yaml.Unmarshal(data, &player); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := player.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &player, nil
+}
+
+// PetSacrificed reports whether the summoned demon is sacrificed before the pull.
+func (p *Player) PetSacrificed() bool {
+	return strings.EqualFold(strings.TrimSpace(p.Pet.Mode), PetModeSacrificed)
+}
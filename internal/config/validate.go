@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strings"
 
 	"wotlk-destro-sim/internal/runes"
 )
@@ -10,10 +11,28 @@ func (cfg *Config) validate() error {
 	if err := cfg.Player.validate(); err != nil {
 		return err
 	}
+	return cfg.Player.ValidateTalents(&cfg.Talents)
+}
+
+// ValidateTalents checks the player profile against the talents it runs
+// with. Profiles are validated on their own first, so this is separate.
+func (p *Player) ValidateTalents(t *Talents) error {
+	if p.PetSacrificed() && t.DemonicSacrifice.Points <= 0 {
+		return fmt.Errorf("pet: mode '%s' needs the Demonic Sacrifice talent (talents.demonic_sacrifice.points is 0)", PetModeSacrificed)
+	}
 	return nil
 }
 
 func (p *Player) validate() error {
+	if err := validatePetMode(p); err != nil {
+		return err
+	}
+	if err := validateSelfBuffs(p); err != nil {
+		return err
+	}
+	if err := validateEncounterPhases(p); err != nil {
+		return err
+	}
 	return validateMysticEnchants(&p.MysticEnchants)
 }
 
@@ -60,3 +79,46 @@ func validateMysticEnchants(me *MysticEnchantConfig) error {
 	me.active = active
 	return nil
 }
+
+func validatePetMode(p *Player) error {
+	p.Pet.Mode = strings.ToLower(strings.TrimSpace(p.Pet.Mode))
+	switch p.Pet.Mode {
+	case "", PetModeActive:
+		return nil
+	case PetModeSacrificed:
+		summon := strings.ToLower(strings.TrimSpace(p.Pet.Summon))
+		if summon == "" || summon == "none" {
+			return fmt.Errorf("pet: mode '%s' requires a summoned demon", p.Pet.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("pet: unknown mode '%s' (expected %s or %s)", p.Pet.Mode, PetModeActive, PetModeSacrificed)
+	}
+}
+
+func validateSelfBuffs(p *Player) error {
+	p.SelfBuffs.Armor = strings.ToLower(strings.TrimSpace(p.SelfBuffs.Armor))
+	switch p.SelfBuffs.Armor {
+	case "", ArmorNone, ArmorFelArmor:
+		return nil
+	default:
+		return fmt.Errorf("self_buffs: unknown armor '%s'", p.SelfBuffs.Armor)
+	}
+}
+
+func validateEncounterPhases(p *Player) error {
+	for i := range p.Simulation.Phases {
+		phase := &p.Simulation.Phases[i]
+		phase.Name = strings.ToLower(strings.TrimSpace(phase.Name))
+		if phase.Name == "" {
+			return fmt.Errorf("simulation.phases[%d]: name missing", i)
+		}
+		if phase.StartSeconds < 0 {
+			return fmt.Errorf("simulation.phases[%d] '%s': start_seconds must not be negative", i, phase.Name)
+		}
+		if phase.EndSeconds != 0 && phase.EndSeconds <= phase.StartSeconds {
+			return fmt.Errorf("simulation.phases[%d] '%s': end_seconds must be after start_seconds", i, phase.Name)
+		}
+	}
+	return nil
+}
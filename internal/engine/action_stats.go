@@ -0,0 +1,196 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"wotlk-destro-sim/internal/apl"
+)
+
+// castFailure records why the last tryCast returned false.
+type castFailure int
+
+const (
+	castFailNone castFailure = iota
+	castFailGCD
+	castFailOOM
+	castFailCooldown
+	castFailTalent
+	castFailInterrupt // interrupt_if already holds at cast start
+	castFailOther     // missing pet/form or unsupported spell
+)
+
+// ActionStats counts how one APL entry behaved, summed over iterations.
+// Evaluations counts how often the entry was reached, Passed how often its
+// condition held, Attempts how often it tried to act and Successes how often
+// that ended (or, for variable actions, updated) the decision.
+type ActionStats struct {
+	Location    string   `json:"location"` // e.g. "rotation[3]", "action_lists.aoe[0]"
+	Label       string   `json:"label"`    // e.g. "cast_spell chaos_bolt"
+	Tags        []string `json:"tags,omitempty"`
+	Evaluations int      `json:"evaluations"`
+	Passed      int      `json:"passed"`
+	Attempts    int      `json:"attempts"`
+	Successes   int      `json:"successes"`
+	FailOOM     int      `json:"fail_oom"`
+	FailCD      int      `json:"fail_cooldown"`
+	FailGCD     int      `json:"fail_gcd"`
+	FailTalent  int      `json:"fail_talent"`
+	FailOther   int      `json:"fail_other"`
+}
+
+func (a *ActionStats) add(other *ActionStats) {
+	a.Evaluations += other.Evaluations
+	a.Passed += other.Passed
+	a.Attempts += other.Attempts
+	a.Successes += other.Successes
+	a.FailOOM += other.FailOOM
+	a.FailCD += other.FailCD
+	a.FailGCD += other.FailGCD
+	a.FailTalent += other.FailTalent
+	a.FailOther += other.FailOther
+}
+
+// evaluated records one visit to the entry; nil-safe so callers need not
+// check whether the action is tracked.
+func (a *ActionStats) evaluated(passed bool) {
+	if a == nil {
+		return
+	}
+	a.Evaluations++
+	if passed {
+		a.Passed++
+	}
+}
+
+// attempted records one attempt to act and, for failed casts, the reason.
+func (a *ActionStats) attempted(ok bool, reason castFailure) {
+	if a == nil {
+		return
+	}
+	a.Attempts++
+	if ok {
+		a.Successes++
+		return
+	}
+	switch reason {
+	case castFailOOM:
+		a.FailOOM++
+	case castFailCooldown:
+		a.FailCD++
+	case castFailGCD:
+		a.FailGCD++
+	case castFailTalent:
+		a.FailTalent++
+	case castFailInterrupt, castFailOther:
+		a.FailOther++
+	}
+}
+
+// indexActions assigns a stats slot to every top-level entry of the rotation,
+// its named lists and the precombat and pet lists.
+func (s *Simulator) indexActions() {
+	s.actionIndex = map[*apl.Action]int{}
+	s.actionTemplate = nil
+	if s.Rotation == nil {
+		return
+	}
+	add := func(prefix string, actions []*apl.Action) {
+		for idx, action := range actions {
+			if action == nil {
+				continue
+			}
+			s.actionIndex[action] = len(s.actionTemplate)
+			s.actionTemplate = append(s.actionTemplate, ActionStats{
+				Location: fmt.Sprintf("%s[%d]", prefix, idx),
+				Label:    actionLabel(action),
+				Tags:     action.Tags,
+			})
+		}
+	}
+	add("rotation", s.Rotation.Actions)
+	names := make([]string, 0, len(s.Rotation.Lists))
+	for name := range s.Rotation.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add("action_lists."+name, s.Rotation.Lists[name])
+	}
+	add("action_lists."+apl.PrecombatList, s.Rotation.Precombat)
+	add("action_lists."+apl.PetList, s.Rotation.Pet)
+}
+
+func (s *Simulator) newActionStats() []*ActionStats {
+	if s.actionIndex == nil {
+		s.indexActions()
+	}
+	out := make([]*ActionStats, len(s.actionTemplate))
+	for idx := range s.actionTemplate {
+		stats := s.actionTemplate[idx]
+		out[idx] = &stats
+	}
+	return out
+}
+
+// statsFor returns the counters for action, or nil for nested steps.
+func (s *Simulator) statsFor(result *SimulationResult, action *apl.Action) *ActionStats {
+	idx, ok := s.actionIndex[action]
+	if !ok || idx >= len(result.ActionStats) {
+		return nil
+	}
+	return result.ActionStats[idx]
+}
+
+func actionLabel(action *apl.Action) string {
+	switch action.Type {
+	case apl.ActionCastSpell, apl.ActionPetCast:
+		return action.Type.String() + " " + action.Spell
+	case apl.ActionUseItem:
+		return "use_item " + action.Item
+	case apl.ActionCancelBuff:
+		return "cancel_buff " + action.Buff
+	case apl.ActionCallList, apl.ActionRunList:
+		return action.Type.String() + " " + action.List
+	case apl.ActionWait:
+		return fmt.Sprintf("wait %.2fs", action.Duration.Seconds())
+	case apl.ActionMacro, apl.ActionSequence:
+		var spellNames []string
+		for _, step := range action.Steps {
+			if step != nil && step.Type == apl.ActionCastSpell {
+				spellNames = append(spellNames, step.Spell)
+			}
+		}
+		return action.Type.String() + " " + strings.Join(spellNames, ">")
+	default:
+		if action.Type.IsVariableAction() {
+			return action.Type.String() + " " + action.Variable
+		}
+		return action.Type.String()
+	}
+}
+
+func (r *SimulationResult) printActionStats() {
+	if len(r.ActionStats) == 0 {
+		return
+	}
+	perIter := func(n int) float64 { return float64(n) / float64(r.Iterations) }
+	fmt.Println()
+	fmt.Println("APL Action Coverage (average per iteration):")
+	fmt.Println("------------------------------------------------------------------------------------------------------------------------------")
+	fmt.Printf("%-22s | %-28s | %7s | %7s | %7s | %7s | %5s | %5s | %5s | %6s | %5s\n",
+		"Action", "Label", "Evals", "True", "Tries", "Fired", "OOM", "CD", "GCD", "Talent", "Other")
+	fmt.Println("------------------------------------------------------------------------------------------------------------------------------")
+	for _, stats := range r.ActionStats {
+		label := stats.Label
+		if len(stats.Tags) > 0 {
+			label += " [" + strings.Join(stats.Tags, ",") + "]"
+		}
+		fmt.Printf("%-22s | %-28s | %7.1f | %7.1f | %7.1f | %7.1f | %5.1f | %5.1f | %5.1f | %6.1f | %5.1f\n",
+			stats.Location, label, perIter(stats.Evaluations), perIter(stats.Passed), perIter(stats.Attempts),
+			perIter(stats.Successes), perIter(stats.FailOOM), perIter(stats.FailCD), perIter(stats.FailGCD),
+			perIter(stats.FailTalent), perIter(stats.FailOther))
+	}
+	fmt.Println("------------------------------------------------------------------------------------------------------------------------------")
+}
@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/effects"
+	"wotlk-destro-sim/internal/runes"
+)
+
+// auraKind says where the state of a named aura lives.
+type auraKind uint8
+
+const (
+	auraUntracked auraKind = iota // never active
+	auraBuff                      // a character.Buff; Charges are its stacks
+	auraDebuff                    // a character.Debuff
+	auraEffect                    // an effects.Aura (rune and set-bonus stacks)
+	auraFlag                      // an on/off flag with no duration
+	auraExpiry                    // active until a timestamp
+	auraAlways                    // life_tap_buff without the glyph
+)
+
+// boundAura is one aura resolved against an iteration's character.
+type boundAura struct {
+	kind   auraKind
+	buff   *character.Buff
+	debuff *character.Debuff // also the ticks_remaining source
+	effect *effects.Aura
+	flag   *bool
+	expiry *time.Duration
+	max    int
+}
+
+// auraSource resolves an aura against the bound character.
+type auraSource func(c *rotationContext) boundAura
+
+// auraSources registers every buff, debuff and rune state the engine tracks
+// under its APL name. The buff_*, debuff_* and aura_* predicates all read
+// through it; names missing here are accepted by the APL but never active.
+var auraSources = map[string]auraSource{
+	"pyroclasm":           buffAura(func(ch *character.Character) *character.Buff { return &ch.Pyroclasm }),
+	"improved_soul_leech": buffAura(func(ch *character.Character) *character.Buff { return &ch.ImprovedSoulLeech }),
+	"soul_leech":          buffAura(func(ch *character.Character) *character.Buff { return &ch.ImprovedSoulLeech }),
+	"empowered_imp":       buffAura(func(ch *character.Character) *character.Buff { return &ch.EmpoweredImp }),
+	"cursed_shadows":      buffAura(func(ch *character.Character) *character.Buff { return &ch.CursedShadows }),
+	"shadow_trance":       buffAura(func(ch *character.Character) *character.Buff { return &ch.ShadowTrance }),
+	"metamorphosis":       buffAura(func(ch *character.Character) *character.Buff { return &ch.Metamorphosis }),
+	"decimation":          buffAura(func(ch *character.Character) *character.Buff { return &ch.Decimation }),
+	"demonic_empowerment": buffAura(func(ch *character.Character) *character.Buff { return &ch.DemonicEmpowerment }),
+	"demonic_pact":        buffAura(func(ch *character.Character) *character.Buff { return &ch.DemonicPact }),
+	"demonic_sacrifice":   buffAura(func(ch *character.Character) *character.Buff { return &ch.DemonicSacrifice }),
+	"backdraft": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraBuff, buff: &c.char.Backdraft, max: c.sim.Config.Talents.Backdraft.Charges}
+	},
+	"molten_core": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraBuff, buff: &c.char.MoltenCore, max: c.sim.Config.Talents.MoltenCore.Charges}
+	},
+	"life_tap_buff": func(c *rotationContext) boundAura {
+		if !c.sim.Config.Player.HasRune(runes.RuneGlyphOfLifeTap) {
+			return boundAura{kind: auraAlways}
+		}
+		return boundAura{kind: auraBuff, buff: &c.char.LifeTapBuff}
+	},
+
+	"immolate":              debuffAura(func(ch *character.Character) *character.Debuff { return &ch.Immolate }),
+	"corruption":            debuffAura(func(ch *character.Character) *character.Debuff { return &ch.Corruption }),
+	"curse_of_agony":        debuffAura(func(ch *character.Character) *character.Debuff { return &ch.CurseOfAgony }),
+	"curse_of_the_elements": debuffAura(func(ch *character.Character) *character.Debuff { return &ch.CurseOfElements }),
+	"curse_of_doom":         debuffAura(func(ch *character.Character) *character.Debuff { return &ch.CurseOfDoom }),
+	"immolation_aura":       debuffAura(func(ch *character.Character) *character.Debuff { return &ch.ImmolationAura }),
+
+	"heating_up":        effectAura(func(ch *character.Character) *effects.Aura { return ch.HeatingUp }),
+	"cataclysmic_burst": effectAura(func(ch *character.Character) *effects.Aura { return ch.CataclysmicBurst }),
+	"guldans_chosen":    effectAura(func(ch *character.Character) *effects.Aura { return ch.GuldansChosen }),
+	"pure_shadow":       effectAura(func(ch *character.Character) *effects.Aura { return ch.PureShadow }),
+	"dusk_till_dawn":    effectAura(func(ch *character.Character) *effects.Aura { return ch.DuskTillDawn }),
+
+	"decisive_decimation": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraFlag, flag: &c.char.DecisiveDecimation.Active}
+	},
+	"inner_flame": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraFlag, flag: &c.char.InnerFlame.Active}
+	},
+	"chaos_manifesting_fire": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraExpiry, expiry: &c.char.ChaosManifesting.FireExpiresAt}
+	},
+	"chaos_manifesting_shadow": func(c *rotationContext) boundAura {
+		return boundAura{kind: auraExpiry, expiry: &c.char.ChaosManifesting.ShadowExpiresAt}
+	},
+
+	"fire_shield": func(c *rotationContext) boundAura {
+		if imp := c.sim.activeImp(); imp != nil {
+			return boundAura{kind: auraExpiry, expiry: &imp.fireShieldExpires}
+		}
+		return boundAura{}
+	},
+	"phase_shift": func(c *rotationContext) boundAura {
+		if imp := c.sim.activeImp(); imp != nil {
+			return boundAura{kind: auraFlag, flag: &imp.phaseShifted}
+		}
+		return boundAura{}
+	},
+}
+
+func buffAura(field func(*character.Character) *character.Buff) auraSource {
+	return func(c *rotationContext) boundAura { return boundAura{kind: auraBuff, buff: field(c.char)} }
+}
+
+func debuffAura(field func(*character.Character) *character.Debuff) auraSource {
+	return func(c *rotationContext) boundAura { return boundAura{kind: auraDebuff, debuff: field(c.char)} }
+}
+
+func effectAura(field func(*character.Character) *effects.Aura) auraSource {
+	return func(c *rotationContext) boundAura {
+		aura := field(c.char)
+		if aura == nil {
+			return boundAura{}
+		}
+		return boundAura{kind: auraEffect, effect: aura, max: aura.MaxStacks}
+	}
+}
+
+// resolveAura binds name against the current character; unknown names are
+// untracked. Auras that do not stack report a maximum of 1.
+func (c *rotationContext) resolveAura(name string) boundAura {
+	source, ok := auraSources[strings.ToLower(name)]
+	if !ok {
+		return boundAura{}
+	}
+	aura := source(c)
+	if aura.max < 1 {
+		aura.max = 1
+	}
+	return aura
+}
+
+func (c *rotationContext) auraActive(a boundAura) bool {
+	now := c.char.CurrentTime
+	switch a.kind {
+	case auraBuff:
+		return a.buff.Active && a.buff.ExpiresAt > now
+	case auraDebuff:
+		return a.debuff.Active && a.debuff.ExpiresAt > now
+	case auraEffect:
+		return a.effect.ActiveAt(now)
+	case auraFlag:
+		return *a.flag
+	case auraExpiry:
+		return *a.expiry > now
+	case auraAlways:
+		return true
+	}
+	return false
+}
+
+// auraRemaining is 0 when the aura is down and an hour for active auras
+// without a duration.
+func (c *rotationContext) auraRemaining(a boundAura) time.Duration {
+	if !c.auraActive(a) {
+		return 0
+	}
+	now := c.char.CurrentTime
+	switch a.kind {
+	case auraBuff:
+		return a.buff.ExpiresAt - now
+	case auraDebuff:
+		return a.debuff.ExpiresAt - now
+	case auraEffect:
+		if a.effect.Duration > 0 {
+			return a.effect.Remaining(now)
+		}
+	case auraExpiry:
+		return *a.expiry - now
+	}
+	return time.Hour
+}
+
+// auraStacks is 0 when the aura is down and at least 1 while it is up.
+func (c *rotationContext) auraStacks(a boundAura) int {
+	if !c.auraActive(a) {
+		return 0
+	}
+	stacks := 0
+	switch a.kind {
+	case auraBuff:
+		stacks = a.buff.Charges
+	case auraEffect:
+		stacks = a.effect.Stacks()
+	}
+	if stacks < 1 {
+		return 1
+	}
+	return stacks
+}
+
+func (c *rotationContext) ticksRemaining(a boundAura) int {
+	if a.debuff == nil || !c.auraActive(a) {
+		return 0
+	}
+	return a.debuff.TicksRemaining
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"strings"
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/runes"
+	"wotlk-destro-sim/internal/spells"
+)
+
+// activePet returns the normalized name of the demon fighting alongside the warlock.
+func (s *Simulator) activePet() string {
+	if s.Config.Player.PetSacrificed() {
+		return ""
+	}
+	summon := strings.ToLower(strings.TrimSpace(s.Config.Player.Pet.Summon))
+	if summon == "none" {
+		return ""
+	}
+	return summon
+}
+
+// petDamageMultiplier collects owner-side bonuses to demon damage.
+func (s *Simulator) petDamageMultiplier(owner *character.Character) float64 {
+	mult := 1.0
+	if s.Config.Player.HasRune(runes.RuneDemonologyMastery) {
+		mult *= runes.DemonologyMasteryPetDamageMultiplier
+	}
+	if s.Config.Player.HasRune(runes.RuneDemonicInfluence) && s.metamorphosisActive(owner) {
+		mult *= runes.DemonicInfluencePetDamageMultiplier
+	}
+	return mult
+}
+
+func (s *Simulator) metamorphosisActive(char *character.Character) bool {
+	return s.Config.Talents.Metamorphosis.Points > 0 &&
+		char.Metamorphosis.Active && char.Metamorphosis.ExpiresAt > char.CurrentTime
+}
+
+func (s *Simulator) demonicEmpowermentActive(char *character.Character, at time.Duration) bool {
+	return s.Config.Talents.DemonicEmpowerment.Points > 0 &&
+		char.DemonicEmpowerment.Active && char.DemonicEmpowerment.ExpiresAt > at
+}
+
+// applyDemonicSacrifice grants the sacrificed demon's buff for the whole fight.
+func (s *Simulator) applyDemonicSacrifice(char *character.Character, spellEngine *spells.Engine) {
+	pet := spellEngine.SacrificedPet()
+	if pet == "" {
+		return
+	}
+	char.DemonicSacrifice.Active = true
+	char.DemonicSacrifice.ExpiresAt = s.SimConfig.Duration
+	if s.LogEnabled {
+		s.logStaticf("Demonic Sacrifice: %s sacrificed", pet)
+	}
+
+	ds := s.Config.Talents.DemonicSacrifice
+	percent := 0.0
+	switch pet {
+	case "felguard":
+		percent = ds.Felguard.ManaReturnPercent
+	case "felhunter":
+		percent = ds.Felhunter.ManaReturnPercent
+	}
+	interval := time.Duration(ds.ManaReturnInterval * float64(time.Second))
+	if percent <= 0 || interval <= 0 {
+		return
+	}
+	var restore func(at time.Duration)
+	restore = func(at time.Duration) {
+		gain := char.Stats.MaxMana * percent
+		char.GainMana(gain)
+		if s.LogEnabled {
+			s.logAt(at, "RESOURCE Mana +%.0f => %.0f (Demonic Sacrifice)", gain, char.Resources.CurrentMana)
+		}
+		next := at + interval
+		s.scheduleEvent(next, func() { restore(next) })
+	}
+	first := char.CurrentTime + interval
+	s.scheduleEvent(first, func() { restore(first) })
+}
+
+// grantDemonicPact refreshes the Demonic Pact spell power buff after a pet crit.
+func (s *Simulator) grantDemonicPact(owner *character.Character, at time.Duration) {
+	pact := s.Config.Talents.DemonicPact
+	if pact.Points <= 0 || owner == nil {
+		return
+	}
+	wasActive := owner.DemonicPact.Active && owner.DemonicPact.ExpiresAt > at
+	owner.DemonicPact.Active = true
+	owner.DemonicPact.Value = owner.Stats.SpellPower * pact.SpellPowerFraction
+	owner.DemonicPact.ExpiresAt = at + time.Duration(pact.Duration*float64(time.Second))
+	if s.LogEnabled && !wasActive {
+		s.logAt(at, "BUFF_GAIN Demonic Pact (+%.0f SP, %.1fs)", owner.DemonicPact.Value, pact.Duration)
+	}
+}
+
+// tryMoltenCoreProc rolls Molten Core for a DoT tick from the given source spell.
+func (s *Simulator) tryMoltenCoreProc(char *character.Character, source string, tickTime time.Duration, spellEngine *spells.Engine) {
+	mc := s.Config.Talents.MoltenCore
+	if mc.Points <= 0 || mc.Charges <= 0 {
+		return
+	}
+	eligible := false
+	for _, name := range mc.ProcSpells {
+		if strings.EqualFold(strings.TrimSpace(name), source) {
+			eligible = true
+			break
+		}
+	}
+	if !eligible {
+		return
+	}
+	if spellEngine.Rng.Float64() >= float64(mc.Points)*mc.ProcChancePerPoint {
+		return
+	}
+	char.MoltenCore.Active = true
+	char.MoltenCore.Charges = mc.Charges
+	char.MoltenCore.ExpiresAt = tickTime + time.Duration(mc.Duration*float64(time.Second))
+	if s.LogEnabled {
+		s.logAt(tickTime, "BUFF_GAIN Molten Core (charges %d)", mc.Charges)
+	}
+}
+
+func (s *Simulator) cancelImmolationAuraTicks(char *character.Character) {
+	if char.ImmolationAura.TickHandle != nil {
+		char.ImmolationAura.TickHandle.Cancel()
+		char.ImmolationAura.TickHandle = nil
+	}
+}
+
+func (s *Simulator) scheduleNextImmolationAuraTick(char *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
+	if char.ImmolationAura.TickInterval <= 0 || char.ImmolationAura.TicksRemaining <= 0 {
+		return
+	}
+	nextTick := char.ImmolationAura.LastTick + char.ImmolationAura.TickInterval
+	handle := s.scheduleEvent(nextTick, func() {
+		s.executeImmolationAuraTick(char, nextTick, result, spellEngine)
+	})
+	char.ImmolationAura.TickHandle = handle
+}
+
+func (s *Simulator) executeImmolationAuraTick(char *character.Character, tickTime time.Duration, result *SimulationResult, spellEngine *spells.Engine) {
+	char.ImmolationAura.TickHandle = nil
+	if !char.ImmolationAura.Active {
+		return
+	}
+
+	damage := char.ImmolationAura.TickDamage
+	didCrit := false
+	chance := char.ImmolationAura.TickCritChance
+	if chance >= 1 {
+		didCrit = true
+	} else if chance > 0 && spellEngine.Rng.Float64() < chance {
+		didCrit = true
+	}
+	if didCrit {
+		damage *= s.Config.Talents.Ruin.CritMultiplier
+	}
+
+	result.recordDotTick(spells.SpellImmolationAura, damage, didCrit)
+	if s.LogEnabled {
+		critTag := ""
+		if didCrit {
+			critTag = " (CRIT)"
+		}
+		s.logAt(tickTime, "DOT_TICK Immolation Aura damage=%.0f%s", damage, critTag)
+	}
+
+	char.ImmolationAura.LastTick = tickTime
+	char.ImmolationAura.TicksRemaining--
+	s.scheduleNextImmolationAuraTick(char, result, spellEngine)
+}
+
+func (s *Simulator) clearImmolationAura(char *character.Character) {
+	s.cancelImmolationAuraTicks(char)
+	char.ImmolationAura.Active = false
+	char.ImmolationAura.TicksRemaining = 0
+}
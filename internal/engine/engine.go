@@ -36,7 +36,27 @@ var spellPrintOrder = []struct {
 	{spells.SpellIncinerate, "Incinerate"},
 	{spells.SpellChaosBolt, "Chaos Bolt"},
 	{spells.SpellConflagrate, "Conflagrate"},
+	{spells.SpellImmolationAura, "Immolation Aura"},
 	{spells.SpellImpFirebolt, "Firebolt (Imp)"},
+	{spells.SpellPetMelee, "Melee (Pet)"},
+	{spells.SpellFelguardCleave, "Cleave (Felguard)"},
+	{spells.SpellFelhunterShadowBite, "Shadow Bite (Felhunter)"},
+	{spells.SpellSuccubusLashOfPain, "Lash of Pain (Succubus)"},
+	{spells.SpellCurseOfDoom, "Curse of Doom"},
+	{spells.SpellInferno, "Inferno"},
+	{spells.SpellInfernalMelee, "Melee (Infernal)"},
+	{spells.SpellInfernalImmolation, "Immolation (Infernal)"},
+	{spells.SpellDoomguardDoomBolt, "Doom Bolt (Doomguard)"},
+}
+
+// SpellLabel returns the display name used in the spell breakdown.
+func SpellLabel(spell spells.SpellType) string {
+	for _, entry := range spellPrintOrder {
+		if entry.Type == spell {
+			return entry.Label
+		}
+	}
+	return fmt.Sprintf("spell %d", int(spell))
 }
 
 // SpellStats keeps per-spell performance details
@@ -48,6 +68,10 @@ type SpellStats struct {
 	Damage    float64
 	MinDamage float64
 	MaxDamage float64
+
+	// Interrupted casts never resolved and are not counted in Casts.
+	Interrupted        int
+	InterruptedSeconds float64
 }
 
 func newSpellStats() *SpellStats {
@@ -136,6 +160,13 @@ func (s *Simulator) cancelCurseOfAgonyTicks(char *character.Character) {
 	}
 }
 
+func (s *Simulator) cancelCurseOfDoomTick(char *character.Character) {
+	if char.CurseOfDoom.TickHandle != nil {
+		char.CurseOfDoom.TickHandle.Cancel()
+		char.CurseOfDoom.TickHandle = nil
+	}
+}
+
 func (s *Simulator) scheduleNextImmolateTick(char *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
 	if char.Immolate.TickInterval <= 0 {
 		return
@@ -169,6 +200,14 @@ func (s *Simulator) scheduleNextCurseOfAgonyTick(char *character.Character, resu
 	char.CurseOfAgony.TickHandle = handle
 }
 
+func (s *Simulator) scheduleCurseOfDoomTick(char *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
+	hitAt := char.CurseOfDoom.ExpiresAt
+	handle := s.scheduleEvent(hitAt, func() {
+		s.executeCurseOfDoomTick(char, hitAt, result, spellEngine)
+	})
+	char.CurseOfDoom.TickHandle = handle
+}
+
 func (s *Simulator) executeImmolateTick(char *character.Character, tickTime time.Duration, result *SimulationResult, spellEngine *spells.Engine) {
 	char.Immolate.TickHandle = nil
 	if !char.Immolate.Active {
@@ -197,6 +236,7 @@ func (s *Simulator) executeImmolateTick(char *character.Character, tickTime time
 	}
 
 	result.recordDotTick(spells.SpellImmolate, damage, didCrit)
+	s.tryMoltenCoreProc(char, "immolate", tickTime, spellEngine)
 	if s.LogEnabled {
 		critTag := ""
 		if didCrit {
@@ -240,6 +280,7 @@ func (s *Simulator) executeCorruptionTick(char *character.Character, tickTime ti
 	}
 
 	result.recordDotTick(spells.SpellCorruption, damage, didCrit)
+	s.tryMoltenCoreProc(char, "corruption", tickTime, spellEngine)
 	if s.LogEnabled {
 		critTag := ""
 		if didCrit {
@@ -308,12 +349,35 @@ func (s *Simulator) executeCurseOfAgonyTick(char *character.Character, tickTime
 	s.scheduleNextCurseOfAgonyTick(char, result, spellEngine)
 }
 
+func (s *Simulator) executeCurseOfDoomTick(char *character.Character, tickTime time.Duration, result *SimulationResult, spellEngine *spells.Engine) {
+	char.CurseOfDoom.TickHandle = nil
+	if char.CurseOfDoom.TicksRemaining <= 0 {
+		return
+	}
+	damage := char.CurseOfDoom.TickDamage
+	char.CurseOfDoom.TicksRemaining = 0
+	char.CurseOfDoom.Active = false
+	char.CurseOfDoom.LastTick = tickTime
+
+	result.recordDotTick(spells.SpellCurseOfDoom, damage, false)
+	if s.LogEnabled {
+		s.logAt(tickTime, "DOT_TICK Curse of Doom damage=%.0f", damage)
+	}
+
+	chance := s.Config.Spells.CurseOfDoom.DoomguardSummonChance
+	if chance > 0 && spellEngine.Rng.Float64() < chance {
+		s.summonDoomguard(char, result, spellEngine, tickTime)
+	}
+}
+
 func (s *SpellStats) add(other *SpellStats) {
 	s.Casts += other.Casts
 	s.Hits += other.Hits
 	s.Crits += other.Crits
 	s.Misses += other.Misses
 	s.Damage += other.Damage
+	s.Interrupted += other.Interrupted
+	s.InterruptedSeconds += other.InterruptedSeconds
 	if other.Hits > 0 {
 		if s.MinDamage == math.MaxFloat64 || other.MinDamage < s.MinDamage {
 			s.MinDamage = other.MinDamage
@@ -348,11 +412,26 @@ type SimulationResult struct {
 	// Mana
 	OOMEvents int // Out of mana events
 
+	// InterruptedCasts counts casts stopped by interrupt_if (see interrupts.go).
+	InterruptedCasts int
+
 	// Buff uptimes (seconds across all iterations)
 	PyroclasmActiveSeconds         float64
 	ImprovedSoulLeechActiveSeconds float64
 	BackdraftActiveSeconds         float64
 	BackdraftChargeSeconds         float64
+	MetamorphosisActiveSeconds     float64
+	DemonicPactActiveSeconds       float64
+
+	// ActionStats holds per-entry APL coverage counters (see action_stats.go).
+	ActionStats []*ActionStats
+	// Phases splits the fight by rotation phase (see phases.go); nil when
+	// the rotation declares no phases.
+	Phases []*PhaseStats
+
+	// IterationDPS holds each iteration's DPS in order. Iteration i always
+	// uses seed BaseSeed+i, so runs sharing a base seed pair up by index.
+	IterationDPS []float64
 }
 
 func (r *SimulationResult) recordSpellCast(spell spells.SpellType, castResult spells.CastResult) {
@@ -406,8 +485,47 @@ type Simulator struct {
 	LogEnabled bool
 	LogWriter  io.Writer
 	BaseSeed   int64
-	events     eventQueue
-	pets       []petController
+	// TraceAPL adds APL decision lines (chosen entry and skipped higher
+	// priority entries with the reason) to the combat log.
+	TraceAPL bool
+	events   eventQueue
+	pets     []petController
+
+	// precombat is set while the precombat list runs; casts then resolve at
+	// the pull and precombatGCD keeps the GCD left over from the last one.
+	precombat    bool
+	precombatGCD time.Duration
+
+	// landing is set while an interruptible cast resolves at its end (see
+	// interrupts.go); resolveCast then skips the GCD check.
+	landing bool
+
+	// variables holds this iteration's runtime APL variable values and
+	// sequences the next step index of each sequence action.
+	variables map[string]float64
+	sequences map[*apl.Action]int
+
+	// actionIndex maps each top-level APL entry to its ActionStats slot and
+	// castFailure holds the reason the last tryCast returned false.
+	actionIndex    map[*apl.Action]int
+	actionTemplate []ActionStats
+	castFailure    castFailure
+
+	// traceNotes collects why higher-priority entries were passed over in the
+	// current decision; traceSkipped counts them all.
+	traceNotes   []string
+	traceSkipped int
+
+	// rotCtx is rebound to each iteration's character instead of being
+	// allocated per decision.
+	rotCtx rotationContext
+
+	// phase is the PhaseStats slot of the running rotation phase (-1 before
+	// the first decision); the other fields are the totals when it began.
+	phase       int
+	phaseStart  time.Duration
+	phaseDamage float64
+	phaseCasts  int
 }
 
 // NewSimulator creates a new simulator
@@ -421,6 +539,7 @@ func NewSimulator(cfg *config.Config, simCfg SimulationConfig, rotation *apl.Com
 		BaseSeed:   seed,
 	}
 	sim.initializePets()
+	sim.indexActions()
 	return sim
 }
 
@@ -430,6 +549,8 @@ func (s *Simulator) Run(char *character.Character) *SimulationResult {
 		Duration:       s.SimConfig.Duration,
 		Iterations:     s.SimConfig.Iterations,
 		SpellBreakdown: newSpellStatsMap(),
+		ActionStats:    s.newActionStats(),
+		Phases:         s.newPhaseStats(),
 	}
 	result.TargetDebuffs.CurseOfElements = s.Config.Player.Target.Debuffs.CurseOfElements
 	if s.LogEnabled {
@@ -459,6 +580,8 @@ func (s *Simulator) runSingleIteration(originalChar *character.Character, iterat
 		char.CurseOfElements.ExpiresAt = s.SimConfig.Duration
 	}
 	s.resetPets(char)
+	s.resetRotationState()
+	s.resetPhase()
 	s.events = s.events[:0]
 	if s.LogEnabled {
 		s.logStaticf("--- Iteration %d Start ---", iteration+1)
@@ -466,11 +589,17 @@ func (s *Simulator) runSingleIteration(originalChar *character.Character, iterat
 
 	// Create spell engine with unique seed for this iteration
 	spellEngine := spells.NewEngine(s.Config, s.BaseSeed+int64(iteration), s.SimConfig.IsBoss)
+	spellEngine.FightDuration = s.SimConfig.Duration
+	s.rotCtx.bind(s, char, spellEngine)
 
 	result := &SimulationResult{
 		SpellBreakdown: newSpellStatsMap(),
+		ActionStats:    s.newActionStats(),
+		Phases:         s.newPhaseStats(),
 	}
 	s.startPets(char, result, spellEngine)
+	s.applyDemonicSacrifice(char, spellEngine)
+	s.executePrecombat(char, result, spellEngine)
 	hasImmolate := false
 
 	// Combat loop
@@ -540,57 +669,74 @@ func (s *Simulator) runSingleIteration(originalChar *character.Character, iterat
 		// If we somehow can't do anything, advance time by GCD
 		s.wait(char, time.Duration(s.Config.Constants.GCD.Base*float64(time.Second)), result, spellEngine)
 	}
+	s.closePhase(char, result)
 
 	return result
 }
 
 // tryCast attempts to cast a spell
 func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, result *SimulationResult, spellEngine *spells.Engine) bool {
-	// Check if GCD is ready
-	if !char.IsGCDReady() {
+	castResult, pendingLog, ok := s.resolveCast(char, spell, result, spellEngine)
+	if !ok {
 		return false
 	}
 
+	if s.precombat {
+		// Pre-pull casts start early enough to land at time zero.
+		s.precombatGCD = castResult.GCDTime - castResult.CastTime
+		if s.precombatGCD < 0 {
+			s.precombatGCD = 0
+		}
+		if pendingLog != nil {
+			s.emitCastResult(pendingLog, char.CurrentTime)
+		}
+		return true
+	}
+
+	s.wait(char, s.startCastTimers(char, castResult), result, spellEngine)
+
+	if pendingLog != nil {
+		s.emitCastResult(pendingLog, char.CurrentTime)
+	}
+
+	return true
+}
+
+// startCastTimers starts the GCD for a resolved cast and returns how long the
+// caster is busy: the cast time or the GCD, whichever is longer.
+func (s *Simulator) startCastTimers(char *character.Character, castResult spells.CastResult) time.Duration {
+	totalTime := castResult.CastTime
+	if castResult.GCDTime > totalTime {
+		totalTime = castResult.GCDTime
+	}
+	if castResult.GCDTime > 0 {
+		char.GCD.Reset(char.CurrentTime, castResult.GCDTime)
+	}
+	return totalTime
+}
+
+// resolveCast checks that spell can be cast and applies it at the current
+// time: mana, damage, procs and statistics. The caller spends the cast time.
+// A cast with a cast time returns its result log entry to emit when it ends.
+func (s *Simulator) resolveCast(char *character.Character, spell spells.SpellType, result *SimulationResult, spellEngine *spells.Engine) (spells.CastResult, *castResultLog, bool) {
+	s.castFailure = castFailNone
+	// Check if GCD is ready
+	if !s.landing && !char.IsGCDReady() {
+		s.castFailure = castFailGCD
+		return spells.CastResult{}, nil, false
+	}
+
 	spellName := spellTypeName(spell)
 	startTime := char.CurrentTime
 
 	// Check mana cost
-	var manaCost float64
-	switch spell {
-	case spells.SpellImmolate:
-		manaCost = s.Config.Spells.Immolate.ManaCost
-	case spells.SpellIncinerate:
-		manaCost = s.Config.Spells.Incinerate.ManaCost
-	case spells.SpellChaosBolt:
-		manaCost = s.Config.Spells.ChaosBolt.ManaCost
-	case spells.SpellConflagrate:
-		manaCost = s.Config.Spells.Conflagrate.ManaCost
-	case spells.SpellLifeTap:
-		manaCost = 0
-	case spells.SpellCurseOfElements:
-		manaCost = 0
-	case spells.SpellSoulFire:
-		manaCost = s.Config.Spells.SoulFire.ManaCost
-	case spells.SpellShadowBolt:
-		manaCost = s.Config.Spells.ShadowBolt.ManaCost
-		if char.ShadowTrance.Active && char.ShadowTranceFreeCast && char.ShadowTrance.ExpiresAt > char.CurrentTime {
-			manaCost = 0
-		}
-	case spells.SpellShadowburn:
-		manaCost = s.Config.Spells.Shadowburn.ManaCost
-	case spells.SpellCorruption:
-		manaCost = s.Config.Spells.Corruption.ManaCost
-	case spells.SpellCurseOfAgony:
-		manaCost = s.Config.Spells.CurseOfAgony.ManaCost
-	case spells.SpellShadowfury:
-		manaCost = s.Config.Spells.ShadowFury.ManaCost
-	}
-
+	manaCost := s.spellManaCost(char, spell)
 	if manaCost > 0 && !char.HasMana(manaCost) {
 		if s.LogEnabled {
 			s.logf(char, "CAST_FAIL %s (OOM)", spellName)
 		}
-		return false
+		s.castFailure = castFailOOM
+		return spells.CastResult{}, nil, false
 	}
 
 	prevBuffs := captureBuffState(char)
@@ -624,7 +770,8 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 		castResult = spellEngine.CastShadowBolt(char)
 	case spells.SpellShadowburn:
 		if !char.IsCooldownReady(&char.Shadowburn) {
-			return false
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
 		}
 		castResult = spellEngine.CastShadowburn(char)
 	case spells.SpellCorruption:
@@ -638,6 +785,7 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 	case spells.SpellCurseOfAgony:
 		castResult = spellEngine.CastCurseOfAgony(char)
 		if castResult.DidHit {
+			s.cancelCurseOfDoomTick(char)
 			s.cancelCurseOfAgonyTicks(char)
 			s.scheduleNextCurseOfAgonyTick(char, result, spellEngine)
 		} else {
@@ -645,9 +793,73 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 		}
 	case spells.SpellShadowfury:
 		if !char.IsCooldownReady(&char.Shadowfury) {
-			return false
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
 		}
 		castResult = spellEngine.CastShadowfury(char)
+	case spells.SpellMetamorphosis:
+		if s.Config.Talents.Metamorphosis.Points <= 0 {
+			s.castFailure = castFailTalent
+			return spells.CastResult{}, nil, false
+		}
+		if !char.IsCooldownReady(&char.MetamorphosisCooldown) {
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
+		}
+		castResult = spellEngine.CastMetamorphosis(char)
+	case spells.SpellDemonicEmpowerment:
+		if s.Config.Talents.DemonicEmpowerment.Points <= 0 {
+			s.castFailure = castFailTalent
+			return spells.CastResult{}, nil, false
+		}
+		if s.activePet() == "" {
+			s.castFailure = castFailOther
+			return spells.CastResult{}, nil, false
+		}
+		if !char.IsCooldownReady(&char.DemonicEmpowermentCooldown) {
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
+		}
+		castResult = spellEngine.CastDemonicEmpowerment(char)
+	case spells.SpellImmolationAura:
+		if !s.metamorphosisActive(char) {
+			s.castFailure = castFailOther
+			return spells.CastResult{}, nil, false
+		}
+		if !char.IsCooldownReady(&char.ImmolationAuraCooldown) {
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
+		}
+		castResult = spellEngine.CastImmolationAura(char)
+		s.cancelImmolationAuraTicks(char)
+		s.scheduleNextImmolationAuraTick(char, result, spellEngine)
+	case spells.SpellCurseOfDoom:
+		if !char.IsCooldownReady(&char.CurseOfDoomCooldown) {
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
+		}
+		castResult = spellEngine.CastCurseOfDoom(char)
+		if castResult.DidHit {
+			s.cancelCurseOfAgonyTicks(char)
+			s.cancelCurseOfDoomTick(char)
+			s.scheduleCurseOfDoomTick(char, result, spellEngine)
+		}
+	case spells.SpellInferno:
+		if !char.IsCooldownReady(&char.InfernoCooldown) {
+			s.castFailure = castFailCooldown
+			return spells.CastResult{}, nil, false
+		}
+		castResult = spellEngine.CastInferno(char)
+		if castResult.DidHit {
+			landsAt := char.CurrentTime + castResult.CastTime
+			if s.landing {
+				landsAt = char.CurrentTime
+			}
+			s.summonInfernal(char, result, spellEngine, landsAt)
+		}
+	default:
+		s.castFailure = castFailOther
+		return spells.CastResult{}, nil, false
 	}
 
 	// If Corruption was (re)applied by effects (e.g., Dusk till Dawn), ensure ticks are scheduled.
@@ -661,7 +873,7 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 		s.scheduleNextCurseOfAgonyTick(char, result, spellEngine)
 	}
 
-	if s.LogEnabled && castResult.CastTime > 0 {
+	if s.LogEnabled && castResult.CastTime > 0 && !s.landing {
 		s.logAt(startTime, "CAST_START %s (mana=%.0f)", spellName, startMana)
 	}
 
@@ -672,7 +884,7 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 			didHit:  castResult.DidHit,
 			didCrit: castResult.DidCrit,
 			damage:  castResult.Damage,
-			instant: castResult.CastTime == 0,
+			instant: castResult.CastTime == 0 || s.landing,
 			start:   startTime,
 		}
 		if pendingLog.instant {
@@ -692,6 +904,7 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 	}
 
 	result.recordSpellCast(spell, castResult)
+	char.RecordCast(spellKey(spell))
 	if castResult.Healing > 0 {
 		result.TotalHealing += castResult.Healing
 	}
@@ -705,21 +918,45 @@ func (s *Simulator) tryCast(char *character.Character, spell spells.SpellType, r
 		result.CritCount++
 	}
 
-	// Advance time by cast time, respecting GCD
-	totalTime := castResult.CastTime
-	if castResult.GCDTime > totalTime {
-		totalTime = castResult.GCDTime
-	}
-	if castResult.GCDTime > 0 {
-		char.GCD.Reset(char.CurrentTime, castResult.GCDTime)
-	}
-	s.wait(char, totalTime, result, spellEngine)
-
-	if pendingLog != nil {
-		s.emitCastResult(pendingLog, char.CurrentTime)
-	}
+	return castResult, pendingLog, true
+}
 
-	return true
+// spellManaCost is what casting spell right now costs; 0 for free spells.
+func (s *Simulator) spellManaCost(char *character.Character, spell spells.SpellType) float64 {
+	switch spell {
+	case spells.SpellImmolate:
+		return s.Config.Spells.Immolate.ManaCost
+	case spells.SpellIncinerate:
+		return s.Config.Spells.Incinerate.ManaCost
+	case spells.SpellChaosBolt:
+		return s.Config.Spells.ChaosBolt.ManaCost
+	case spells.SpellConflagrate:
+		return s.Config.Spells.Conflagrate.ManaCost
+	case spells.SpellSoulFire:
+		return s.Config.Spells.SoulFire.ManaCost
+	case spells.SpellShadowBolt:
+		if char.ShadowTrance.Active && char.ShadowTranceFreeCast && char.ShadowTrance.ExpiresAt > char.CurrentTime {
+			return 0
+		}
+		return s.Config.Spells.ShadowBolt.ManaCost
+	case spells.SpellShadowburn:
+		return s.Config.Spells.Shadowburn.ManaCost
+	case spells.SpellCorruption:
+		return s.Config.Spells.Corruption.ManaCost
+	case spells.SpellCurseOfAgony:
+		return s.Config.Spells.CurseOfAgony.ManaCost
+	case spells.SpellShadowfury:
+		return s.Config.Spells.ShadowFury.ManaCost
+	case spells.SpellDemonicEmpowerment:
+		return s.Config.Talents.DemonicEmpowerment.ManaCost
+	case spells.SpellImmolationAura:
+		return s.Config.Spells.ImmolationAura.ManaCost
+	case spells.SpellCurseOfDoom:
+		return s.Config.Spells.CurseOfDoom.ManaCost
+	case spells.SpellInferno:
+		return s.Config.Spells.Inferno.ManaCost
+	}
+	return 0
 }
 
 type castResultLog struct {
@@ -749,6 +986,9 @@ func (s *Simulator) advanceTime(char *character.Character, duration time.Duratio
 		}
 	}
 
+	result.MetamorphosisActiveSeconds += s.buffOverlapSeconds(&char.Metamorphosis, start, end)
+	result.DemonicPactActiveSeconds += s.buffOverlapSeconds(&char.DemonicPact, start, end)
+
 	char.AdvanceTime(duration)
 	s.processSoulLeechHoT(char, start, end)
 	s.expireBuffs(char)
@@ -994,6 +1234,48 @@ func (s *Simulator) expireBuffs(char *character.Character) {
 			s.logAt(ts, "BUFF_EXPIRE Gul'dan's Chosen")
 		}
 	}
+	if char.Metamorphosis.Active && now >= char.Metamorphosis.ExpiresAt {
+		char.Metamorphosis.Active = false
+		if char.ImmolationAura.Active {
+			s.clearImmolationAura(char)
+		}
+		if s.LogEnabled {
+			s.logAt(char.Metamorphosis.ExpiresAt, "BUFF_EXPIRE Metamorphosis")
+		}
+	}
+	if char.ImmolationAura.Active && (now >= char.ImmolationAura.ExpiresAt || char.ImmolationAura.TicksRemaining <= 0) {
+		s.clearImmolationAura(char)
+	}
+	if char.MoltenCore.Active && (now >= char.MoltenCore.ExpiresAt || char.MoltenCore.Charges <= 0) {
+		ts := char.MoltenCore.ExpiresAt
+		if ts > now {
+			ts = now
+		}
+		char.MoltenCore.Active = false
+		char.MoltenCore.Charges = 0
+		if s.LogEnabled {
+			s.logAt(ts, "BUFF_EXPIRE Molten Core")
+		}
+	}
+	if char.Decimation.Active && now >= char.Decimation.ExpiresAt {
+		char.Decimation.Active = false
+		if s.LogEnabled {
+			s.logAt(char.Decimation.ExpiresAt, "BUFF_EXPIRE Decimation")
+		}
+	}
+	if char.DemonicEmpowerment.Active && now >= char.DemonicEmpowerment.ExpiresAt {
+		char.DemonicEmpowerment.Active = false
+		if s.LogEnabled {
+			s.logAt(char.DemonicEmpowerment.ExpiresAt, "BUFF_EXPIRE Demonic Empowerment")
+		}
+	}
+	if char.DemonicPact.Active && now >= char.DemonicPact.ExpiresAt {
+		char.DemonicPact.Active = false
+		char.DemonicPact.Value = 0
+		if s.LogEnabled {
+			s.logAt(char.DemonicPact.ExpiresAt, "BUFF_EXPIRE Demonic Pact")
+		}
+	}
 	if char.CursedShadows.Active && now >= char.CursedShadows.ExpiresAt {
 		char.CursedShadows.Active = false
 		char.CursedShadows.ExpiresAt = 0
@@ -1006,6 +1288,7 @@ func (s *Simulator) expireBuffs(char *character.Character) {
 
 // aggregateResult combines results from multiple iterations
 func (r *SimulationResult) aggregateResult(iter *SimulationResult) {
+	r.IterationDPS = append(r.IterationDPS, iter.TotalDamage/r.Duration.Seconds())
 	r.TotalDamage += iter.TotalDamage
 	r.TotalHealing += iter.TotalHealing
 	r.LifeTapCount += iter.LifeTapCount
@@ -1014,10 +1297,13 @@ func (r *SimulationResult) aggregateResult(iter *SimulationResult) {
 	r.CritCount += iter.CritCount
 	r.TotalCasts += iter.TotalCasts
 	r.OOMEvents += iter.OOMEvents
+	r.InterruptedCasts += iter.InterruptedCasts
 	r.PyroclasmActiveSeconds += iter.PyroclasmActiveSeconds
 	r.ImprovedSoulLeechActiveSeconds += iter.ImprovedSoulLeechActiveSeconds
 	r.BackdraftActiveSeconds += iter.BackdraftActiveSeconds
 	r.BackdraftChargeSeconds += iter.BackdraftChargeSeconds
+	r.MetamorphosisActiveSeconds += iter.MetamorphosisActiveSeconds
+	r.DemonicPactActiveSeconds += iter.DemonicPactActiveSeconds
 
 	for spell, stats := range iter.SpellBreakdown {
 		if base, ok := r.SpellBreakdown[spell]; ok {
@@ -1026,6 +1312,16 @@ func (r *SimulationResult) aggregateResult(iter *SimulationResult) {
 			r.SpellBreakdown[spell] = stats
 		}
 	}
+	for idx, stats := range iter.ActionStats {
+		if idx < len(r.ActionStats) {
+			r.ActionStats[idx].add(stats)
+		}
+	}
+	for idx, stats := range iter.Phases {
+		if idx < len(r.Phases) {
+			r.Phases[idx].add(stats)
+		}
+	}
 }
 
 // PrintResults outputs simulation results
@@ -1116,6 +1412,7 @@ func (r *SimulationResult) PrintResults() {
 	if r.LifeTapCount > 0 {
 		fmt.Printf("Life Tap casts (avg): %.1f\n", float64(r.LifeTapCount)/float64(r.Iterations))
 	}
+	r.printInterrupts()
 
 	fmt.Println()
 	fmt.Println("Buff Uptimes:")
@@ -1146,6 +1443,14 @@ func (r *SimulationResult) PrintResults() {
 	} else {
 		fmt.Println("Backdraft:           0.0s (0.0%)")
 	}
+	if r.MetamorphosisActiveSeconds > 0 {
+		avg := r.MetamorphosisActiveSeconds / float64(r.Iterations)
+		fmt.Printf("Metamorphosis:       %.1fs (%.1f%%)\n", avg, uptimePercent(avg, fightSeconds))
+	}
+	if r.DemonicPactActiveSeconds > 0 {
+		avg := r.DemonicPactActiveSeconds / float64(r.Iterations)
+		fmt.Printf("Demonic Pact:        %.1fs (%.1f%%)\n", avg, uptimePercent(avg, fightSeconds))
+	}
 
 	fmt.Println()
 	fmt.Println("Statistics:")
@@ -1166,9 +1471,18 @@ func (r *SimulationResult) PrintResults() {
 	if r.ShadowTranceProcs > 0 {
 		fmt.Printf("Shadow Trance Procs: %.1f\n", float64(r.ShadowTranceProcs)/float64(r.Iterations))
 	}
+	r.printPhaseStats()
+	r.printActionStats()
 	fmt.Println("========================================")
 }
 
+func uptimePercent(seconds, fightSeconds float64) float64 {
+	if fightSeconds <= 0 {
+		return 0
+	}
+	return seconds / fightSeconds * 100.0
+}
+
 func (s *Simulator) logf(char *character.Character, format string, args ...interface{}) {
 	if !s.LogEnabled || s.LogWriter == nil {
 		return
@@ -1260,6 +1574,21 @@ func (s *Simulator) logBuffChanges(prev buffState, char *character.Character) {
 	} else if prev.empImpActive && !char.EmpoweredImp.Active {
 		s.logf(char, "BUFF_EXPIRE Empowered Imp")
 	}
+	if !prev.metamorphosisActive && char.Metamorphosis.Active {
+		remain := char.Metamorphosis.ExpiresAt - char.CurrentTime
+		s.logf(char, "BUFF_GAIN Metamorphosis (%.1fs window)", remain.Seconds())
+	}
+	if prev.moltenCoreCharges != char.MoltenCore.Charges && char.MoltenCore.Active && prev.moltenCoreCharges > char.MoltenCore.Charges {
+		s.logf(char, "BUFF_UPDATE Molten Core charges -> %d", char.MoltenCore.Charges)
+	}
+	if !prev.decimationActive && char.Decimation.Active {
+		remain := char.Decimation.ExpiresAt - char.CurrentTime
+		s.logf(char, "BUFF_GAIN Decimation (%.1fs window)", remain.Seconds())
+	}
+	if !prev.demonicEmpowermentActive && char.DemonicEmpowerment.Active {
+		remain := char.DemonicEmpowerment.ExpiresAt - char.CurrentTime
+		s.logf(char, "BUFF_GAIN Demonic Empowerment (%.1fs window)", remain.Seconds())
+	}
 	if !prev.shadowTranceActive && char.ShadowTrance.Active {
 		remain := char.ShadowTrance.ExpiresAt - char.CurrentTime
 		tag := ""
@@ -0,0 +1,174 @@
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/spells"
+)
+
+const testRotationDir = "../../configs/rotations"
+
+// testSimulator builds a simulator from the shipped configs and an inline
+// rotation.
+func testSimulator(tb testing.TB, rotation string, duration time.Duration) (*Simulator, *character.Character) {
+	tb.Helper()
+	dir := tb.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "rotation.yaml"), []byte(rotation), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return rotationSimulator(tb, dir, "rotation.yaml", duration)
+}
+
+// rotationSimulator builds a simulator from the shipped configs and the
+// rotation file dir/name.
+func rotationSimulator(tb testing.TB, dir, name string, duration time.Duration) (*Simulator, *character.Character) {
+	tb.Helper()
+	cfg, err := config.LoadConfig("../../configs")
+	if err != nil {
+		tb.Fatalf("load config: %v", err)
+	}
+	file, err := apl.LoadRotation(dir, name)
+	if err != nil {
+		tb.Fatalf("load rotation: %v", err)
+	}
+	compiled, err := apl.Compile(file)
+	if err != nil {
+		tb.Fatalf("compile rotation: %v", err)
+	}
+	simCfg := SimulationConfig{Duration: duration, Iterations: 1, IsBoss: cfg.Player.Target.Type == "boss"}
+	char := character.NewCharacter(character.Stats{
+		Intellect:  cfg.Player.Stats.Intellect,
+		SpellPower: cfg.Player.Stats.SpellPower,
+		CritPct:    cfg.Player.Stats.CritPercent,
+		HastePct:   cfg.Player.Stats.HastePercent,
+		Spirit:     cfg.Player.Stats.Spirit,
+		HitPct:     cfg.Player.Stats.HitPercent,
+		MaxMana:    cfg.Player.Stats.MaxMana,
+	})
+	return NewSimulator(cfg, simCfg, compiled, 7, false, nil), char
+}
+
+// compiledCondition pairs a condition tree with its flattened program.
+type compiledCondition struct {
+	tree    apl.Condition
+	program *apl.Program
+}
+
+// rotationConditions gathers every condition the engine evaluates as a
+// program.
+func rotationConditions(rot *apl.CompiledRotation) []compiledCondition {
+	var out []compiledCondition
+	add := func(tree apl.Condition, program *apl.Program) {
+		if tree != nil {
+			out = append(out, compiledCondition{tree: tree, program: program})
+		}
+	}
+	var visit func(actions []*apl.Action)
+	visit = func(actions []*apl.Action) {
+		for _, action := range actions {
+			if action == nil {
+				continue
+			}
+			add(action.Condition, action.Program)
+			add(action.Until, action.UntilProgram)
+			add(action.Reset, action.ResetProgram)
+			add(action.Interrupt, action.InterruptProgram)
+			visit(action.Steps)
+		}
+	}
+	visit(rot.Actions)
+	for _, list := range rot.Lists {
+		visit(list)
+	}
+	visit(rot.Precombat)
+	visit(rot.Pet)
+	for _, phase := range rot.Phases {
+		add(phase.Condition, phase.Program)
+	}
+	return out
+}
+
+// pullContext binds sim's rotation context to a fresh character at the pull.
+func pullContext(sim *Simulator, char *character.Character) *rotationContext {
+	spellEngine := spells.NewEngine(sim.Config, sim.BaseSeed, sim.SimConfig.IsBoss)
+	spellEngine.FightDuration = sim.SimConfig.Duration
+	sim.resetRotationState()
+	sim.rotCtx.bind(sim, character.NewCharacter(char.Stats), spellEngine)
+	return &sim.rotCtx
+}
+
+// Programs must give the same answer as the condition trees they were
+// flattened from, at the pull and at the end of fights of growing length.
+func TestProgramsMatchTrees(t *testing.T) {
+	entries, err := os.ReadDir(testRotationDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, apl.FixtureSuffix) {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			sim, char := rotationSimulator(t, testRotationDir, name, 0)
+			conditions := rotationConditions(sim.Rotation)
+			check := func(ctx *rotationContext) {
+				for _, c := range conditions {
+					if c.tree.Eval(ctx) != c.program.Eval(ctx) {
+						t.Fatalf("%.2fs: program and tree disagree", ctx.char.CurrentTime.Seconds())
+					}
+				}
+			}
+			check(pullContext(sim, char))
+			for d := 1500 * time.Millisecond; d <= 45*time.Second; d += 1500 * time.Millisecond {
+				sim.SimConfig.Duration = d
+				sim.runSingleIteration(char, 0)
+				check(&sim.rotCtx)
+			}
+		})
+	}
+}
+
+func BenchmarkSimulateIteration(b *testing.B) {
+	sim, char := rotationSimulator(b, testRotationDir, "destruction-default.yaml", 5*time.Minute)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sim.runSingleIteration(char, i)
+	}
+}
+
+// One op of the condition benchmarks evaluates every condition of the
+// default rotation once against the engine's context at the pull.
+func BenchmarkConditionsTree(b *testing.B) {
+	sim, char := rotationSimulator(b, testRotationDir, "destruction-default.yaml", 5*time.Minute)
+	conditions := rotationConditions(sim.Rotation)
+	ctx := pullContext(sim, char)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range conditions {
+			c.tree.Eval(ctx)
+		}
+	}
+}
+
+func BenchmarkConditionsProgram(b *testing.B) {
+	sim, char := rotationSimulator(b, testRotationDir, "destruction-default.yaml", 5*time.Minute)
+	conditions := rotationConditions(sim.Rotation)
+	ctx := pullContext(sim, char)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range conditions {
+			c.program.Eval(ctx)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/spells"
+)
+
+// guardian is a temporary demon that copies the warlock's stats when it is summoned.
+// Unlike pets it is not under the owner's talents and disappears after its lifetime.
+type guardian struct {
+	name        string
+	spellPower  float64
+	attackPower float64
+	critChance  float64
+	summonedAt  time.Duration
+	expiresAt   time.Duration
+}
+
+// summonGuardian snapshots the owner's spell power and crit for a guardian landing at the given time.
+func (s *Simulator) summonGuardian(name string, owner *character.Character, spellEngine *spells.Engine, at time.Duration, lifetime float64) *guardian {
+	sp, crit := spellEngine.GuardianSnapshot(owner)
+	g := &guardian{
+		name:       name,
+		spellPower: sp,
+		critChance: crit,
+		summonedAt: at,
+		expiresAt:  at + time.Duration(lifetime*float64(time.Second)),
+	}
+	if s.LogEnabled {
+		s.logAt(at, "GUARDIAN_SUMMON %s (SP %.0f, crit %.1f%%, %.0fs)", name, sp, crit*100, lifetime)
+	}
+	return g
+}
+
+// active reports whether the guardian is still fighting at the given time.
+func (g *guardian) active(at time.Duration) bool {
+	return at >= g.summonedAt && at < g.expiresAt
+}
+
+// scheduleGuardianAction queues an action for the guardian if it is still alive at that time.
+func (s *Simulator) scheduleGuardianAction(g *guardian, at time.Duration, action func()) {
+	if !g.active(at) {
+		if s.LogEnabled && at >= g.expiresAt {
+			s.logAt(g.expiresAt, "GUARDIAN_EXPIRE %s", g.name)
+		}
+		return
+	}
+	s.scheduleEvent(at, action)
+}
+
+// summonInfernal drops an Infernal that auto-attacks and pulses Immolation until it expires.
+func (s *Simulator) summonInfernal(owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	data := s.Config.Spells.Infernal
+	g := s.summonGuardian("Infernal", owner, spellEngine, at, data.Duration)
+	g.attackPower = data.BaseAttackPower + g.spellPower*data.AttackPowerPerSpellPower
+
+	swing := time.Duration(data.SwingSpeed * float64(time.Second))
+	if swing > 0 {
+		var attack func(time.Duration)
+		attack = func(now time.Duration) {
+			damage := data.WeaponDamageMin + (data.WeaponDamageMax-data.WeaponDamageMin)*spellEngine.Rng.Float64()
+			damage += g.attackPower / meleePetAttackPowerPerDPS * data.SwingSpeed
+			damage *= 1 - meleePetArmorMitigation
+			didCrit := spellEngine.Rng.Float64() < g.critChance
+			if didCrit {
+				damage *= meleePetPhysicalCritMultiplier
+			}
+			s.recordGuardianHit(result, spells.SpellInfernalMelee, "Melee (Infernal)", damage, didCrit, now)
+			next := now + swing
+			s.scheduleGuardianAction(g, next, func() { attack(next) })
+		}
+		s.scheduleGuardianAction(g, at, func() { attack(at) })
+	}
+
+	pulse := time.Duration(data.ImmolationInterval * float64(time.Second))
+	if pulse > 0 {
+		var immolation func(time.Duration)
+		immolation = func(now time.Duration) {
+			damage := data.ImmolationDamage + g.spellPower*data.ImmolationSPCoefficient
+			s.recordGuardianHit(result, spells.SpellInfernalImmolation, "Immolation (Infernal)", damage, false, now)
+			next := now + pulse
+			s.scheduleGuardianAction(g, next, func() { immolation(next) })
+		}
+		first := at + pulse
+		s.scheduleGuardianAction(g, first, func() { immolation(first) })
+	}
+}
+
+// summonDoomguard brings in a Doomguard that chain-casts Doom Bolt until it expires.
+func (s *Simulator) summonDoomguard(owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	data := s.Config.Spells.Doomguard
+	g := s.summonGuardian("Doomguard", owner, spellEngine, at, data.Duration)
+
+	castTime := time.Duration(data.DoomBoltCastTime * float64(time.Second))
+	if castTime <= 0 {
+		return
+	}
+	var doomBolt func(time.Duration)
+	doomBolt = func(now time.Duration) {
+		damage := data.DoomBoltDamageMin + (data.DoomBoltDamageMax-data.DoomBoltDamageMin)*spellEngine.Rng.Float64()
+		damage += g.spellPower * data.DoomBoltSPCoefficient
+		didCrit := spellEngine.Rng.Float64() < g.critChance
+		if didCrit {
+			damage *= meleePetSpellCritMultiplier
+		}
+		s.recordGuardianHit(result, spells.SpellDoomguardDoomBolt, "Doom Bolt (Doomguard)", damage, didCrit, now)
+		next := now + castTime
+		s.scheduleGuardianAction(g, next, func() { doomBolt(next) })
+	}
+	first := at + castTime
+	s.scheduleGuardianAction(g, first, func() { doomBolt(first) })
+}
+
+func (s *Simulator) recordGuardianHit(result *SimulationResult, spell spells.SpellType, label string, damage float64, didCrit bool, at time.Duration) {
+	result.recordSpellCast(spell, spells.CastResult{
+		Spell:   spell,
+		Damage:  damage,
+		DidHit:  true,
+		DidCrit: didCrit,
+	})
+	if s.LogEnabled {
+		outcome := "HIT"
+		if didCrit {
+			outcome = "CRIT"
+		}
+		s.logAt(at, "GUARDIAN_HIT %s %s damage=%.0f", label, outcome, damage)
+	}
+}
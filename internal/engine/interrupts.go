@@ -0,0 +1,156 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/spells"
+)
+
+// castAction casts the spell of a cast_spell entry or step. Entries with an
+// interrupt_if go through interruptibleCast.
+func (s *Simulator) castAction(ctx *rotationContext, action *apl.Action, spell spells.SpellType, result *SimulationResult, spellEngine *spells.Engine) bool {
+	if action.Interrupt == nil || s.precombat {
+		return s.tryCast(ctx.char, spell, result, spellEngine)
+	}
+	return s.interruptibleCast(ctx, action, spell, result, spellEngine)
+}
+
+// interruptibleCast starts a cast and watches the entry's interrupt_if while
+// it is in flight. The spell resolves when the cast completes, so the
+// condition sees the live state with the spell not yet landed (Backdraft not
+// yet spent, no damage dealt), and an interrupted cast costs only the time
+// spent and the GCD: no mana, damage, procs or random rolls. A condition that
+// already holds when the cast would start keeps it from starting, so the
+// entry cannot loop on start and interrupt. Instants resolve through tryCast.
+func (s *Simulator) interruptibleCast(ctx *rotationContext, action *apl.Action, spell spells.SpellType, result *SimulationResult, spellEngine *spells.Engine) bool {
+	char := ctx.char
+	castTime := spellEngine.PreviewCastTime(char, spell)
+	if castTime <= 0 {
+		return s.tryCast(char, spell, result, spellEngine)
+	}
+	s.castFailure = castFailNone
+	if !char.IsGCDReady() {
+		s.castFailure = castFailGCD
+		return false
+	}
+	spellName := spellTypeName(spell)
+	if cost := s.spellManaCost(char, spell); cost > 0 && !char.HasMana(cost) {
+		if s.LogEnabled {
+			s.logf(char, "CAST_FAIL %s (OOM)", spellName)
+		}
+		s.castFailure = castFailOOM
+		return false
+	}
+	if !ctx.cooldownReady(ctx.getCooldown(spellKey(spell))) {
+		s.castFailure = castFailCooldown
+		return false
+	}
+	if action.InterruptProgram.Eval(ctx) {
+		s.castFailure = castFailInterrupt
+		return false
+	}
+
+	start := char.CurrentTime
+	end := start + castTime
+	char.GCD.Reset(start, spellEngine.PreviewGCD(char, spell))
+	if s.LogEnabled {
+		s.logf(char, "CAST_START %s (mana=%.0f)", spellName, char.Resources.CurrentMana)
+	}
+	for char.CurrentTime < end {
+		s.runDueEvents(char.CurrentTime)
+		step := end - char.CurrentTime
+		if next, ok := s.nextEventDelta(char.CurrentTime); ok && next > 0 && next < step {
+			step = next
+		}
+		// Besides after every event, check on a fixed grid from the cast
+		// start: remaining durations and time_elapsed change without one.
+		if poll := interruptPollInterval - (char.CurrentTime-start)%interruptPollInterval; poll < step {
+			step = poll
+		}
+		s.wait(char, step, result, spellEngine)
+		if char.CurrentTime < end && action.InterruptProgram.Eval(ctx) {
+			s.recordInterrupt(spell, start, end, result, "interrupt_if")
+			return true
+		}
+	}
+
+	s.landing = true
+	_, _, cast := s.resolveCast(char, spell, result, spellEngine)
+	s.landing = false
+	if !cast {
+		s.recordInterrupt(spell, start, end, result, s.castFailure.String()+" at cast end")
+		s.castFailure = castFailNone
+	}
+	return true
+}
+
+// recordInterrupt counts a cast that was abandoned before it resolved.
+func (s *Simulator) recordInterrupt(spell spells.SpellType, start, end time.Duration, result *SimulationResult, reason string) {
+	now := s.rotCtx.char.CurrentTime
+	result.InterruptedCasts++
+	if stats, ok := result.SpellBreakdown[spell]; ok {
+		stats.Interrupted++
+		stats.InterruptedSeconds += (now - start).Seconds()
+	}
+	if s.LogEnabled {
+		s.logAt(now, "CAST_INTERRUPT %s after %.2fs of %.2fs (%s)", spellTypeName(spell), (now - start).Seconds(), (end - start).Seconds(), reason)
+	}
+}
+
+// cancelBuff removes a buff for a cancel_buff entry and reports whether it
+// was up. Buffs without a duration of their own (life_tap_buff without the
+// glyph) cannot be cancelled.
+func (s *Simulator) cancelBuff(ctx *rotationContext, name string) bool {
+	aura := ctx.resolveAura(name)
+	if !ctx.auraActive(aura) {
+		return false
+	}
+	now := ctx.char.CurrentTime
+	switch aura.kind {
+	case auraBuff:
+		aura.buff.Active = false
+		aura.buff.Charges = 0
+		aura.buff.ExpiresAt = now
+	case auraEffect:
+		aura.effect.Clear(now)
+	case auraFlag:
+		*aura.flag = false
+	case auraExpiry:
+		*aura.expiry = now
+	default:
+		return false
+	}
+	if s.LogEnabled {
+		s.logAt(now, "BUFF_CANCEL %s", name)
+	}
+	return true
+}
+
+// printInterrupts lists interrupted casts per spell; nothing when there were
+// none.
+func (r *SimulationResult) printInterrupts() {
+	if r.InterruptedCasts == 0 {
+		return
+	}
+	type row struct {
+		label string
+		stats *SpellStats
+	}
+	var rows []row
+	for _, entry := range spellPrintOrder {
+		if stats := r.SpellBreakdown[entry.Type]; stats != nil && stats.Interrupted > 0 {
+			rows = append(rows, row{label: entry.Label, stats: stats})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].stats.Interrupted > rows[j].stats.Interrupted })
+	iterations := float64(r.Iterations)
+	fmt.Println()
+	fmt.Println("Interrupted Casts (average per iteration):")
+	fmt.Println("----------------------------------------")
+	for _, row := range rows {
+		fmt.Printf("%-20s %5.1f | %.1fs cast time lost\n", row.label+":", float64(row.stats.Interrupted)/iterations, row.stats.InterruptedSeconds/iterations)
+	}
+}
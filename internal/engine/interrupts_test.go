@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"wotlk-destro-sim/internal/spells"
+)
+
+const interruptFiller = `
+  - action: cast_spell
+    spell: shadow_bolt
+`
+
+func TestInterruptBeforeCastEnd(t *testing.T) {
+	sim, char := testSimulator(t, `
+rotation:
+  - action: cast_spell
+    spell: incinerate
+    when: "time_elapsed < 0.01"
+    interrupt_if: "time_elapsed >= 1"
+`+interruptFiller, 10*time.Second)
+	result := sim.runSingleIteration(char, 0)
+
+	if result.InterruptedCasts != 1 {
+		t.Fatalf("InterruptedCasts = %d, want 1", result.InterruptedCasts)
+	}
+	stats := result.SpellBreakdown[spells.SpellIncinerate]
+	if stats.Casts != 0 || stats.Damage != 0 {
+		t.Errorf("interrupted Incinerate resolved: %d casts, %.0f damage", stats.Casts, stats.Damage)
+	}
+	if got := stats.InterruptedSeconds; got != 1 {
+		t.Errorf("InterruptedSeconds = %.3f, want 1", got)
+	}
+}
+
+func TestInterruptConditionNeverHolds(t *testing.T) {
+	sim, char := testSimulator(t, `
+rotation:
+  - action: cast_spell
+    spell: incinerate
+    when: "time_elapsed < 0.01"
+    interrupt_if: "time_elapsed >= 60"
+`+interruptFiller, 10*time.Second)
+	result := sim.runSingleIteration(char, 0)
+
+	if result.InterruptedCasts != 0 {
+		t.Fatalf("InterruptedCasts = %d, want 0", result.InterruptedCasts)
+	}
+	stats := result.SpellBreakdown[spells.SpellIncinerate]
+	if stats.Casts != 1 {
+		t.Errorf("Incinerate casts = %d, want 1", stats.Casts)
+	}
+	if stats.Hits+stats.Crits > 0 && stats.Damage == 0 {
+		t.Errorf("Incinerate landed without damage")
+	}
+}
+
+// An interrupted cast draws no random numbers and changes no state beyond
+// time, so it must leave the RNG and totals exactly where a plain wait of
+// the same length does.
+func TestInterruptMatchesNeverCastRun(t *testing.T) {
+	interrupted, char := testSimulator(t, `
+rotation:
+  - action: cast_spell
+    spell: incinerate
+    when: "time_elapsed < 0.01"
+    interrupt_if: "time_elapsed >= 2"
+`+interruptFiller, 30*time.Second)
+	waited, _ := testSimulator(t, `
+rotation:
+  - action: wait
+    duration_seconds: 2
+    when: "time_elapsed < 0.01"
+`+interruptFiller, 30*time.Second)
+
+	a := interrupted.runSingleIteration(char, 0)
+	aNext := interrupted.rotCtx.spellEngine.Rng.Int63()
+	b := waited.runSingleIteration(char, 0)
+	bNext := waited.rotCtx.spellEngine.Rng.Int63()
+
+	if a.InterruptedCasts != 1 {
+		t.Fatalf("InterruptedCasts = %d, want 1", a.InterruptedCasts)
+	}
+	if b.TotalDamage == 0 {
+		t.Fatal("filler dealt no damage")
+	}
+	if a.TotalDamage != b.TotalDamage {
+		t.Errorf("TotalDamage = %.2f, want %.2f", a.TotalDamage, b.TotalDamage)
+	}
+	if a.TotalCasts != b.TotalCasts {
+		t.Errorf("TotalCasts = %d, want %d", a.TotalCasts, b.TotalCasts)
+	}
+	if aNext != bNext {
+		t.Errorf("RNG diverged: next draw %d, want %d", aNext, bNext)
+	}
+}
@@ -23,20 +23,35 @@ const (
 	impSpiritToMp5             = 0.169
 	impCastingRegenFraction    = 0.15
 	impFireboltManaCost        = 115.0
+	impFireShieldManaCost      = 140.0
+	impFireShieldDuration      = 30 * time.Minute
+	// impFiveSecondRule is how long after a cast the imp regenerates at the
+	// casting rate when the pet list drives it.
+	impFiveSecondRule = 5 * time.Second
 )
 
+// SupportedPets lists the demons the simulator can summon.
+func SupportedPets() []string {
+	return []string{"none", "imp", "felguard", "felhunter", "succubus", "voidwalker"}
+}
+
 type petController interface {
 	reset(owner *character.Character)
 	start(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine)
 }
 
 func (s *Simulator) initializePets() {
+	if s.Config.Player.PetSacrificed() {
+		return
+	}
 	summon := strings.ToLower(strings.TrimSpace(s.Config.Player.Pet.Summon))
 	switch summon {
 	case "", "none":
 		return
 	case "imp":
 		s.pets = append(s.pets, newImpController(s.Config))
+	case "felguard", "felhunter", "succubus", "voidwalker":
+		s.pets = append(s.pets, newMeleePetController(s.Config, summon))
 	default:
 		// Unknown pet type, ignore for now.
 	}
@@ -68,6 +83,13 @@ type impController struct {
 	castTime       time.Duration
 	lastManaUpdate time.Duration
 	event          *scheduledEvent
+
+	// petList is set when the rotation's pet list picks the imp's abilities
+	// instead of the Firebolt loop; the fields below are only used then.
+	petList           bool
+	lastCastEnd       time.Duration
+	fireShieldExpires time.Duration
+	phaseShifted      bool
 }
 
 func newImpController(cfg *config.Config) *impController {
@@ -116,12 +138,24 @@ func (imp *impController) reset(owner *character.Character) {
 	imp.mp5Casting = imp.mp5OOC * impCastingRegenFraction
 	imp.lastManaUpdate = owner.CurrentTime
 	imp.event = nil
+	imp.petList = false
+	imp.lastCastEnd = owner.CurrentTime - impFiveSecondRule
+	imp.fireShieldExpires = 0
+	imp.phaseShifted = false
 }
 
 func (imp *impController) start(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
 	if owner == nil {
 		return
 	}
+	if sim.petListEnabled() {
+		imp.petList = true
+		sim.schedulePetDecision(imp, owner, result, spellEngine, owner.CurrentTime)
+		if sim.LogEnabled {
+			sim.logStaticf("Imp summoned (abilities from the pet action list)")
+		}
+		return
+	}
 	imp.scheduleFirebolt(sim, owner, result, spellEngine, owner.CurrentTime)
 	if sim.LogEnabled {
 		sim.logStaticf("Imp summoned (Firebolt every %.2fs)", imp.castTime.Seconds())
@@ -134,6 +168,7 @@ func (imp *impController) scheduleFirebolt(sim *Simulator, owner *character.Char
 	imp.event = sim.scheduleEvent(finish, func() {
 		imp.event = nil
 		imp.castFirebolt(sim, owner, result, spellEngine, castStart, finish)
+		imp.scheduleFirebolt(sim, owner, result, spellEngine, finish)
 	})
 }
 
@@ -194,9 +229,22 @@ func (imp *impController) castFirebolt(sim *Simulator, owner *character.Characte
 	if imp.cfg != nil && imp.cfg.Player.HasRune(runes.RuneImprovedImp) {
 		damage *= runes.ImprovedImpDamageMultiplier
 	}
+	damage *= sim.petDamageMultiplier(owner)
+
+	critChance := imp.critChance
+	if imp.cfg != nil {
+		md := imp.cfg.Talents.MasterDemonologist
+		if md.Points > 0 {
+			damage *= 1 + float64(md.Points)*md.Imp.FireDamagePerPoint
+			critChance += float64(md.Points) * md.Imp.FireCritPerPoint
+		}
+		if sim.demonicEmpowermentActive(owner, castStart) {
+			critChance += imp.cfg.Talents.DemonicEmpowerment.ImpCritBonus
+		}
+	}
 
 	didCrit := false
-	if spellEngine.Rng.Float64() < imp.critChance {
+	if spellEngine.Rng.Float64() < critChance {
 		didCrit = true
 		damage *= sim.Config.Talents.Ruin.CritMultiplier
 	}
@@ -218,7 +266,9 @@ func (imp *impController) castFirebolt(sim *Simulator, owner *character.Characte
 		sim.logAt(castComplete, "PET_CAST Firebolt %s damage=%.0f (mana %.0f/%.0f)", outcome, damage, imp.mana, imp.manaMax)
 	}
 
-	imp.scheduleFirebolt(sim, owner, result, spellEngine, castComplete)
+	if didCrit {
+		sim.grantDemonicPact(owner, castComplete)
+	}
 
 	if didCrit && imp.cfg != nil {
 		points := imp.cfg.Talents.EmpoweredImp.Points
@@ -238,3 +288,109 @@ func (imp *impController) castFirebolt(sim *Simulator, owner *character.Characte
 		}
 	}
 }
+
+// manaAt projects the imp's mana at now without committing the regeneration.
+// Under the pet list the imp regenerates at the casting rate until five
+// seconds after its last cast; the Firebolt loop always casts.
+func (imp *impController) manaAt(now time.Duration) float64 {
+	mana, last := imp.mana, imp.lastManaUpdate
+	regen := func(until time.Duration, mp5 float64) {
+		if until > last {
+			mana += mp5 * (until - last).Seconds() / 5.0
+			last = until
+		}
+	}
+	if imp.petList {
+		fsrEnd := imp.lastCastEnd + impFiveSecondRule
+		if fsrEnd > now {
+			fsrEnd = now
+		}
+		regen(fsrEnd, imp.mp5Casting)
+		regen(now, imp.mp5OOC)
+	} else {
+		regen(now, imp.mp5Casting)
+	}
+	return math.Min(imp.manaMax, mana)
+}
+
+func (imp *impController) manaPercent(now time.Duration) float64 {
+	if imp.manaMax <= 0 {
+		return 0
+	}
+	return imp.manaAt(now) / imp.manaMax
+}
+
+// cooldownRemaining is always 0: none of the imp's abilities has a cooldown.
+func (imp *impController) cooldownRemaining(string, time.Duration) time.Duration {
+	return 0
+}
+
+// spend commits regeneration up to now and pays cost, reporting false when
+// the imp cannot afford it.
+func (imp *impController) spend(cost float64, now time.Duration) bool {
+	if now > imp.lastManaUpdate {
+		imp.mana = imp.manaAt(now)
+		imp.lastManaUpdate = now
+	}
+	if imp.mana < cost {
+		return false
+	}
+	imp.mana -= cost
+	return true
+}
+
+func (imp *impController) useAbility(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, ability string, now time.Duration) (time.Duration, castFailure) {
+	if owner == nil {
+		return 0, castFailOther
+	}
+	switch ability {
+	case "firebolt":
+		if !imp.spend(impFireboltManaCost, now) {
+			return 0, castFailOOM
+		}
+		imp.breakPhaseShift(sim, now)
+		finish := now + imp.castTime
+		imp.lastCastEnd = finish
+		imp.event = sim.scheduleEvent(finish, func() {
+			imp.event = nil
+			imp.castFirebolt(sim, owner, result, spellEngine, now, finish)
+		})
+		if imp.castTime < petAbilityGCD {
+			return petAbilityGCD, castFailNone
+		}
+		return imp.castTime, castFailNone
+	case "fire_shield":
+		if !imp.spend(impFireShieldManaCost, now) {
+			return 0, castFailOOM
+		}
+		imp.breakPhaseShift(sim, now)
+		imp.lastCastEnd = now
+		imp.fireShieldExpires = now + impFireShieldDuration
+		if sim.LogEnabled {
+			sim.logAt(now, "PET_CAST Fire Shield (mana %.0f/%.0f)", imp.mana, imp.manaMax)
+		}
+		return petAbilityGCD, castFailNone
+	case "phase_shift":
+		if imp.phaseShifted {
+			return 0, castFailOther
+		}
+		imp.phaseShifted = true
+		if sim.LogEnabled {
+			sim.logAt(now, "PET_CAST Phase Shift")
+		}
+		return petAbilityGCD, castFailNone
+	default:
+		return 0, castFailOther
+	}
+}
+
+// breakPhaseShift ends Phase Shift when the imp acts.
+func (imp *impController) breakPhaseShift(sim *Simulator, now time.Duration) {
+	if !imp.phaseShifted {
+		return
+	}
+	imp.phaseShifted = false
+	if sim.LogEnabled {
+		sim.logAt(now, "BUFF_FADE Phase Shift")
+	}
+}
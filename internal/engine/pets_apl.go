@@ -0,0 +1,102 @@
+package engine
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/spells"
+)
+
+const (
+	// petAbilityGCD is the global cooldown a pet ability triggers.
+	petAbilityGCD = 1500 * time.Millisecond
+	// petIdleInterval is how long the pet waits before re-evaluating its
+	// list when no entry acted.
+	petIdleInterval = 500 * time.Millisecond
+)
+
+// aplPet is a pet whose abilities can be driven by the rotation's pet list
+// instead of its built-in autocast loop.
+type aplPet interface {
+	manaPercent(now time.Duration) float64
+	// cooldownRemaining is 0 for abilities the pet does not have.
+	cooldownRemaining(ability string, now time.Duration) time.Duration
+	// useAbility starts ability at now and returns how long the pet is busy,
+	// or why it could not.
+	useAbility(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, ability string, now time.Duration) (time.Duration, castFailure)
+}
+
+// petListEnabled reports whether the rotation drives pet abilities.
+func (s *Simulator) petListEnabled() bool {
+	return s.Rotation != nil && len(s.Rotation.Pet) > 0
+}
+
+// listPet returns the summoned pet that answers pet_* queries, or nil.
+func (s *Simulator) listPet() aplPet {
+	for _, pet := range s.pets {
+		if p, ok := pet.(aplPet); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// activeImp returns the summoned imp, or nil.
+func (s *Simulator) activeImp() *impController {
+	for _, pet := range s.pets {
+		if imp, ok := pet.(*impController); ok {
+			return imp
+		}
+	}
+	return nil
+}
+
+// schedulePetDecision evaluates the pet list at the given time and keeps
+// re-evaluating it whenever the pet becomes free.
+func (s *Simulator) schedulePetDecision(pet aplPet, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	s.scheduleEvent(at, func() {
+		busy := s.executePetList(pet, owner, result, spellEngine, at)
+		s.schedulePetDecision(pet, owner, result, spellEngine, at+busy)
+	})
+}
+
+// executePetList walks the pet list once and returns how long the pet is
+// busy with the entry that acted, or petIdleInterval when none did.
+func (s *Simulator) executePetList(pet aplPet, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, now time.Duration) time.Duration {
+	ctx := &s.rotCtx
+	for _, action := range s.Rotation.Pet {
+		if action == nil {
+			continue
+		}
+		stats := s.statsFor(result, action)
+		if action.Condition != nil && !action.Program.Eval(ctx) {
+			stats.evaluated(false)
+			continue
+		}
+		stats.evaluated(true)
+		if action.Type.IsVariableAction() {
+			s.applyVariableAction(owner, action)
+			stats.attempted(true, castFailNone)
+			continue
+		}
+		if s.tracing() {
+			s.logAt(now, "APL %s %s", s.actionLocation(action), actionLabel(action))
+		}
+		switch action.Type {
+		case apl.ActionPetCast:
+			busy, fail := pet.useAbility(s, owner, result, spellEngine, action.Spell, now)
+			stats.attempted(fail == castFailNone, fail)
+			if fail == castFailNone {
+				return busy
+			}
+			if s.tracing() {
+				s.logAt(now, "APL   pet_cast %s failed (%s)", action.Spell, fail)
+			}
+		case apl.ActionWait:
+			stats.attempted(true, castFailNone)
+			return action.Duration
+		}
+	}
+	return petIdleInterval
+}
@@ -0,0 +1,456 @@
+package engine
+
+import (
+	"math"
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/runes"
+	"wotlk-destro-sim/internal/spells"
+)
+
+const (
+	meleePetAttackPowerInheritance = 0.57
+	meleePetSpellPowerInheritance  = 0.15
+	meleePetIntellectInheritance   = 0.30
+	meleePetSpiritInheritance      = 0.30
+	meleePetManaPerIntellect       = 11.55
+	meleePetSpiritToMp5            = 0.169
+	meleePetAttackPowerPerDPS      = 14.0
+	meleePetPhysicalCritMultiplier = 2.0
+	meleePetSpellCritMultiplier    = 1.5
+	// Flat mitigation for physical pet damage against a raid boss.
+	meleePetArmorMitigation = 0.30
+	// Shadow Bite gains this much damage per warlock DoT on the target.
+	shadowBiteDotBonus = 0.15
+)
+
+type petSpecialSchool int
+
+const (
+	petSpecialPhysical petSpecialSchool = iota
+	petSpecialShadow
+)
+
+// meleePetSpecial describes the autocast ability of a melee demon.
+type meleePetSpecial struct {
+	name          string
+	key           string // pet_cast name
+	spell         spells.SpellType
+	school        petSpecialSchool
+	cooldown      time.Duration
+	manaCost      float64
+	baseMin       float64
+	baseMax       float64
+	weaponDamage  bool    // adds a normal weapon swing (Cleave)
+	spCoefficient float64 // scales with the demon's spell power
+}
+
+// meleePetProfile holds the base stats of a melee demon.
+type meleePetProfile struct {
+	name            string
+	baseAttackPower float64
+	weaponMin       float64
+	weaponMax       float64
+	swingSpeed      time.Duration
+	baseCritPercent float64
+	baseIntellect   float64
+	baseSpirit      float64
+	special         *meleePetSpecial
+}
+
+var meleePetProfiles = map[string]meleePetProfile{
+	"felguard": {
+		name:            "Felguard",
+		baseAttackPower: 280,
+		weaponMin:       85,
+		weaponMax:       115,
+		swingSpeed:      2 * time.Second,
+		baseCritPercent: 5,
+		baseIntellect:   150,
+		baseSpirit:      120,
+		special: &meleePetSpecial{
+			name:         "Cleave",
+			key:          "cleave",
+			spell:        spells.SpellFelguardCleave,
+			school:       petSpecialPhysical,
+			cooldown:     6 * time.Second,
+			manaCost:     100,
+			baseMin:      124,
+			baseMax:      124,
+			weaponDamage: true,
+		},
+	},
+	"felhunter": {
+		name:            "Felhunter",
+		baseAttackPower: 220,
+		weaponMin:       60,
+		weaponMax:       80,
+		swingSpeed:      2 * time.Second,
+		baseCritPercent: 5,
+		baseIntellect:   200,
+		baseSpirit:      150,
+		special: &meleePetSpecial{
+			name:          "Shadow Bite",
+			key:           "shadow_bite",
+			spell:         spells.SpellFelhunterShadowBite,
+			school:        petSpecialShadow,
+			cooldown:      6 * time.Second,
+			manaCost:      130,
+			baseMin:       97,
+			baseMax:       129,
+			spCoefficient: 0.429,
+		},
+	},
+	"succubus": {
+		name:            "Succubus",
+		baseAttackPower: 220,
+		weaponMin:       60,
+		weaponMax:       80,
+		swingSpeed:      2 * time.Second,
+		baseCritPercent: 5,
+		baseIntellect:   200,
+		baseSpirit:      150,
+		special: &meleePetSpecial{
+			name:          "Lash of Pain",
+			key:           "lash_of_pain",
+			spell:         spells.SpellSuccubusLashOfPain,
+			school:        petSpecialShadow,
+			cooldown:      12 * time.Second,
+			manaCost:      190,
+			baseMin:       237,
+			baseMax:       237,
+			spCoefficient: 0.429,
+		},
+	},
+	"voidwalker": {
+		name:            "Voidwalker",
+		baseAttackPower: 200,
+		weaponMin:       55,
+		weaponMax:       75,
+		swingSpeed:      2 * time.Second,
+		baseCritPercent: 5,
+		baseIntellect:   120,
+		baseSpirit:      110,
+	},
+}
+
+// meleePetController drives auto-attacks and the autocast special of a melee demon.
+type meleePetController struct {
+	cfg     *config.Config
+	key     string
+	profile meleePetProfile
+	owner   *character.Character
+
+	attackPower    float64
+	spellPower     float64
+	critChance     float64
+	mana           float64
+	manaMax        float64
+	mp5            float64
+	lastManaUpdate time.Duration
+	specialCD      time.Duration
+	specialReadyAt time.Duration
+
+	swingEvent   *scheduledEvent
+	specialEvent *scheduledEvent
+}
+
+func newMeleePetController(cfg *config.Config, key string) *meleePetController {
+	profile, ok := meleePetProfiles[key]
+	if !ok {
+		return nil
+	}
+	return &meleePetController{
+		cfg:     cfg,
+		key:     key,
+		profile: profile,
+	}
+}
+
+func (pet *meleePetController) reset(owner *character.Character) {
+	pet.owner = owner
+	pet.swingEvent = nil
+	pet.specialEvent = nil
+	if owner == nil {
+		return
+	}
+	ownerSP := math.Max(owner.Stats.SpellPower, 0)
+	intellect := pet.profile.baseIntellect + math.Max(owner.Stats.Intellect, 0)*meleePetIntellectInheritance
+	spirit := pet.profile.baseSpirit + math.Max(owner.Stats.Spirit, 0)*meleePetSpiritInheritance
+
+	pet.attackPower = pet.profile.baseAttackPower + ownerSP*meleePetAttackPowerInheritance
+	pet.spellPower = ownerSP * meleePetSpellPowerInheritance
+	pet.critChance = pet.profile.baseCritPercent / 100.0
+	pet.manaMax = intellect * meleePetManaPerIntellect
+	pet.mana = pet.manaMax
+	pet.mp5 = spirit * meleePetSpiritToMp5
+	pet.lastManaUpdate = owner.CurrentTime
+	pet.specialReadyAt = owner.CurrentTime
+
+	pet.specialCD = 0
+	if special := pet.profile.special; special != nil {
+		pet.specialCD = special.cooldown
+		if pet.cfg != nil {
+			switch special.spell {
+			case spells.SpellFelhunterShadowBite:
+				imp := pet.cfg.Talents.ImprovedFelhunter
+				pet.specialCD -= time.Duration(float64(imp.Points) * imp.ShadowBiteCooldownPerPoint * float64(time.Second))
+			case spells.SpellSuccubusLashOfPain:
+				dp := pet.cfg.Talents.DemonicPower
+				pet.specialCD -= time.Duration(float64(dp.Points) * dp.LashOfPainCooldownCut * float64(time.Second))
+			}
+		}
+		if pet.specialCD < time.Second {
+			pet.specialCD = time.Second
+		}
+	}
+}
+
+func (pet *meleePetController) start(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
+	if owner == nil {
+		return
+	}
+	pet.scheduleSwing(sim, owner, result, spellEngine, owner.CurrentTime)
+	if sim.petListEnabled() {
+		sim.schedulePetDecision(pet, owner, result, spellEngine, owner.CurrentTime)
+	} else if pet.profile.special != nil {
+		pet.scheduleSpecial(sim, owner, result, spellEngine, owner.CurrentTime)
+	}
+	if sim.LogEnabled {
+		special := "none"
+		if pet.profile.special != nil {
+			special = pet.profile.special.name
+		}
+		sim.logStaticf("%s summoned (AP %.0f, swing %.2fs, special %s)", pet.profile.name, pet.attackPower, pet.profile.swingSpeed.Seconds(), special)
+	}
+}
+
+// swingInterval applies attack speed bonuses active at the given time.
+func (pet *meleePetController) swingInterval(sim *Simulator, owner *character.Character, at time.Duration) time.Duration {
+	speed := 1.0
+	if sim.Config.Player.HasRune(runes.RuneDemonologyMastery) {
+		speed += runes.DemonologyMasteryPetAttackSpeedBonus
+	}
+	if pet.key == "felguard" && sim.demonicEmpowermentActive(owner, at) {
+		speed += sim.Config.Talents.DemonicEmpowerment.FelguardAttackSpeedBonus
+	}
+	return time.Duration(float64(pet.profile.swingSpeed) / speed)
+}
+
+func (pet *meleePetController) scheduleSwing(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	pet.swingEvent = sim.scheduleEvent(at, func() {
+		pet.swingEvent = nil
+		pet.swing(sim, owner, result, spellEngine, at)
+	})
+}
+
+func (pet *meleePetController) scheduleSpecial(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	pet.specialEvent = sim.scheduleEvent(at, func() {
+		pet.specialEvent = nil
+		pet.castSpecial(sim, owner, result, spellEngine, at)
+	})
+}
+
+func (pet *meleePetController) weaponDamage(spellEngine *spells.Engine) float64 {
+	base := pet.profile.weaponMin + (pet.profile.weaponMax-pet.profile.weaponMin)*spellEngine.Rng.Float64()
+	return base + pet.attackPower/meleePetAttackPowerPerDPS*pet.profile.swingSpeed.Seconds()
+}
+
+// damageMultiplier collects talent and owner bonuses for an attack of the given school.
+func (pet *meleePetController) damageMultiplier(sim *Simulator, owner *character.Character, school petSpecialSchool, melee bool) float64 {
+	mult := sim.petDamageMultiplier(owner)
+	if pet.cfg == nil {
+		return mult
+	}
+	if melee {
+		up := pet.cfg.Talents.UnholyPower
+		mult *= 1 + float64(up.Points)*up.MeleeDamagePerPoint
+	}
+	md := pet.cfg.Talents.MasterDemonologist
+	if md.Points > 0 {
+		switch {
+		case pet.key == "felguard":
+			mult *= 1 + float64(md.Points)*md.Felguard.DamagePerPoint
+		case pet.key == "succubus" && school == petSpecialShadow:
+			mult *= 1 + float64(md.Points)*md.Succubus.ShadowDamagePerPoint
+		}
+	}
+	return mult
+}
+
+func (pet *meleePetController) critBonus(school petSpecialSchool) float64 {
+	if pet.cfg == nil {
+		return 0
+	}
+	md := pet.cfg.Talents.MasterDemonologist
+	if md.Points <= 0 {
+		return 0
+	}
+	switch {
+	case pet.key == "felguard":
+		return float64(md.Points) * md.Felguard.CritPerPoint
+	case pet.key == "succubus" && school == petSpecialShadow:
+		return float64(md.Points) * md.Succubus.ShadowCritPerPoint
+	}
+	return 0
+}
+
+func (pet *meleePetController) swing(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	damage := pet.weaponDamage(spellEngine)
+	damage *= pet.damageMultiplier(sim, owner, petSpecialPhysical, true)
+	damage *= 1 - meleePetArmorMitigation
+
+	didCrit := spellEngine.Rng.Float64() < pet.critChance+pet.critBonus(petSpecialPhysical)
+	if didCrit {
+		damage *= meleePetPhysicalCritMultiplier
+	}
+
+	result.recordSpellCast(spells.SpellPetMelee, spells.CastResult{
+		Spell:   spells.SpellPetMelee,
+		Damage:  damage,
+		DidHit:  true,
+		DidCrit: didCrit,
+	})
+	if sim.LogEnabled {
+		outcome := "HIT"
+		if didCrit {
+			outcome = "CRIT"
+		}
+		sim.logAt(at, "PET_MELEE %s %s damage=%.0f", pet.profile.name, outcome, damage)
+	}
+
+	pet.scheduleSwing(sim, owner, result, spellEngine, at+pet.swingInterval(sim, owner, at))
+	if didCrit {
+		sim.grantDemonicPact(owner, at)
+	}
+}
+
+func (pet *meleePetController) regenMana(now time.Duration) {
+	if now <= pet.lastManaUpdate {
+		return
+	}
+	elapsed := (now - pet.lastManaUpdate).Seconds()
+	pet.mana = math.Min(pet.manaMax, pet.mana+pet.mp5*elapsed/5.0)
+	pet.lastManaUpdate = now
+}
+
+func (pet *meleePetController) castSpecial(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	special := pet.profile.special
+	if special == nil {
+		return
+	}
+	pet.regenMana(at)
+	if pet.mana < special.manaCost {
+		regenPerSecond := pet.mp5 / 5.0
+		if regenPerSecond <= 0 {
+			regenPerSecond = 1
+		}
+		delay := time.Duration(math.Ceil((special.manaCost - pet.mana) / regenPerSecond * float64(time.Second)))
+		pet.scheduleSpecial(sim, owner, result, spellEngine, at+delay)
+		return
+	}
+	pet.useSpecial(sim, owner, result, spellEngine, at)
+	pet.scheduleSpecial(sim, owner, result, spellEngine, pet.specialReadyAt)
+}
+
+// useSpecial pays for and resolves the special at the given time and starts
+// its cooldown. The caller has checked mana.
+func (pet *meleePetController) useSpecial(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, at time.Duration) {
+	special := pet.profile.special
+	pet.mana -= special.manaCost
+
+	damage := special.baseMin + (special.baseMax-special.baseMin)*spellEngine.Rng.Float64()
+	damage += pet.spellPower * special.spCoefficient
+	if special.weaponDamage {
+		damage += pet.weaponDamage(spellEngine)
+	}
+	if special.spell == spells.SpellFelhunterShadowBite {
+		damage *= 1 + shadowBiteDotBonus*float64(activeWarlockDots(owner, at))
+	}
+	damage *= pet.damageMultiplier(sim, owner, special.school, special.weaponDamage)
+
+	critMultiplier := meleePetSpellCritMultiplier
+	if special.school == petSpecialPhysical {
+		damage *= 1 - meleePetArmorMitigation
+		critMultiplier = meleePetPhysicalCritMultiplier
+	}
+	didCrit := spellEngine.Rng.Float64() < pet.critChance+pet.critBonus(special.school)
+	if didCrit {
+		damage *= critMultiplier
+	}
+
+	result.recordSpellCast(special.spell, spells.CastResult{
+		Spell:   special.spell,
+		Damage:  damage,
+		DidHit:  true,
+		DidCrit: didCrit,
+	})
+
+	if special.spell == spells.SpellFelhunterShadowBite && pet.cfg != nil {
+		imp := pet.cfg.Talents.ImprovedFelhunter
+		if imp.Points > 0 {
+			pet.mana = math.Min(pet.manaMax, pet.mana+pet.manaMax*float64(imp.Points)*imp.ManaReturnPerPoint)
+		}
+	}
+	if sim.LogEnabled {
+		outcome := "HIT"
+		if didCrit {
+			outcome = "CRIT"
+		}
+		sim.logAt(at, "PET_CAST %s %s damage=%.0f (mana %.0f/%.0f)", special.name, outcome, damage, pet.mana, pet.manaMax)
+	}
+
+	pet.specialReadyAt = at + pet.specialCD
+	if didCrit {
+		sim.grantDemonicPact(owner, at)
+	}
+}
+
+func (pet *meleePetController) manaPercent(now time.Duration) float64 {
+	if pet.manaMax <= 0 {
+		return 0
+	}
+	mana := pet.mana
+	if now > pet.lastManaUpdate {
+		mana += pet.mp5 * (now - pet.lastManaUpdate).Seconds() / 5.0
+	}
+	return math.Min(pet.manaMax, mana) / pet.manaMax
+}
+
+func (pet *meleePetController) cooldownRemaining(ability string, now time.Duration) time.Duration {
+	special := pet.profile.special
+	if special == nil || special.key != ability || pet.specialReadyAt <= now {
+		return 0
+	}
+	return pet.specialReadyAt - now
+}
+
+// useAbility casts the special; auto-attacks keep their own timer.
+func (pet *meleePetController) useAbility(sim *Simulator, owner *character.Character, result *SimulationResult, spellEngine *spells.Engine, ability string, now time.Duration) (time.Duration, castFailure) {
+	special := pet.profile.special
+	if special == nil || special.key != ability {
+		return 0, castFailOther
+	}
+	if pet.specialReadyAt > now {
+		return 0, castFailCooldown
+	}
+	pet.regenMana(now)
+	if pet.mana < special.manaCost {
+		return 0, castFailOOM
+	}
+	pet.useSpecial(sim, owner, result, spellEngine, now)
+	return petAbilityGCD, castFailNone
+}
+
+// activeWarlockDots counts the owner's periodic effects on the target.
+func activeWarlockDots(owner *character.Character, at time.Duration) int {
+	count := 0
+	for _, dot := range []*character.Debuff{&owner.Immolate, &owner.Corruption, &owner.CurseOfAgony} {
+		if dot.Active && dot.ExpiresAt > at {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"wotlk-destro-sim/internal/apl"
+	"wotlk-destro-sim/internal/character"
+)
+
+// PhaseStats is one rotation phase's share of the fight, summed over
+// iterations. A phase owns the time and damage from the decision that
+// selected it until the decision that selects another phase.
+type PhaseStats struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+	Damage  float64 `json:"damage"`
+	Casts   int     `json:"casts"`
+	Entries int     `json:"entries"` // times the rotation switched into the phase
+}
+
+func (p *PhaseStats) add(other *PhaseStats) {
+	p.Seconds += other.Seconds
+	p.Damage += other.Damage
+	p.Casts += other.Casts
+	p.Entries += other.Entries
+}
+
+// newPhaseStats returns one slot for the default rotation followed by one per
+// rotation phase, or nil when the rotation declares no phases.
+func (s *Simulator) newPhaseStats() []*PhaseStats {
+	if s.Rotation == nil || len(s.Rotation.Phases) == 0 {
+		return nil
+	}
+	out := []*PhaseStats{{Name: apl.DefaultPhase}}
+	for _, phase := range s.Rotation.Phases {
+		out = append(out, &PhaseStats{Name: phase.Name})
+	}
+	return out
+}
+
+// markerActive reports whether an encounter phase marker named name covers now.
+func (s *Simulator) markerActive(name string, now time.Duration) bool {
+	for _, marker := range s.Config.Player.Simulation.Phases {
+		if marker.Name != name {
+			continue
+		}
+		start := time.Duration(marker.StartSeconds * float64(time.Second))
+		end := time.Duration(marker.EndSeconds * float64(time.Second))
+		if now >= start && (marker.EndSeconds == 0 || now < end) {
+			return true
+		}
+	}
+	return false
+}
+
+// selectPhase returns the slot and action list of the first phase whose
+// marker and condition hold, falling back to the rotation list.
+func (s *Simulator) selectPhase(ctx *rotationContext) (int, []*apl.Action) {
+	for idx, phase := range s.Rotation.Phases {
+		if phase.Marker != "" && !s.markerActive(phase.Marker, ctx.char.CurrentTime) {
+			continue
+		}
+		if phase.Condition != nil && !phase.Program.Eval(ctx) {
+			continue
+		}
+		return idx + 1, phase.Actions
+	}
+	return 0, s.Rotation.Actions
+}
+
+// enterPhase selects the phase for this decision, logging and accounting for
+// a switch, and returns the list to walk.
+func (s *Simulator) enterPhase(ctx *rotationContext, result *SimulationResult) []*apl.Action {
+	idx, actions := s.selectPhase(ctx)
+	if idx == s.phase {
+		return actions
+	}
+	char := ctx.char
+	if s.LogEnabled {
+		from := "start"
+		if s.phase >= 0 {
+			from = result.Phases[s.phase].Name
+		}
+		s.logf(char, "PHASE %s -> %s", from, result.Phases[idx].Name)
+	}
+	s.closePhase(char, result)
+	s.phase = idx
+	result.Phases[idx].Entries++
+	return actions
+}
+
+// closePhase credits the running phase with the time, damage and casts since
+// it was entered. The first phase of an iteration also claims the pull and
+// any precombat damage.
+func (s *Simulator) closePhase(char *character.Character, result *SimulationResult) {
+	if s.phase < 0 {
+		return
+	}
+	end := char.CurrentTime
+	if end > s.SimConfig.Duration {
+		end = s.SimConfig.Duration
+	}
+	stats := result.Phases[s.phase]
+	stats.Seconds += (end - s.phaseStart).Seconds()
+	stats.Damage += result.TotalDamage - s.phaseDamage
+	stats.Casts += result.TotalCasts - s.phaseCasts
+	s.phaseStart = end
+	s.phaseDamage = result.TotalDamage
+	s.phaseCasts = result.TotalCasts
+}
+
+// resetPhase forgets the previous iteration's phase.
+func (s *Simulator) resetPhase() {
+	s.phase = -1
+	s.phaseStart = 0
+	s.phaseDamage = 0
+	s.phaseCasts = 0
+}
+
+func (r *SimulationResult) printPhaseStats() {
+	if len(r.Phases) == 0 {
+		return
+	}
+	iters := float64(r.Iterations)
+	fightSeconds := r.Duration.Seconds()
+	fmt.Println()
+	fmt.Println("Phases (average per iteration):")
+	fmt.Println("--------------------------------------------------------------------------")
+	fmt.Printf("%-16s | %8s | %7s | %12s | %9s | %7s | %7s\n",
+		"Phase", "Time", "Uptime", "Damage", "DPS", "Casts", "Entries")
+	fmt.Println("--------------------------------------------------------------------------")
+	for _, stats := range r.Phases {
+		avgSeconds := stats.Seconds / iters
+		dps := 0.0
+		if stats.Seconds > 0 {
+			dps = stats.Damage / stats.Seconds
+		}
+		fmt.Printf("%-16s | %7.1fs | %6.1f%% | %12.0f | %9.2f | %7.1f | %7.1f\n",
+			stats.Name, avgSeconds, uptimePercent(avgSeconds, fightSeconds), stats.Damage/iters, dps,
+			float64(stats.Casts)/iters, float64(stats.Entries)/iters)
+	}
+	fmt.Println("--------------------------------------------------------------------------")
+}
@@ -0,0 +1,276 @@
+package engine
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"runtime/debug"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// ReportVersion is the version of the Report document. Bump it when a field
+// is renamed, removed or changes meaning; new fields keep the version.
+const ReportVersion = 1
+
+// ReportInput describes what a run was given. The simulator does not keep
+// these itself, so callers fill them in before building a Report.
+type ReportInput struct {
+	Rotation     string // the rotation's name
+	RotationFile string
+	Seed         int64 // base seed; iteration i used Seed+i
+	Stats        character.Stats
+	Runes        []string // equipped mystic enchants, legendary first
+}
+
+// Report is the machine-readable form of a SimulationResult. Counts and
+// damage are averages per iteration, like the text table.
+type Report struct {
+	Version     int                        `json:"version"`
+	Build       ReportBuild                `json:"build"`
+	Input       ReportRun                  `json:"input"`
+	Totals      ReportTotals               `json:"totals"`
+	Spells      map[string]ReportSpell     `json:"spells"` // keyed by breakdown label, e.g. "Chaos Bolt"
+	Uptimes     map[string]ReportUptime    `json:"buff_uptimes"`
+	Mana        ReportMana                 `json:"mana"`
+	Interrupted map[string]ReportInterrupt `json:"interrupted,omitempty"`
+	Coverage    []ReportAction             `json:"coverage"` // APL action coverage, in table order
+}
+
+// ReportBuild identifies the binary that produced a Report.
+type ReportBuild struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// ReportRun is the input summary of a Report.
+type ReportRun struct {
+	Rotation        string      `json:"rotation"`
+	RotationFile    string      `json:"rotation_file"`
+	Seed            int64       `json:"seed"`
+	DurationSeconds float64     `json:"duration_seconds"`
+	Iterations      int         `json:"iterations"`
+	Stats           ReportStats `json:"stats"`
+	Runes           []string    `json:"runes"`
+}
+
+// ReportStats mirrors character.Stats.
+type ReportStats struct {
+	Intellect  float64 `json:"intellect"`
+	SpellPower float64 `json:"spell_power"`
+	CritPct    float64 `json:"crit_percent"`
+	HastePct   float64 `json:"haste_percent"`
+	Spirit     float64 `json:"spirit"`
+	HitPct     float64 `json:"hit_percent"`
+	MaxMana    float64 `json:"max_mana"`
+}
+
+// ReportTotals holds the fight-wide numbers.
+type ReportTotals struct {
+	DPS               float64 `json:"dps"`
+	Damage            float64 `json:"damage"`
+	Healing           float64 `json:"healing"`
+	Casts             float64 `json:"casts"`
+	Misses            float64 `json:"misses"`
+	Crits             float64 `json:"crits"`
+	ShadowTranceProcs float64 `json:"shadow_trance_procs"`
+}
+
+// ReportSpell is one row of the spell breakdown.
+type ReportSpell struct {
+	Casts    float64 `json:"casts"`
+	Damage   float64 `json:"damage"`
+	DPS      float64 `json:"dps"`
+	SharePct float64 `json:"share_percent"`
+	AvgHit   float64 `json:"avg_hit"`
+	MinHit   float64 `json:"min_hit"`
+	MaxHit   float64 `json:"max_hit"`
+	CritPct  float64 `json:"crit_percent"`
+	MissPct  float64 `json:"miss_percent"`
+}
+
+// ReportUptime is one buff's average uptime.
+type ReportUptime struct {
+	Seconds float64 `json:"seconds"`
+	Pct     float64 `json:"percent"`
+}
+
+// ReportMana holds the mana management counts.
+type ReportMana struct {
+	LifeTaps  float64 `json:"life_taps"`
+	OOMEvents float64 `json:"oom_events"`
+}
+
+// ReportInterrupt is one spell's interrupted casts.
+type ReportInterrupt struct {
+	Casts       float64 `json:"casts"`
+	SecondsLost float64 `json:"seconds_lost"`
+}
+
+// ReportAction is one row of the APL action coverage table.
+type ReportAction struct {
+	Location    string   `json:"location"`
+	Label       string   `json:"label"`
+	Tags        []string `json:"tags,omitempty"`
+	Evaluations float64  `json:"evaluations"`
+	Passed      float64  `json:"passed"`
+	Attempts    float64  `json:"attempts"`
+	Successes   float64  `json:"successes"`
+	FailOOM     float64  `json:"fail_oom"`
+	FailCD      float64  `json:"fail_cooldown"`
+	FailGCD     float64  `json:"fail_gcd"`
+	FailTalent  float64  `json:"fail_talent"`
+	FailOther   float64  `json:"fail_other"`
+}
+
+// Report builds the machine-readable form of r.
+func (r *SimulationResult) Report(in ReportInput) *Report {
+	iters := float64(r.Iterations)
+	if iters <= 0 {
+		iters = 1
+	}
+	fightSeconds := r.Duration.Seconds()
+	rep := &Report{
+		Version: ReportVersion,
+		Build:   buildMetadata(),
+		Input: ReportRun{
+			Rotation:        in.Rotation,
+			RotationFile:    in.RotationFile,
+			Seed:            in.Seed,
+			DurationSeconds: fightSeconds,
+			Iterations:      r.Iterations,
+			Stats:           ReportStats(in.Stats),
+			Runes:           in.Runes,
+		},
+		Totals: ReportTotals{
+			DPS:               r.TotalDPS,
+			Damage:            r.TotalDamage, // already averaged by Run
+			Healing:           r.TotalHealing,
+			Casts:             float64(r.TotalCasts) / iters,
+			Misses:            float64(r.MissCount) / iters,
+			Crits:             float64(r.CritCount) / iters,
+			ShadowTranceProcs: float64(r.ShadowTranceProcs) / iters,
+		},
+		Spells:  map[string]ReportSpell{},
+		Uptimes: map[string]ReportUptime{},
+		Mana: ReportMana{
+			LifeTaps:  float64(r.LifeTapCount) / iters,
+			OOMEvents: float64(r.OOMEvents) / iters,
+		},
+	}
+	if rep.Input.Runes == nil {
+		rep.Input.Runes = []string{}
+	}
+
+	spellDamage := 0.0
+	for _, stats := range r.SpellBreakdown {
+		spellDamage += stats.Damage
+	}
+	for _, entry := range spellPrintOrder {
+		stats := r.SpellBreakdown[entry.Type]
+		if stats == nil {
+			continue
+		}
+		if stats.Interrupted > 0 {
+			if rep.Interrupted == nil {
+				rep.Interrupted = map[string]ReportInterrupt{}
+			}
+			rep.Interrupted[entry.Label] = ReportInterrupt{
+				Casts:       float64(stats.Interrupted) / iters,
+				SecondsLost: stats.InterruptedSeconds / iters,
+			}
+		}
+		if stats.Casts == 0 {
+			continue
+		}
+		row := ReportSpell{
+			Casts:   float64(stats.Casts) / iters,
+			Damage:  stats.Damage / iters,
+			MissPct: float64(stats.Misses) / float64(stats.Casts) * 100.0,
+		}
+		if fightSeconds > 0 {
+			row.DPS = row.Damage / fightSeconds
+		}
+		if spellDamage > 0 {
+			row.SharePct = stats.Damage / spellDamage * 100.0
+		}
+		if stats.Hits > 0 {
+			row.AvgHit = stats.Damage / float64(stats.Hits)
+			row.MinHit = stats.MinDamage
+			if stats.MinDamage == math.MaxFloat64 {
+				row.MinHit = 0
+			}
+			row.MaxHit = stats.MaxDamage
+			if stats.MaxDamage == 0 {
+				row.MaxHit = row.AvgHit
+			}
+			row.CritPct = float64(stats.Crits) / float64(stats.Hits) * 100.0
+		}
+		rep.Spells[entry.Label] = row
+	}
+
+	uptime := func(name string, total float64) {
+		avg := total / iters
+		rep.Uptimes[name] = ReportUptime{Seconds: avg, Pct: uptimePercent(avg, fightSeconds)}
+	}
+	uptime("pyroclasm", r.PyroclasmActiveSeconds)
+	uptime("improved_soul_leech", r.ImprovedSoulLeechActiveSeconds)
+	uptime("backdraft", r.BackdraftActiveSeconds)
+	uptime("metamorphosis", r.MetamorphosisActiveSeconds)
+	uptime("demonic_pact", r.DemonicPactActiveSeconds)
+
+	rep.Coverage = make([]ReportAction, 0, len(r.ActionStats))
+	for _, stats := range r.ActionStats {
+		rep.Coverage = append(rep.Coverage, ReportAction{
+			Location:    stats.Location,
+			Label:       stats.Label,
+			Tags:        stats.Tags,
+			Evaluations: float64(stats.Evaluations) / iters,
+			Passed:      float64(stats.Passed) / iters,
+			Attempts:    float64(stats.Attempts) / iters,
+			Successes:   float64(stats.Successes) / iters,
+			FailOOM:     float64(stats.FailOOM) / iters,
+			FailCD:      float64(stats.FailCD) / iters,
+			FailGCD:     float64(stats.FailGCD) / iters,
+			FailTalent:  float64(stats.FailTalent) / iters,
+			FailOther:   float64(stats.FailOther) / iters,
+		})
+	}
+	return rep
+}
+
+// WriteJSON writes r's Report as indented JSON.
+func (r *SimulationResult) WriteJSON(w io.Writer, in ReportInput) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Report(in))
+}
+
+// buildMetadata reads the module version and VCS stamp the Go toolchain
+// embeds in the binary. go run and test binaries carry no VCS settings.
+func buildMetadata() ReportBuild {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ReportBuild{GoVersion: "unknown"}
+	}
+	build := ReportBuild{
+		GoVersion: info.GoVersion,
+		Module:    info.Main.Path,
+		Version:   info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
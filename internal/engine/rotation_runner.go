@@ -1,18 +1,96 @@
 package engine
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	"wotlk-destro-sim/internal/apl"
 	"wotlk-destro-sim/internal/character"
-	"wotlk-destro-sim/internal/runes"
 	"wotlk-destro-sim/internal/spells"
 )
 
+// rotationContext answers APL queries for one character. The simulator keeps
+// a single instance and binds it once per iteration, resolving the compiled
+// rotation's symbols to the state they read so Programs can query by index.
 type rotationContext struct {
-	sim  *Simulator
-	char *character.Character
+	sim         *Simulator
+	char        *character.Character
+	spellEngine *spells.Engine
+
+	buffs     []boundAura
+	debuffs   []boundAura
+	auras     []boundAura
+	cooldowns []*character.Cooldown
+	spells    []boundSpell
+	resources []string
+	variables []string
+	spellKeys []string
+	petSpells []string
+}
+
+type boundSpell struct {
+	spell spells.SpellType
+	ok    bool
+}
+
+// bind points the context at this iteration's character and spell engine and
+// resolves the rotation's symbols against them.
+func (c *rotationContext) bind(sim *Simulator, char *character.Character, spellEngine *spells.Engine) {
+	c.sim, c.char, c.spellEngine = sim, char, spellEngine
+	c.buffs, c.debuffs, c.auras = c.buffs[:0], c.debuffs[:0], c.auras[:0]
+	c.cooldowns, c.spells = c.cooldowns[:0], c.spells[:0]
+	if sim.Rotation == nil {
+		return
+	}
+	syms := &sim.Rotation.Symbols
+	for _, name := range syms.Buffs {
+		c.buffs = append(c.buffs, c.resolveAura(name))
+	}
+	for _, name := range syms.Debuffs {
+		c.debuffs = append(c.debuffs, c.resolveAura(name))
+	}
+	for _, name := range syms.Auras {
+		c.auras = append(c.auras, c.resolveAura(name))
+	}
+	for _, name := range syms.Cooldowns {
+		c.cooldowns = append(c.cooldowns, c.getCooldown(name))
+	}
+	for _, name := range syms.Spells {
+		spell, ok := spellFromName(name)
+		c.spells = append(c.spells, boundSpell{spell: spell, ok: ok})
+	}
+	c.resources = syms.Resources
+	c.variables = syms.Variables
+	c.spellKeys = syms.Spells
+	c.petSpells = syms.PetSpells
+}
+
+// spellFor returns the spell a compiled cast action names.
+func (c *rotationContext) spellFor(action *apl.Action) (spells.SpellType, bool) {
+	if action.SpellSym < 0 || action.SpellSym >= len(c.spells) {
+		return 0, false
+	}
+	bound := c.spells[action.SpellSym]
+	return bound.spell, bound.ok
+}
+
+func (c *rotationContext) cooldownReady(cd *character.Cooldown) bool {
+	return cd == nil || c.char.IsCooldownReady(cd)
+}
+
+func (c *rotationContext) cooldownRemaining(cd *character.Cooldown) time.Duration {
+	if c.cooldownReady(cd) {
+		return 0
+	}
+	return cd.ReadyAt - c.char.CurrentTime
+}
+
+func (c *rotationContext) castTime(spell boundSpell) time.Duration {
+	if !spell.ok || c.spellEngine == nil {
+		return 0
+	}
+	return c.spellEngine.PreviewCastTime(c.char, spell.spell)
 }
 
 type buffState struct {
@@ -31,105 +109,47 @@ type buffState struct {
 	guldansExpires      time.Duration
 	shadowTranceActive  bool
 	shadowTranceExpires time.Duration
+
+	metamorphosisActive      bool
+	moltenCoreCharges        int
+	decimationActive         bool
+	demonicEmpowermentActive bool
 }
 
 func (c *rotationContext) BuffActive(name string) bool {
-	lower := strings.ToLower(name)
-	if lower == "life_tap_buff" && !c.sim.Config.Player.HasRune(runes.RuneGlyphOfLifeTap) {
-		return true
-	}
-	if lower == "decisive_decimation" {
-		return c.char.DecisiveDecimation.Active
-	}
-	if lower == "dusk_till_dawn" {
-		return c.char.DuskTillDawn != nil && c.char.DuskTillDawn.ActiveAt(c.char.CurrentTime)
-	}
-	buff := c.getBuff(lower)
-	if buff == nil {
-		return false
-	}
-	if !buff.Active {
-		return false
-	}
-	return buff.ExpiresAt > c.char.CurrentTime
+	return c.auraActive(c.resolveAura(name))
 }
 
 func (c *rotationContext) BuffRemaining(name string) time.Duration {
-	lower := strings.ToLower(name)
-	if lower == "life_tap_buff" && !c.sim.Config.Player.HasRune(runes.RuneGlyphOfLifeTap) {
-		return time.Hour
-	}
-	if lower == "decisive_decimation" {
-		if c.char.DecisiveDecimation.Active {
-			return time.Hour
-		}
-		return 0
-	}
-	if lower == "dusk_till_dawn" {
-		if c.char.DuskTillDawn == nil {
-			return 0
-		}
-		return c.char.DuskTillDawn.Remaining(c.char.CurrentTime)
-	}
-	buff := c.getBuff(lower)
-	if buff == nil {
-		return 0
-	}
-	if !buff.Active || buff.ExpiresAt <= c.char.CurrentTime {
-		return 0
-	}
-	return buff.ExpiresAt - c.char.CurrentTime
+	return c.auraRemaining(c.resolveAura(name))
 }
 
 func (c *rotationContext) BuffCharges(name string) int {
-	if strings.ToLower(name) == "dusk_till_dawn" {
-		if c.char.DuskTillDawn == nil {
-			return 0
-		}
-		return c.char.DuskTillDawn.Stacks()
-	}
-	buff := c.getBuff(name)
-	if buff == nil {
-		return 0
-	}
-	return buff.Charges
+	return c.auraStacks(c.resolveAura(name))
 }
 
 func (c *rotationContext) DebuffActive(name string) bool {
-	switch strings.ToLower(name) {
-	case "immolate":
-		return c.char.Immolate.Active && c.char.Immolate.ExpiresAt > c.char.CurrentTime
-	case "curse_of_the_elements":
-		return c.char.CurseOfElements.Active && c.char.CurseOfElements.ExpiresAt > c.char.CurrentTime
-	case "corruption":
-		return c.char.Corruption.Active && c.char.Corruption.ExpiresAt > c.char.CurrentTime
-	case "curse_of_agony":
-		return c.char.CurseOfAgony.Active && c.char.CurseOfAgony.ExpiresAt > c.char.CurrentTime
-	default:
-		return false
-	}
+	return c.auraActive(c.resolveAura(name))
 }
 
 func (c *rotationContext) DebuffRemaining(name string) time.Duration {
-	switch strings.ToLower(name) {
-	case "immolate":
-		if c.char.Immolate.Active && c.char.Immolate.ExpiresAt > c.char.CurrentTime {
-			return c.char.Immolate.ExpiresAt - c.char.CurrentTime
-		}
-	case "curse_of_the_elements":
-		if c.char.CurseOfElements.Active && c.char.CurseOfElements.ExpiresAt > c.char.CurrentTime {
-			return c.char.CurseOfElements.ExpiresAt - c.char.CurrentTime
-		}
-	case "corruption":
-		if c.char.Corruption.Active && c.char.Corruption.ExpiresAt > c.char.CurrentTime {
-			return c.char.Corruption.ExpiresAt - c.char.CurrentTime
-		}
-	case "curse_of_agony":
-		if c.char.CurseOfAgony.Active && c.char.CurseOfAgony.ExpiresAt > c.char.CurrentTime {
-			return c.char.CurseOfAgony.ExpiresAt - c.char.CurrentTime
-		}
-	}
-	return 0
+	return c.auraRemaining(c.resolveAura(name))
+}
+
+func (c *rotationContext) AuraActive(name string) bool {
+	return c.auraActive(c.resolveAura(name))
+}
+
+func (c *rotationContext) AuraRemaining(name string) time.Duration {
+	return c.auraRemaining(c.resolveAura(name))
+}
+
+func (c *rotationContext) AuraStacks(name string) int {
+	return c.auraStacks(c.resolveAura(name))
+}
+
+func (c *rotationContext) AuraMaxStacks(name string) int {
+	return c.resolveAura(name).max
 }
 
 func (c *rotationContext) ResourcePercent(resource string) float64 {
@@ -139,72 +159,153 @@ func (c *rotationContext) ResourcePercent(resource string) float64 {
 			return 0
 		}
 		return c.char.Resources.CurrentMana / c.char.Stats.MaxMana
+	case "pet_mana":
+		if pet := c.sim.listPet(); pet != nil {
+			return pet.manaPercent(c.char.CurrentTime)
+		}
+		return 0
 	default:
 		return 0
 	}
 }
 
-func (c *rotationContext) CooldownReady(name string) bool {
-	switch strings.ToLower(name) {
-	case "conflagrate":
-		return c.char.IsCooldownReady(&c.char.Conflagrate)
-	case "chaos_bolt":
-		return c.char.IsCooldownReady(&c.char.ChaosBolt)
-	case "shadowburn":
-		return c.char.IsCooldownReady(&c.char.Shadowburn)
-	case "shadowfury":
-		return c.char.IsCooldownReady(&c.char.Shadowfury)
-	default:
-		return true
+func (c *rotationContext) TimeElapsed() time.Duration {
+	return c.char.CurrentTime
+}
+
+func (c *rotationContext) TimeRemaining() time.Duration {
+	remaining := c.sim.SimConfig.Duration - c.char.CurrentTime
+	if remaining < 0 {
+		return 0
 	}
+	return remaining
+}
+
+func (c *rotationContext) TargetHealthPercent() float64 {
+	if c.spellEngine == nil {
+		return 1
+	}
+	return c.spellEngine.TargetHealthPercent(c.char)
+}
+
+func (c *rotationContext) CastTime(name string) time.Duration {
+	spell, ok := spellFromName(name)
+	return c.castTime(boundSpell{spell: spell, ok: ok})
+}
+
+func (c *rotationContext) GCDRemaining() time.Duration {
+	return c.char.GCD.Remaining(c.char.CurrentTime)
+}
+
+func (c *rotationContext) LastCast() string {
+	return c.char.LastCast
+}
+
+func (c *rotationContext) CastsSince(name string) int {
+	return c.char.CastsSince(strings.ToLower(name))
+}
+
+func (c *rotationContext) TicksRemaining(name string) int {
+	return c.ticksRemaining(c.resolveAura(name))
+}
+
+func (c *rotationContext) MarkerActive(name string) bool {
+	return c.sim.markerActive(name, c.char.CurrentTime)
+}
+
+func (c *rotationContext) Variable(name string) float64 {
+	return c.sim.variables[name]
+}
+
+func (c *rotationContext) CooldownReady(name string) bool {
+	return c.cooldownReady(c.getCooldown(name))
 }
 
 func (c *rotationContext) CooldownRemaining(name string) time.Duration {
-	switch strings.ToLower(name) {
-	case "conflagrate":
-		if c.char.IsCooldownReady(&c.char.Conflagrate) {
-			return 0
-		}
-		if c.char.Conflagrate.ReadyAt > c.char.CurrentTime {
-			return c.char.Conflagrate.ReadyAt - c.char.CurrentTime
-		}
-	case "chaos_bolt":
-		if c.char.IsCooldownReady(&c.char.ChaosBolt) {
-			return 0
-		}
-		if c.char.ChaosBolt.ReadyAt > c.char.CurrentTime {
-			return c.char.ChaosBolt.ReadyAt - c.char.CurrentTime
-		}
-	case "shadowburn":
-		if c.char.IsCooldownReady(&c.char.Shadowburn) {
-			return 0
-		}
-		if c.char.Shadowburn.ReadyAt > c.char.CurrentTime {
-			return c.char.Shadowburn.ReadyAt - c.char.CurrentTime
-		}
-	case "shadowfury":
-		if c.char.IsCooldownReady(&c.char.Shadowfury) {
-			return 0
-		}
-		if c.char.Shadowfury.ReadyAt > c.char.CurrentTime {
-			return c.char.Shadowfury.ReadyAt - c.char.CurrentTime
-		}
+	return c.cooldownRemaining(c.getCooldown(name))
+}
+
+func (c *rotationContext) PetCooldownRemaining(spell string) time.Duration {
+	if pet := c.sim.listPet(); pet != nil {
+		return pet.cooldownRemaining(spell, c.char.CurrentTime)
 	}
 	return 0
 }
 
-func (c *rotationContext) getBuff(name string) *character.Buff {
+// The *At methods answer by index into the bound rotation's apl.Symbols.
+
+func (c *rotationContext) BuffActiveAt(id int) bool { return c.auraActive(c.buffs[id]) }
+
+func (c *rotationContext) BuffRemainingAt(id int) time.Duration {
+	return c.auraRemaining(c.buffs[id])
+}
+
+func (c *rotationContext) BuffChargesAt(id int) int { return c.auraStacks(c.buffs[id]) }
+
+func (c *rotationContext) DebuffActiveAt(id int) bool { return c.auraActive(c.debuffs[id]) }
+
+func (c *rotationContext) DebuffRemainingAt(id int) time.Duration {
+	return c.auraRemaining(c.debuffs[id])
+}
+
+func (c *rotationContext) AuraActiveAt(id int) bool { return c.auraActive(c.auras[id]) }
+
+func (c *rotationContext) AuraRemainingAt(id int) time.Duration {
+	return c.auraRemaining(c.auras[id])
+}
+
+func (c *rotationContext) AuraStacksAt(id int) int { return c.auraStacks(c.auras[id]) }
+
+func (c *rotationContext) AuraMaxStacksAt(id int) int { return c.auras[id].max }
+
+func (c *rotationContext) TicksRemainingAt(id int) int { return c.ticksRemaining(c.debuffs[id]) }
+
+func (c *rotationContext) CooldownReadyAt(id int) bool { return c.cooldownReady(c.cooldowns[id]) }
+
+func (c *rotationContext) CooldownRemainingAt(id int) time.Duration {
+	return c.cooldownRemaining(c.cooldowns[id])
+}
+
+func (c *rotationContext) ResourcePercentAt(id int) float64 {
+	return c.ResourcePercent(c.resources[id])
+}
+
+func (c *rotationContext) CastTimeAt(id int) time.Duration { return c.castTime(c.spells[id]) }
+
+func (c *rotationContext) CastsSinceAt(id int) int {
+	return c.char.CastsSince(c.spellKeys[id])
+}
+
+func (c *rotationContext) LastCastIs(id int) bool {
+	return c.char.LastCast == c.spellKeys[id]
+}
+
+func (c *rotationContext) VariableAt(id int) float64 { return c.sim.variables[c.variables[id]] }
+
+func (c *rotationContext) PetCooldownRemainingAt(id int) time.Duration {
+	return c.PetCooldownRemaining(c.petSpells[id])
+}
+
+func (c *rotationContext) getCooldown(name string) *character.Cooldown {
 	switch strings.ToLower(name) {
-	case "pyroclasm":
-		return &c.char.Pyroclasm
-	case "backdraft":
-		return &c.char.Backdraft
-	case "improved_soul_leech", "soul_leech":
-		return &c.char.ImprovedSoulLeech
-	case "life_tap_buff":
-		return &c.char.LifeTapBuff
-	case "shadow_trance":
-		return &c.char.ShadowTrance
+	case "conflagrate":
+		return &c.char.Conflagrate
+	case "chaos_bolt":
+		return &c.char.ChaosBolt
+	case "shadowburn":
+		return &c.char.Shadowburn
+	case "shadowfury":
+		return &c.char.Shadowfury
+	case "metamorphosis":
+		return &c.char.MetamorphosisCooldown
+	case "demonic_empowerment":
+		return &c.char.DemonicEmpowermentCooldown
+	case "immolation_aura":
+		return &c.char.ImmolationAuraCooldown
+	case "curse_of_doom":
+		return &c.char.CurseOfDoomCooldown
+	case "inferno":
+		return &c.char.InfernoCooldown
 	default:
 		return nil
 	}
@@ -238,6 +339,16 @@ func spellFromName(name string) (spells.SpellType, bool) {
 		return spells.SpellShadowfury, true
 	case "shadow_crash":
 		return spells.SpellShadowCrash, true
+	case "metamorphosis":
+		return spells.SpellMetamorphosis, true
+	case "demonic_empowerment":
+		return spells.SpellDemonicEmpowerment, true
+	case "immolation_aura":
+		return spells.SpellImmolationAura, true
+	case "curse_of_doom":
+		return spells.SpellCurseOfDoom, true
+	case "inferno":
+		return spells.SpellInferno, true
 	default:
 		return 0, false
 	}
@@ -271,11 +382,26 @@ func spellTypeName(spell spells.SpellType) string {
 		return "Curse of Agony"
 	case spells.SpellShadowCrash:
 		return "Shadow Crash"
+	case spells.SpellMetamorphosis:
+		return "Metamorphosis"
+	case spells.SpellDemonicEmpowerment:
+		return "Demonic Empowerment"
+	case spells.SpellImmolationAura:
+		return "Immolation Aura"
+	case spells.SpellCurseOfDoom:
+		return "Curse of Doom"
+	case spells.SpellInferno:
+		return "Inferno"
 	default:
 		return "Unknown"
 	}
 }
 
+// spellKey returns the APL identifier for a spell (e.g. "chaos_bolt").
+func spellKey(spell spells.SpellType) string {
+	return strings.ToLower(strings.ReplaceAll(spellTypeName(spell), " ", "_"))
+}
+
 func captureBuffState(char *character.Character) buffState {
 	catStacks := 0
 	if char.CataclysmicBurst != nil {
@@ -309,45 +435,201 @@ func captureBuffState(char *character.Character) buffState {
 		guldansExpires:      guldansExpires,
 		shadowTranceActive:  char.ShadowTrance.Active,
 		shadowTranceExpires: char.ShadowTrance.ExpiresAt,
+
+		metamorphosisActive:      char.Metamorphosis.Active,
+		moltenCoreCharges:        char.MoltenCore.Charges,
+		decimationActive:         char.Decimation.Active,
+		demonicEmpowermentActive: char.DemonicEmpowerment.Active,
 	}
 }
 
 func (s *Simulator) executeRotation(char *character.Character, result *SimulationResult, spellEngine *spells.Engine) bool {
-	if s.Rotation == nil || len(s.Rotation.Actions) == 0 {
+	if s.Rotation == nil || (len(s.Rotation.Actions) == 0 && len(s.Rotation.Phases) == 0) {
 		return false
 	}
-	ctx := &rotationContext{sim: s, char: char}
-	for _, action := range s.Rotation.Actions {
+	if s.sequences == nil {
+		s.sequences = map[*apl.Action]int{}
+	}
+	ctx := &s.rotCtx
+	s.traceNotes = s.traceNotes[:0]
+	s.traceSkipped = 0
+	actions := s.Rotation.Actions
+	if len(s.Rotation.Phases) > 0 {
+		actions = s.enterPhase(ctx, result)
+	}
+	return s.executeActionList(ctx, actions, result, spellEngine)
+}
+
+// traceSkipLimit caps how many passed-over entries a decision trace lists.
+const traceSkipLimit = 3
+
+func (s *Simulator) tracing() bool {
+	return s.TraceAPL && s.LogEnabled
+}
+
+func (s *Simulator) actionLocation(action *apl.Action) string {
+	if idx, ok := s.actionIndex[action]; ok {
+		return s.actionTemplate[idx].Location
+	}
+	return actionLabel(action)
+}
+
+// traceSkip notes an entry that was passed over in this decision.
+func (s *Simulator) traceSkip(action *apl.Action, format string, args ...interface{}) {
+	if !s.tracing() {
+		return
+	}
+	s.traceSkipped++
+	if len(s.traceNotes) < traceSkipLimit {
+		s.traceNotes = append(s.traceNotes, s.actionLocation(action)+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+// tracePick logs the entry about to act and the entries skipped before it.
+func (s *Simulator) tracePick(char *character.Character, action *apl.Action) {
+	if !s.tracing() {
+		return
+	}
+	line := "APL " + s.actionLocation(action) + " " + actionLabel(action)
+	if len(action.Tags) > 0 {
+		line += " [" + strings.Join(action.Tags, ",") + "]"
+	}
+	s.logf(char, "%s", line)
+	for _, note := range s.traceNotes {
+		s.logf(char, "APL   skipped %s", note)
+	}
+	if extra := s.traceSkipped - len(s.traceNotes); extra > 0 {
+		s.logf(char, "APL   ... %d more skipped", extra)
+	}
+	s.traceNotes = s.traceNotes[:0]
+	s.traceSkipped = 0
+}
+
+func (f castFailure) String() string {
+	switch f {
+	case castFailGCD:
+		return "GCD"
+	case castFailOOM:
+		return "OOM"
+	case castFailCooldown:
+		return "cooldown"
+	case castFailTalent:
+		return "missing talent"
+	case castFailInterrupt:
+		return "interrupt_if holds"
+	case castFailOther:
+		return "unavailable"
+	default:
+		return "no cast"
+	}
+}
+
+const (
+	// sequenceRetryInterval is how long a started sequence idles when its
+	// current step cannot be cast yet.
+	sequenceRetryInterval = 100 * time.Millisecond
+	// waitUntilPollInterval is how often wait_until re-checks its condition.
+	waitUntilPollInterval = 50 * time.Millisecond
+	// interruptPollInterval is how often an interruptible cast re-checks its
+	// interrupt_if between events.
+	interruptPollInterval = 50 * time.Millisecond
+)
+
+// executeActionList walks one priority list and reports whether it cast or waited.
+func (s *Simulator) executeActionList(ctx *rotationContext, actions []*apl.Action, result *SimulationResult, spellEngine *spells.Engine) bool {
+	char := ctx.char
+	for _, action := range actions {
 		if action == nil {
 			continue
 		}
-		if action.Condition != nil && !action.Condition.Eval(ctx) {
+		if action.Type == apl.ActionSequence && action.Reset != nil && action.ResetProgram.Eval(ctx) {
+			s.sequences[action] = 0
+		}
+		stats := s.statsFor(result, action)
+		// A started sequence keeps going even if its when no longer holds.
+		started := action.Type == apl.ActionSequence && s.sequences[action] > 0 && s.sequences[action] < len(action.Steps)
+		if !started && action.Condition != nil && !action.Program.Eval(ctx) {
+			stats.evaluated(false)
+			if s.tracing() {
+				s.traceSkip(action, "%s", apl.Explain(action.Condition, ctx))
+			}
+			continue
+		}
+		stats.evaluated(true)
+		if action.Type.IsVariableAction() {
+			s.applyVariableAction(char, action)
+			stats.attempted(true, castFailNone)
 			continue
 		}
 		switch action.Type {
 		case apl.ActionCastSpell:
-			spell, ok := spellFromName(action.Spell)
+			spell, ok := ctx.spellFor(action)
 			if !ok {
 				continue
 			}
-			if s.tryCast(char, spell, result, spellEngine) {
+			s.tracePick(char, action)
+			cast := s.castAction(ctx, action, spell, result, spellEngine)
+			stats.attempted(cast, s.castFailure)
+			if cast {
+				return true
+			}
+			s.traceSkip(action, "cast failed (%s)", s.castFailure)
+		case apl.ActionCancelBuff:
+			cancelled := s.cancelBuff(ctx, action.Buff)
+			stats.attempted(cancelled, castFailOther)
+			if !cancelled {
+				s.traceSkip(action, "%s not up", action.Buff)
+			}
+		case apl.ActionSequence:
+			s.tracePick(char, action)
+			s.castFailure = castFailNone
+			acted := s.executeSequence(ctx, action, result, spellEngine)
+			stats.attempted(acted, s.castFailure)
+			if acted {
+				return true
+			}
+			s.traceSkip(action, "sequence step not ready (%s)", s.castFailure)
+		case apl.ActionWaitUntil:
+			s.tracePick(char, action)
+			acted := s.waitUntil(ctx, action, result, spellEngine)
+			stats.attempted(acted, castFailNone)
+			if acted {
+				return true
+			}
+		case apl.ActionCallList:
+			acted := s.executeActionList(ctx, s.Rotation.Lists[action.List], result, spellEngine)
+			stats.attempted(acted, castFailNone)
+			if acted {
 				return true
 			}
+		case apl.ActionRunList:
+			acted := s.executeActionList(ctx, s.Rotation.Lists[action.List], result, spellEngine)
+			stats.attempted(acted, castFailNone)
+			return acted
 		case apl.ActionMacro:
+			s.tracePick(char, action)
 			for _, step := range action.Steps {
 				if step == nil {
 					continue
 				}
-				if step.Condition != nil && !step.Condition.Eval(ctx) {
+				if step.Condition != nil && !step.Program.Eval(ctx) {
+					continue
+				}
+				if step.Type.IsVariableAction() {
+					s.applyVariableAction(char, step)
 					continue
 				}
 				switch step.Type {
+				case apl.ActionCancelBuff:
+					s.cancelBuff(ctx, step.Buff)
 				case apl.ActionCastSpell:
-					spell, ok := spellFromName(step.Spell)
+					spell, ok := ctx.spellFor(step)
 					if !ok {
 						continue
 					}
-					if s.tryCast(char, spell, result, spellEngine) {
+					cast := s.castAction(ctx, step, spell, result, spellEngine)
+					stats.attempted(cast, s.castFailure)
+					if cast {
 						return true
 					}
 				case apl.ActionWait:
@@ -355,6 +637,7 @@ func (s *Simulator) executeRotation(char *character.Character, result *Simulatio
 						continue
 					}
 					s.wait(char, step.Duration, result, spellEngine)
+					stats.attempted(true, castFailNone)
 					return true
 				default:
 					continue
@@ -364,7 +647,9 @@ func (s *Simulator) executeRotation(char *character.Character, result *Simulatio
 			if action.Duration <= 0 {
 				continue
 			}
+			s.tracePick(char, action)
 			s.wait(char, action.Duration, result, spellEngine)
+			stats.attempted(true, castFailNone)
 			return true
 		default:
 			// use_item not implemented yet
@@ -373,3 +658,198 @@ func (s *Simulator) executeRotation(char *character.Character, result *Simulatio
 	}
 	return false
 }
+
+// executeSequence runs the next step of a sequence. Steps whose when fails are
+// skipped. Once the first step has been cast, a step that cannot be cast yet
+// holds the rotation until it can (or reset_when rewinds the sequence).
+func (s *Simulator) executeSequence(ctx *rotationContext, action *apl.Action, result *SimulationResult, spellEngine *spells.Engine) bool {
+	char := ctx.char
+	idx := s.sequences[action]
+	if idx >= len(action.Steps) {
+		if action.Reset != nil {
+			return false
+		}
+		idx = 0
+	}
+	defer func() { s.sequences[action] = idx }()
+	for idx < len(action.Steps) {
+		step := action.Steps[idx]
+		if step.Condition != nil && !step.Program.Eval(ctx) {
+			idx++
+			continue
+		}
+		if step.Type.IsVariableAction() {
+			s.applyVariableAction(char, step)
+			idx++
+			continue
+		}
+		switch step.Type {
+		case apl.ActionCastSpell:
+			spell, ok := ctx.spellFor(step)
+			if ok && s.castAction(ctx, step, spell, result, spellEngine) {
+				idx++
+				return true
+			}
+			if idx == 0 {
+				return false
+			}
+			s.wait(char, sequenceRetryInterval, result, spellEngine)
+			return true
+		case apl.ActionWait:
+			idx++
+			s.wait(char, step.Duration, result, spellEngine)
+			return true
+		case apl.ActionWaitUntil:
+			idx++
+			if s.waitUntil(ctx, step, result, spellEngine) {
+				return true
+			}
+		case apl.ActionCancelBuff:
+			s.cancelBuff(ctx, step.Buff)
+			idx++
+		default:
+			idx++
+		}
+	}
+	return false
+}
+
+// waitUntil idles until the action's until condition holds or its timeout
+// expires, and reports whether any time passed.
+func (s *Simulator) waitUntil(ctx *rotationContext, action *apl.Action, result *SimulationResult, spellEngine *spells.Engine) bool {
+	char := ctx.char
+	if action.Until == nil || action.UntilProgram.Eval(ctx) {
+		return false
+	}
+	deadline := char.CurrentTime + action.Duration
+	if deadline > s.SimConfig.Duration {
+		deadline = s.SimConfig.Duration
+	}
+	waited := false
+	for char.CurrentTime < deadline {
+		step := deadline - char.CurrentTime
+		if step > waitUntilPollInterval {
+			step = waitUntilPollInterval
+		}
+		s.wait(char, step, result, spellEngine)
+		waited = true
+		if action.UntilProgram.Eval(ctx) {
+			break
+		}
+	}
+	return waited
+}
+
+// executePrecombat casts the precombat list once, in order, before the pull.
+// Each cast is timed to land at time zero, so only the part of the last
+// cast's GCD that extends past the pull is spent in combat.
+func (s *Simulator) executePrecombat(char *character.Character, result *SimulationResult, spellEngine *spells.Engine) {
+	if s.Rotation == nil || len(s.Rotation.Precombat) == 0 {
+		return
+	}
+	ctx := &s.rotCtx
+	s.precombat = true
+	s.precombatGCD = 0
+	s.traceNotes = s.traceNotes[:0]
+	s.traceSkipped = 0
+	for _, action := range s.Rotation.Precombat {
+		if action == nil {
+			continue
+		}
+		stats := s.statsFor(result, action)
+		if action.Condition != nil && !action.Program.Eval(ctx) {
+			stats.evaluated(false)
+			if s.tracing() {
+				s.traceSkip(action, "%s", apl.Explain(action.Condition, ctx))
+			}
+			continue
+		}
+		stats.evaluated(true)
+		if action.Type.IsVariableAction() {
+			s.applyVariableAction(char, action)
+			stats.attempted(true, castFailNone)
+			continue
+		}
+		if action.Type != apl.ActionCastSpell {
+			continue
+		}
+		spell, ok := ctx.spellFor(action)
+		if !ok {
+			continue
+		}
+		s.tracePick(char, action)
+		cast := s.tryCast(char, spell, result, spellEngine)
+		stats.attempted(cast, s.castFailure)
+		if !cast {
+			s.traceSkip(action, "cast failed (%s)", s.castFailure)
+		}
+	}
+	s.precombat = false
+	if s.precombatGCD > 0 {
+		char.GCD.Reset(char.CurrentTime, s.precombatGCD)
+	}
+}
+
+// resetRotationState restores runtime APL variables to their declared values
+// and rewinds every sequence.
+func (s *Simulator) resetRotationState() {
+	s.sequences = map[*apl.Action]int{}
+	if s.Rotation == nil {
+		s.variables = nil
+		return
+	}
+	if s.variables == nil {
+		s.variables = make(map[string]float64, len(s.Rotation.RuntimeVariables))
+	}
+	for name := range s.variables {
+		delete(s.variables, name)
+	}
+	for name, val := range s.Rotation.RuntimeVariables {
+		s.variables[name] = val
+	}
+}
+
+func (s *Simulator) applyVariableAction(char *character.Character, action *apl.Action) {
+	if s.variables == nil {
+		s.variables = map[string]float64{}
+	}
+	prev := s.variables[action.Variable]
+	switch action.Type {
+	case apl.ActionSetVariable:
+		s.variables[action.Variable] = action.Value
+	case apl.ActionIncrementVariable:
+		s.variables[action.Variable] += action.Value
+	case apl.ActionResetVariable:
+		s.variables[action.Variable] = s.Rotation.RuntimeVariables[action.Variable]
+	}
+	if s.LogEnabled && s.variables[action.Variable] != prev {
+		s.logf(char, "VARIABLE %s = %g", action.Variable, s.variables[action.Variable])
+	}
+}
+
+// APLSupport reports which APL identifiers the engine actually implements, so
+// static analysis can flag names that compile but are never tracked.
+func APLSupport() apl.Support {
+	support := apl.Support{
+		Spells:    map[string]struct{}{},
+		Buffs:     map[string]struct{}{},
+		Debuffs:   map[string]struct{}{},
+		Resources: map[string]struct{}{"mana": {}, "pet_mana": {}},
+	}
+	for name := range apl.KnownSpells() {
+		if _, ok := spellFromName(name); ok {
+			support.Spells[name] = struct{}{}
+		}
+	}
+	for name := range apl.KnownBuffs() {
+		if _, ok := auraSources[name]; ok {
+			support.Buffs[name] = struct{}{}
+		}
+	}
+	for name := range apl.KnownDebuffs() {
+		if _, ok := auraSources[name]; ok {
+			support.Debuffs[name] = struct{}{}
+		}
+	}
+	return support
+}
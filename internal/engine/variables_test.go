@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"wotlk-destro-sim/internal/spells"
+)
+
+// A bare variable name in an expression must see the value variable actions
+// write, exactly like variable(name).
+func TestBareVariableReadsRuntimeValue(t *testing.T) {
+	for _, cond := range []string{"bursts >= 1", "variable(bursts) >= 1"} {
+		sim, char := testSimulator(t, `
+variables:
+  bursts: 0
+rotation:
+  - action: increment_variable
+    variable: bursts
+  - action: cast_spell
+    spell: shadow_bolt
+    when: "`+cond+`"
+`, 10*time.Second)
+		result := sim.runSingleIteration(char, 0)
+		if casts := result.SpellBreakdown[spells.SpellShadowBolt].Casts; casts == 0 {
+			t.Errorf("%s: no Shadow Bolt casts", cond)
+		}
+	}
+}
@@ -16,6 +16,8 @@ const (
 const (
 	RuneDestructionMastery = "destruction_mastery"
 	RuneCataclysmicBurst   = "cataclysmic_burst"
+	RuneDemonologyMastery  = "demonology_mastery"
+	RuneDemonicReoccurence = "demonic_reoccurence"
 
 	RuneInnerFlame         = "inner_flame"
 	RuneEndlessFlames      = "endless_flames"
@@ -32,6 +34,7 @@ const (
 	RuneTwilightReaper     = "twilight_reaper"
 	RuneCursedShadows      = "cursed_shadows"
 	RuneShadowSiphon       = "shadow_siphon"
+	RuneDemonicInfluence   = "demonic_influence"
 
 	RuneGlyphOfLifeTap     = "glyph_of_life_tap"
 	RuneGlyphOfConflagrate = "glyph_of_conflagrate"
@@ -46,6 +49,8 @@ const (
 var runeRarity = map[string]Rarity{
 	RuneDestructionMastery: RarityLegendary,
 	RuneCataclysmicBurst:   RarityLegendary,
+	RuneDemonologyMastery:  RarityLegendary,
+	RuneDemonicReoccurence: RarityLegendary,
 
 	RuneInnerFlame:         RarityEpic,
 	RuneEndlessFlames:      RarityEpic,
@@ -61,6 +66,7 @@ var runeRarity = map[string]Rarity{
 	RuneTwilightReaper:     RarityEpic,
 	RuneCursedShadows:      RarityEpic,
 	RuneShadowSiphon:       RarityEpic,
+	RuneDemonicInfluence:   RarityEpic,
 	RuneNightfall:          RarityRare,
 
 	RuneGlyphOfLifeTap:     RarityRare,
@@ -131,6 +137,11 @@ const (
 	CursedShadowsManaReduction            = 0.20
 	CursedShadowsDurationSec              = 12.0
 	ShadowSiphonDamageBonus               = 0.25
+
+	DemonologyMasteryPetDamageMultiplier = 1.10
+	DemonologyMasteryPetAttackSpeedBonus = 0.10
+	DemonicReoccurenceCooldownReduceSec  = 75.0
+	DemonicInfluencePetDamageMultiplier  = 1.20
 )
 
 // Normalize returns the canonical lowercase snake_case rune name.
@@ -0,0 +1,106 @@
+package schema
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"wotlk-destro-sim/internal/config"
+	"wotlk-destro-sim/internal/engine"
+	"wotlk-destro-sim/internal/runes"
+)
+
+// Player returns the JSON Schema of configs/player.yaml (config.Player). The
+// shape is read from the struct's yaml tags; enumerations come from the same
+// lists the loader and validator check against.
+func Player() map[string]any {
+	out := typeSchema(reflect.TypeOf(config.Player{}), "", playerOverrides())
+	out["$schema"] = Draft
+	out["title"] = "Player profile"
+	return out
+}
+
+// playerOverrides refines fields whose Go type is a plain string or list,
+// keyed by their dotted YAML path.
+func playerOverrides() map[string]map[string]any {
+	byRarity := map[runes.Rarity][]string{}
+	for name, rarity := range runes.KnownRunes() {
+		byRarity[rarity] = append(byRarity[rarity], name)
+	}
+	runeList := func(rarity runes.Rarity) map[string]any {
+		names := byRarity[rarity]
+		slices.Sort(names)
+		return map[string]any{
+			"type":        "array",
+			"uniqueItems": true,
+			"items":       map[string]any{"enum": names},
+		}
+	}
+	return map[string]map[string]any{
+		"pet.summon":       {"enum": append([]string{""}, engine.SupportedPets()...)},
+		"pet.mode":         {"enum": []string{"", config.PetModeActive, config.PetModeSacrificed}},
+		"self_buffs.armor": {"enum": []string{"", config.ArmorNone, config.ArmorFelArmor}},
+		"target.type": {
+			"type":        "string",
+			"description": "boss applies boss hit and level rules; anything else is an equal-level target.",
+		},
+		"rotation": {
+			"type":        "string",
+			"description": "File name under configs/rotations.",
+		},
+		"talents": {
+			"type":        "string",
+			"description": "Talent profile under configs (e.g. talents-demonology.yaml) layered over talents.yaml; empty uses talents.yaml as is.",
+		},
+		"mystic_enchants.equipped.legendary": runeList(runes.RarityLegendary),
+		"mystic_enchants.equipped.epic":      runeList(runes.RarityEpic),
+		"mystic_enchants.equipped.rare":      runeList(runes.RarityRare),
+	}
+}
+
+// typeSchema maps a config struct to a schema, following yaml tags and
+// skipping unexported or "-" fields.
+func typeSchema(t reflect.Type, path string, overrides map[string]map[string]any) map[string]any {
+	if o, ok := overrides[path]; ok {
+		return o
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			childPath := name
+			if path != "" {
+				childPath = path + "." + name
+			}
+			props[name] = typeSchema(field.Type, childPath, overrides)
+		}
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties":           props,
+		}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), path+"[]", overrides)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,200 @@
+// Package schema builds JSON Schema documents for the YAML files the
+// simulator reads, from the same registries the compiler and validators use.
+package schema
+
+import (
+	"slices"
+
+	"wotlk-destro-sim/internal/apl"
+)
+
+// Draft is the JSON Schema dialect of every document in this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// variableRef matches a ${name} reference to a rotation variable, which the
+// compiler substitutes before validating the field.
+var variableRef = map[string]any{"type": "string", "pattern": `^\$\{.+\}$`}
+
+// Rotation returns the JSON Schema of an APL rotation file (apl.File).
+func Rotation() map[string]any {
+	defs := map[string]any{
+		"variableRef": variableRef,
+		"spell":       nameDef("Spell", apl.KnownSpells()),
+		"buff":        nameDef("Buff", apl.KnownBuffs()),
+		"debuff":      nameDef("Debuff", apl.KnownDebuffs()),
+		"aura":        nameDef("Aura", apl.KnownAuras()),
+		"resource":    nameDef("Resource", apl.KnownResources()),
+		"pet_spell":   nameDef("Pet spell", apl.KnownPetSpells()),
+		"item":        withRef(map[string]any{"type": "string"}),
+		"list":        map[string]any{"type": "string", "description": "Name of an entry under action_lists."},
+		"variable":    map[string]any{"type": "string", "description": "Runtime variable declared under variables with a number or boolean."},
+		"seconds":     withRef(map[string]any{"type": "number", "description": "Seconds."}),
+		"number":      withRef(map[string]any{"type": "number"}),
+		"fraction":    withRef(map[string]any{"type": "number", "minimum": 0, "maximum": 1}),
+		"count":       withRef(map[string]any{"type": "integer"}),
+		"value":       withRef(map[string]any{"type": []string{"number", "boolean"}}),
+		"expression":  map[string]any{"type": "string", "minLength": 1},
+		"flag":        map[string]any{},
+		"condition": map[string]any{
+			"description": "A boolean, an expression string, a list (all must hold) or a single-key condition object.",
+			"anyOf": []any{
+				map[string]any{"type": "boolean"},
+				map[string]any{"$ref": "#/$defs/expression"},
+				map[string]any{"$ref": "#/$defs/conditions"},
+				map[string]any{"$ref": "#/$defs/conditionObject"},
+			},
+		},
+		"conditions":      map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/condition"}},
+		"conditionObject": conditionObject(),
+		"actions":         map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/action"}},
+		"action":          actionObject(),
+	}
+	return map[string]any{
+		"$schema":              Draft,
+		"title":                "Rotation (APL)",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"name":        map[string]any{"type": "string"},
+			"description": map[string]any{"type": "string"},
+			"imports":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"variables": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": []string{"number", "boolean", "string"}},
+			},
+			"rotation":     map[string]any{"$ref": "#/$defs/actions"},
+			"action_lists": map[string]any{"type": "object", "additionalProperties": map[string]any{"$ref": "#/$defs/actions"}},
+			"phases":       map[string]any{"type": "array", "items": phaseObject()},
+		},
+		"$defs": defs,
+	}
+}
+
+func conditionObject() map[string]any {
+	props := map[string]any{}
+	for _, spec := range apl.ConditionSpecs() {
+		var body map[string]any
+		if spec.Body != "" {
+			body = ref(spec.Body)
+		} else {
+			body = fieldsObject(spec.Fields)
+			if len(spec.AtLeastOne) > 0 {
+				body["anyOf"] = requireEach(spec.AtLeastOne)
+			}
+		}
+		body["description"] = spec.Doc
+		props[spec.Key] = body
+	}
+	return map[string]any{
+		"type":                 "object",
+		"minProperties":        1,
+		"maxProperties":        1,
+		"additionalProperties": false,
+		"properties":           props,
+	}
+}
+
+// phaseObject describes one entry under phases; it needs a marker or a when.
+func phaseObject() map[string]any {
+	return map[string]any{
+		"type":                 "object",
+		"required":             []string{"name"},
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"name":   map[string]any{"type": "string"},
+			"marker": map[string]any{"type": "string", "description": "Encounter phase marker from simulation.phases in the player profile."},
+			"when":   ref(apl.FieldCondition),
+			"list":   ref(apl.FieldList),
+		},
+		"anyOf": requireEach([]string{"marker", "when"}),
+	}
+}
+
+// actionObject accepts the union of every action's fields and requires the
+// fields of whichever action is named.
+func actionObject() map[string]any {
+	specs := apl.ActionSpecs()
+	names := make([]string, len(specs))
+	props := map[string]any{
+		"when": ref(apl.FieldCondition),
+		"tags": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	}
+	var rules []any
+	for i, spec := range specs {
+		names[i] = spec.Name
+		var required []string
+		for _, f := range spec.Fields {
+			if _, seen := props[f.Name]; !seen {
+				props[f.Name] = ref(f.Kind)
+			}
+			if f.Required {
+				required = append(required, f.Name)
+			}
+		}
+		if len(required) == 0 {
+			continue
+		}
+		rules = append(rules, map[string]any{
+			"if":   map[string]any{"properties": map[string]any{"action": map[string]any{"const": spec.Name}}},
+			"then": map[string]any{"required": required},
+		})
+	}
+	props["action"] = map[string]any{"enum": names}
+	return map[string]any{
+		"type":                 "object",
+		"required":             []string{"action"},
+		"additionalProperties": false,
+		"properties":           props,
+		"allOf":                rules,
+	}
+}
+
+func fieldsObject(fields []apl.Field) map[string]any {
+	props := map[string]any{}
+	var required []string
+	for _, f := range fields {
+		props[f.Name] = ref(f.Kind)
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+	obj := map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           props,
+	}
+	if len(required) > 0 {
+		obj["required"] = required
+	}
+	return obj
+}
+
+func requireEach(names []string) []any {
+	out := make([]any, len(names))
+	for i, name := range names {
+		out[i] = map[string]any{"required": []string{name}}
+	}
+	return out
+}
+
+func ref(kind apl.FieldKind) map[string]any {
+	return map[string]any{"$ref": "#/$defs/" + string(kind)}
+}
+
+// withRef also accepts a ${variable} reference in place of the value.
+func withRef(def map[string]any) map[string]any {
+	return map[string]any{"anyOf": []any{def, map[string]any{"$ref": "#/$defs/variableRef"}}}
+}
+
+func nameDef(title string, set map[string]struct{}) map[string]any {
+	return withRef(map[string]any{"title": title, "enum": sortedKeys(set)})
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -37,7 +37,7 @@ func (e *Engine) CastChaosBolt(char *character.Character) CastResult {
 	damage = e.applyFireTargetModifiers(damage, char)
 
 	forceCrit := e.consumeEmpoweredImp(char) || e.consumeInnerFlame(char)
-	if forceCrit || e.RollCrit(char, 0) {
+	if forceCrit || e.RollCrit(char, e.fireCritBonus()) {
 		result.DidCrit = true
 		damage *= e.Config.Talents.Ruin.CritMultiplier
 	}
@@ -41,7 +41,7 @@ func (e *Engine) CastConflagrate(char *character.Character) CastResult {
 	baseDamage *= e.Config.Talents.Emberstorm.DamageMultiplier
 	baseDamage = e.applyFireTargetModifiers(baseDamage, char)
 
-	bonusCrit := e.Config.Talents.FireAndBrimstone.ConflagrateCritBonus
+	bonusCrit := e.Config.Talents.FireAndBrimstone.ConflagrateCritBonus + e.fireCritBonus()
 	if e.consumeEmpoweredImp(char) || e.consumeInnerFlame(char) || e.RollCrit(char, bonusCrit) {
 		result.DidCrit = true
 		baseDamage *= e.Config.Talents.Ruin.CritMultiplier
@@ -32,6 +32,18 @@ const (
 	SpellCurseOfAgony
 	SpellShadowCrash
 	SpellImpFirebolt
+	SpellMetamorphosis
+	SpellDemonicEmpowerment
+	SpellImmolationAura
+	SpellPetMelee
+	SpellFelguardCleave
+	SpellFelhunterShadowBite
+	SpellSuccubusLashOfPain
+	SpellCurseOfDoom
+	SpellInferno
+	SpellInfernalMelee
+	SpellInfernalImmolation
+	SpellDoomguardDoomBolt
 )
 
 // CastResult represents the result of a spell cast.
@@ -54,6 +66,9 @@ type Engine struct {
 
 	// Target type for hit calculation
 	IsBossTarget bool
+
+	// FightDuration drives the linear target health model (100% at pull, 0% at the end).
+	FightDuration time.Duration
 }
 
 // NewEngine creates a new spell engine.
@@ -65,6 +80,22 @@ func NewEngine(cfg *config.Config, seed int64, isBoss bool) *Engine {
 	}
 }
 
+// TargetHealthPercent returns the target's remaining health as a fraction (0-1).
+// Health drains linearly over the fight; without a fight duration the target stays at full health.
+func (e *Engine) TargetHealthPercent(char *character.Character) float64 {
+	if e.FightDuration <= 0 || char == nil {
+		return 1
+	}
+	pct := 1 - float64(char.CurrentTime)/float64(e.FightDuration)
+	if pct < 0 {
+		return 0
+	}
+	if pct > 1 {
+		return 1
+	}
+	return pct
+}
+
 // RollHit determines if a spell hits.
 func (e *Engine) RollHit(char *character.Character) bool {
 	hitCap := float64(e.Config.Constants.HitMechanics.EqualLevelMissChance)
@@ -134,6 +165,7 @@ func (e *Engine) CalculateSpellDamage(baseDamage, spCoefficient float64, char *c
 	if e.Config.Player.HasRune(runes.RuneDestructionMastery) {
 		damage *= runes.DestructionMasteryGlobalBonus
 	}
+	damage *= e.metamorphosisMultiplier(char)
 	return damage
 }
 
@@ -166,6 +198,8 @@ func (e *Engine) fireTargetMultiplier(char *character.Character) float64 {
 	if char.CurseOfElements.Active && char.CurseOfElements.ExpiresAt > char.CurrentTime {
 		mult *= CurseOfElementsMultiplier
 	}
+	mult *= e.masterDemonologistMultiplier(schoolFire)
+	mult *= e.demonicSacrificeMultiplier(char, schoolFire)
 	return mult
 }
 
@@ -187,6 +221,8 @@ func (e *Engine) shadowTargetMultiplier(char *character.Character) float64 {
 	if char.CurseOfElements.Active && char.CurseOfElements.ExpiresAt > char.CurrentTime {
 		mult *= CurseOfElementsMultiplier
 	}
+	mult *= e.masterDemonologistMultiplier(schoolShadow)
+	mult *= e.demonicSacrificeMultiplier(char, schoolShadow)
 	return mult
 }
 
@@ -248,6 +284,10 @@ func (e *Engine) effectiveSpellPower(char *character.Character) float64 {
 	if e.Config.Player.HasRune(runes.RuneDemonicAegis) {
 		sp += char.Stats.Spirit * runes.DemonicAegisSpiritBonusPerPoint
 	}
+	if e.Config.Player.SelfBuffs.Armor == config.ArmorFelArmor {
+		felArmor := e.Config.Spells.FelArmor
+		sp += felArmor.SpellPower + char.Stats.Spirit*felArmor.SpiritToSpellPower
+	}
 	if bonus := e.Config.Talents.ShadowAndFlame.BonusSPPercentage; bonus > 0 {
 		sp *= 1 + bonus
 	}
@@ -259,6 +299,7 @@ func (e *Engine) effectiveSpellPower(char *character.Character) float64 {
 			char.LifeTapBuff.Value = 0
 		}
 	}
+	sp += e.demonicPactSpellPower(char)
 	return sp
 }
 
@@ -33,5 +33,8 @@ func (e *Engine) CastCurseOfAgony(char *character.Character) CastResult {
 	spSnapshot = e.applyShadowTargetModifiers(spSnapshot, char)
 
 	e.applyCurseOfAgonySnapshot(char, baseSnapshot, spSnapshot)
+	// Curse of Agony replaces a pending Curse of Doom.
+	char.CurseOfDoom.Active = false
+	char.CurseOfDoom.TicksRemaining = 0
 	return result
 }
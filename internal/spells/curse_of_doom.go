@@ -0,0 +1,52 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// CastCurseOfDoom applies Curse of Doom. The snapshot lands as a single hit when the curse expires.
+func (e *Engine) CastCurseOfDoom(char *character.Character) CastResult {
+	spellData := e.Config.Spells.CurseOfDoom
+
+	result := CastResult{
+		Spell:     SpellCurseOfDoom,
+		CastTime:  0,
+		GCDTime:   time.Duration(e.Config.Constants.GCD.Base * float64(time.Second)),
+		ManaSpent: spellData.ManaCost,
+	}
+
+	e.applyBackdraft(char, &result, true)
+	char.SpendMana(spellData.ManaCost)
+
+	if spellData.Cooldown > 0 {
+		char.CurseOfDoomCooldown.ReadyAt = char.CurrentTime + time.Duration(spellData.Cooldown*float64(time.Second))
+	}
+
+	if !e.RollHit(char) {
+		result.DidHit = false
+		return result
+	}
+	result.DidHit = true
+
+	damage := e.CalculateSpellDamage(spellData.Damage, spellData.SPCoefficient, char)
+	damage = e.applyShadowTargetModifiers(damage, char)
+
+	duration := time.Duration(spellData.Duration * float64(time.Second))
+	char.CurseOfDoom.Active = true
+	char.CurseOfDoom.ExpiresAt = char.CurrentTime + duration
+	char.CurseOfDoom.TickInterval = duration
+	char.CurseOfDoom.LastTick = char.CurrentTime
+	char.CurseOfDoom.TickDamage = damage
+	char.CurseOfDoom.TickCritChance = 0
+	char.CurseOfDoom.TicksRemaining = 1
+	char.CurseOfDoom.TotalTicks = 1
+	char.CurseOfDoom.SnapshotDotDamage = damage
+
+	// A warlock can only keep one curse of their own on the target.
+	char.CurseOfAgony.Active = false
+	char.CurseOfAgony.TicksRemaining = 0
+
+	return result
+}
@@ -0,0 +1,38 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// CastDemonicEmpowerment empowers the active demon. The pet controller reads the buff.
+func (e *Engine) CastDemonicEmpowerment(char *character.Character) CastResult {
+	talent := e.Config.Talents.DemonicEmpowerment
+
+	result := CastResult{
+		Spell:     SpellDemonicEmpowerment,
+		CastTime:  0,
+		GCDTime:   time.Duration(e.Config.Constants.GCD.Base * float64(time.Second)),
+		ManaSpent: talent.ManaCost,
+		DidHit:    true,
+	}
+
+	e.applyHasteTimes(char, &result)
+	char.SpendMana(talent.ManaCost)
+
+	duration := 0.0
+	switch e.SummonedPet() {
+	case "imp":
+		duration = talent.ImpDuration
+	case "felguard":
+		duration = talent.FelguardDuration
+	}
+	if duration > 0 {
+		char.DemonicEmpowerment.Active = true
+		char.DemonicEmpowerment.ExpiresAt = char.CurrentTime + time.Duration(duration*float64(time.Second))
+	}
+	char.DemonicEmpowermentCooldown.ReadyAt = char.CurrentTime + time.Duration(talent.Cooldown*float64(time.Second))
+
+	return result
+}
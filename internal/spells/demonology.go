@@ -0,0 +1,220 @@
+package spells
+
+import (
+	"strings"
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// decimationSpellKeys maps spells to the identifiers used by talents.yaml trigger lists.
+var decimationSpellKeys = map[SpellType]string{
+	SpellShadowBolt: "shadow_bolt",
+	SpellIncinerate: "incinerate",
+	SpellSoulFire:   "soul_fire",
+}
+
+// SummonedPet returns the normalized pet name from the player config ("" when no demon is out).
+func (e *Engine) SummonedPet() string {
+	if e.Config.Player.PetSacrificed() {
+		return ""
+	}
+	return e.configuredPet()
+}
+
+// SacrificedPet returns the demon consumed by Demonic Sacrifice ("" when none was sacrificed).
+func (e *Engine) SacrificedPet() string {
+	if !e.Config.Player.PetSacrificed() || e.Config.Talents.DemonicSacrifice.Points <= 0 {
+		return ""
+	}
+	return e.configuredPet()
+}
+
+func (e *Engine) configuredPet() string {
+	summon := strings.ToLower(strings.TrimSpace(e.Config.Player.Pet.Summon))
+	if summon == "none" {
+		return ""
+	}
+	return summon
+}
+
+// demonicSacrificeMultiplier returns the school damage bonus from the sacrificed demon.
+func (e *Engine) demonicSacrificeMultiplier(char *character.Character, school spellSchool) float64 {
+	if !char.DemonicSacrifice.Active {
+		return 1
+	}
+	ds := e.Config.Talents.DemonicSacrifice
+	switch e.SacrificedPet() {
+	case "imp":
+		if school == schoolFire {
+			return 1 + ds.Imp.FireDamageBonus
+		}
+	case "succubus":
+		if school == schoolShadow {
+			return 1 + ds.Succubus.ShadowDamageBonus
+		}
+	case "felguard":
+		if school == schoolShadow {
+			return 1 + ds.Felguard.ShadowDamageBonus
+		}
+	}
+	return 1
+}
+
+type spellSchool int
+
+const (
+	schoolFire spellSchool = iota
+	schoolShadow
+)
+
+// masterDemonologistMultiplier returns the warlock damage bonus granted by the active demon.
+func (e *Engine) masterDemonologistMultiplier(school spellSchool) float64 {
+	md := e.Config.Talents.MasterDemonologist
+	if md.Points <= 0 {
+		return 1
+	}
+	points := float64(md.Points)
+	switch e.SummonedPet() {
+	case "imp":
+		if school == schoolFire {
+			return 1 + points*md.Imp.FireDamagePerPoint
+		}
+	case "felguard":
+		return 1 + points*md.Felguard.DamagePerPoint
+	case "succubus":
+		if school == schoolShadow {
+			return 1 + points*md.Succubus.ShadowDamagePerPoint
+		}
+	}
+	return 1
+}
+
+// masterDemonologistCrit returns the warlock crit bonus (0-1) granted by the active demon.
+func (e *Engine) masterDemonologistCrit(school spellSchool) float64 {
+	md := e.Config.Talents.MasterDemonologist
+	if md.Points <= 0 {
+		return 0
+	}
+	points := float64(md.Points)
+	switch e.SummonedPet() {
+	case "imp":
+		if school == schoolFire {
+			return points * md.Imp.FireCritPerPoint
+		}
+	case "felguard":
+		return points * md.Felguard.CritPerPoint
+	case "succubus":
+		if school == schoolShadow {
+			return points * md.Succubus.ShadowCritPerPoint
+		}
+	}
+	return 0
+}
+
+// fireCritBonus returns extra crit chance (0-1) for fire spells.
+func (e *Engine) fireCritBonus() float64 {
+	return e.masterDemonologistCrit(schoolFire)
+}
+
+// shadowCritBonus returns extra crit chance (0-1) for shadow spells.
+func (e *Engine) shadowCritBonus() float64 {
+	return e.masterDemonologistCrit(schoolShadow)
+}
+
+func (e *Engine) metamorphosisActive(char *character.Character) bool {
+	return e.Config.Talents.Metamorphosis.Points > 0 &&
+		char.Metamorphosis.Active && char.Metamorphosis.ExpiresAt > char.CurrentTime
+}
+
+func (e *Engine) metamorphosisMultiplier(char *character.Character) float64 {
+	if !e.metamorphosisActive(char) {
+		return 1
+	}
+	if mult := e.Config.Talents.Metamorphosis.DamageMultiplier; mult > 0 {
+		return mult
+	}
+	return 1
+}
+
+func (e *Engine) demonicPactSpellPower(char *character.Character) float64 {
+	if !char.DemonicPact.Active {
+		return 0
+	}
+	if char.DemonicPact.ExpiresAt <= char.CurrentTime {
+		char.DemonicPact.Active = false
+		char.DemonicPact.Value = 0
+		return 0
+	}
+	return char.DemonicPact.Value
+}
+
+func (e *Engine) moltenCoreActive(char *character.Character) bool {
+	if e.Config.Talents.MoltenCore.Points <= 0 {
+		return false
+	}
+	return char.MoltenCore.Active && char.MoltenCore.Charges > 0 && char.MoltenCore.ExpiresAt > char.CurrentTime
+}
+
+// consumeMoltenCore spends one charge and reports whether the cast is empowered.
+func (e *Engine) consumeMoltenCore(char *character.Character) bool {
+	if !e.moltenCoreActive(char) {
+		return false
+	}
+	char.MoltenCore.Charges--
+	if char.MoltenCore.Charges <= 0 {
+		char.MoltenCore.Charges = 0
+		char.MoltenCore.Active = false
+		char.MoltenCore.ExpiresAt = char.CurrentTime
+	}
+	return true
+}
+
+func (e *Engine) moltenCoreDamageMultiplier() float64 {
+	mc := e.Config.Talents.MoltenCore
+	return 1 + float64(mc.Points)*mc.DamagePerPoint
+}
+
+func (e *Engine) decimationActive(char *character.Character) bool {
+	return e.Config.Talents.Decimation.Points > 0 &&
+		char.Decimation.Active && char.Decimation.ExpiresAt > char.CurrentTime
+}
+
+func (e *Engine) decimationCastMultiplier(char *character.Character) float64 {
+	if !e.decimationActive(char) {
+		return 1
+	}
+	dec := e.Config.Talents.Decimation
+	mult := 1 - float64(dec.Points)*dec.SoulFireCastReductionPerPoint
+	if mult < 0 {
+		return 0
+	}
+	return mult
+}
+
+// tryDecimation grants Decimation when a trigger spell lands on a target in execute range.
+func (e *Engine) tryDecimation(char *character.Character, spell SpellType) {
+	dec := e.Config.Talents.Decimation
+	if dec.Points <= 0 {
+		return
+	}
+	if e.TargetHealthPercent(char) >= dec.ExecuteThreshold {
+		return
+	}
+	key, ok := decimationSpellKeys[spell]
+	if !ok {
+		return
+	}
+	triggered := false
+	for _, name := range dec.TriggerSpells {
+		if strings.EqualFold(strings.TrimSpace(name), key) {
+			triggered = true
+			break
+		}
+	}
+	if !triggered {
+		return
+	}
+	char.Decimation.Active = true
+	char.Decimation.ExpiresAt = char.CurrentTime + time.Duration(dec.Duration*float64(time.Second))
+}
@@ -55,7 +55,7 @@ func (e *Engine) CastImmolate(char *character.Character) CastResult {
 		directDamage *= runes.AgentOfChaosDirectDamagePenalty
 	}
 
-	directCrit := forceCrit || e.RollCrit(char, 0)
+	directCrit := forceCrit || e.RollCrit(char, e.fireCritBonus())
 	if directCrit {
 		directDamage *= e.Config.Talents.Ruin.CritMultiplier
 	}
@@ -80,7 +80,7 @@ func (e *Engine) CastImmolate(char *character.Character) CastResult {
 	} else {
 		baseTickDamage = dotSnapshot
 	}
-	tickCritChance := e.snapshotCritChance(char, 0)
+	tickCritChance := e.snapshotCritChance(char, e.fireCritBonus())
 
 	result.DidCrit = directCrit
 	result.Damage = directDamage
@@ -0,0 +1,45 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// CastImmolationAura starts the Metamorphosis-only fire aura. The engine schedules its ticks.
+func (e *Engine) CastImmolationAura(char *character.Character) CastResult {
+	spellData := e.Config.Spells.ImmolationAura
+
+	result := CastResult{
+		Spell:     SpellImmolationAura,
+		CastTime:  0,
+		GCDTime:   time.Duration(e.Config.Constants.GCD.Base * float64(time.Second)),
+		ManaSpent: spellData.ManaCost,
+		DidHit:    true,
+	}
+
+	e.applyHasteTimes(char, &result)
+	char.SpendMana(spellData.ManaCost)
+
+	tickCount := spellData.Ticks
+	if tickCount <= 0 {
+		tickCount = 1
+	}
+	tickDamage := e.CalculateSpellDamage(spellData.TickDamage, spellData.SPCoefficientTick, char)
+	tickDamage = e.applyFireTargetModifiers(tickDamage, char)
+
+	char.ImmolationAura.Active = true
+	char.ImmolationAura.ExpiresAt = char.CurrentTime + time.Duration(spellData.Duration*float64(time.Second))
+	char.ImmolationAura.TickInterval = time.Duration((spellData.Duration / float64(tickCount)) * float64(time.Second))
+	char.ImmolationAura.LastTick = char.CurrentTime
+	char.ImmolationAura.TickDamage = tickDamage
+	char.ImmolationAura.BaseTickDamage = tickDamage
+	char.ImmolationAura.TickCritChance = e.snapshotCritChance(char, e.fireCritBonus())
+	char.ImmolationAura.TicksRemaining = tickCount
+	char.ImmolationAura.TotalTicks = tickCount
+	char.ImmolationAura.SnapshotDotDamage = tickDamage * float64(tickCount)
+
+	char.ImmolationAuraCooldown.ReadyAt = char.CurrentTime + time.Duration(spellData.Cooldown*float64(time.Second))
+
+	return result
+}
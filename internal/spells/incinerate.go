@@ -18,6 +18,12 @@ func (e *Engine) CastIncinerate(char *character.Character) CastResult {
 		ManaSpent: spellData.ManaCost,
 	}
 
+	moltenCore := e.consumeMoltenCore(char)
+	if moltenCore {
+		mc := e.Config.Talents.MoltenCore
+		result.CastTime = time.Duration(float64(result.CastTime) * (1 - float64(mc.Points)*mc.IncinerateCastReductionPerPoint))
+	}
+
 	e.applyHasteTimes(char, &result)
 	e.applyBackdraft(char, &result, true)
 	char.SpendMana(spellData.ManaCost)
@@ -38,9 +44,12 @@ func (e *Engine) CastIncinerate(char *character.Character) CastResult {
 	damage := e.CalculateSpellDamage(baseDamage, spellData.SPCoefficient, char)
 	damage = e.ApplyFireAndBrimstone(damage, char, SpellIncinerate)
 	damage = e.applyFireTargetModifiers(damage, char)
+	if moltenCore {
+		damage *= e.moltenCoreDamageMultiplier()
+	}
 
 	forceCrit := e.consumeEmpoweredImp(char) || e.consumeInnerFlame(char)
-	if forceCrit || e.RollCrit(char, 0) {
+	if forceCrit || e.RollCrit(char, e.fireCritBonus()) {
 		result.DidCrit = true
 		damage *= e.Config.Talents.Ruin.CritMultiplier
 	}
@@ -56,6 +65,7 @@ func (e *Engine) CastIncinerate(char *character.Character) CastResult {
 		e.CheckSoulLeechProc(char)
 		e.tryProcInnerFlame(char)
 		e.addDuskTillDawnStack(char)
+		e.tryDecimation(char, SpellIncinerate)
 	}
 
 	return result
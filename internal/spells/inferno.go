@@ -0,0 +1,49 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+)
+
+// CastInferno calls down an Infernal. The landing damage is returned; the engine summons the guardian.
+func (e *Engine) CastInferno(char *character.Character) CastResult {
+	spellData := e.Config.Spells.Inferno
+
+	result := CastResult{
+		Spell:     SpellInferno,
+		CastTime:  time.Duration(spellData.CastTime * float64(time.Second)),
+		GCDTime:   time.Duration(e.Config.Constants.GCD.Base * float64(time.Second)),
+		ManaSpent: spellData.ManaCost,
+	}
+
+	e.applyHasteTimes(char, &result)
+	char.SpendMana(spellData.ManaCost)
+
+	if spellData.Cooldown > 0 {
+		char.InfernoCooldown.ReadyAt = char.CurrentTime + time.Duration(spellData.Cooldown*float64(time.Second))
+	}
+
+	if !e.RollHit(char) {
+		result.DidHit = false
+		return result
+	}
+	result.DidHit = true
+
+	baseDamage := spellData.BaseDamageMin + e.Rng.Float64()*(spellData.BaseDamageMax-spellData.BaseDamageMin)
+	damage := e.CalculateSpellDamage(baseDamage, spellData.SPCoefficient, char)
+	damage = e.applyFireTargetModifiers(damage, char)
+
+	if e.RollCrit(char, e.fireCritBonus()) {
+		result.DidCrit = true
+		damage *= e.Config.Talents.Ruin.CritMultiplier
+	}
+	result.Damage = damage
+
+	return result
+}
+
+// GuardianSnapshot returns the warlock's spell power and crit chance (0-1) copied by a guardian at summon.
+func (e *Engine) GuardianSnapshot(char *character.Character) (spellPower, critChance float64) {
+	return e.effectiveSpellPower(char), e.snapshotCritChance(char, 0)
+}
@@ -0,0 +1,32 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/runes"
+)
+
+// CastMetamorphosis transforms the warlock into a demon (off the GCD).
+func (e *Engine) CastMetamorphosis(char *character.Character) CastResult {
+	talent := e.Config.Talents.Metamorphosis
+
+	result := CastResult{
+		Spell:  SpellMetamorphosis,
+		DidHit: true,
+	}
+
+	char.Metamorphosis.Active = true
+	char.Metamorphosis.ExpiresAt = char.CurrentTime + time.Duration(talent.Duration*float64(time.Second))
+
+	cooldown := talent.Cooldown
+	if e.Config.Player.HasRune(runes.RuneDemonicReoccurence) {
+		cooldown -= runes.DemonicReoccurenceCooldownReduceSec
+		if cooldown < 0 {
+			cooldown = 0
+		}
+	}
+	char.MetamorphosisCooldown.ReadyAt = char.CurrentTime + time.Duration(cooldown*float64(time.Second))
+
+	return result
+}
@@ -0,0 +1,91 @@
+package spells
+
+import (
+	"time"
+
+	"wotlk-destro-sim/internal/character"
+	"wotlk-destro-sim/internal/runes"
+)
+
+// PreviewCastTime returns the cast time the spell would have if cast right now,
+// including haste, Backdraft, Shadow Trance, Molten Core, Decimation and
+// Decisive Decimation. Unlike the Cast* functions it does not consume charges
+// or otherwise touch character state. Instant spells report 0.
+func (e *Engine) PreviewCastTime(char *character.Character, spell SpellType) time.Duration {
+	if char == nil {
+		return 0
+	}
+	spellsCfg := e.Config.Spells
+	var base float64
+	backdraft := true
+	switch spell {
+	case SpellImmolate:
+		base = spellsCfg.Immolate.CastTime
+	case SpellIncinerate:
+		base = spellsCfg.Incinerate.CastTime
+		if e.moltenCoreActive(char) {
+			mc := e.Config.Talents.MoltenCore
+			base *= 1 - float64(mc.Points)*mc.IncinerateCastReductionPerPoint
+		}
+	case SpellChaosBolt:
+		base = spellsCfg.ChaosBolt.CastTime
+	case SpellShadowBolt:
+		if char.ShadowTrance.Active && char.ShadowTrance.ExpiresAt > char.CurrentTime {
+			return 0
+		}
+		base = spellsCfg.ShadowBolt.CastTime
+	case SpellShadowburn:
+		base = spellsCfg.Shadowburn.CastTime
+	case SpellShadowCrash:
+		base = spellsCfg.ShadowCrash.CastTime
+	case SpellSoulFire:
+		base = spellsCfg.SoulFire.CastTime
+		if char.DecisiveDecimation.Active {
+			base *= 1 - runes.DecisiveDecimationCastReduction
+		}
+		base *= e.decimationCastMultiplier(char)
+	case SpellShadowfury:
+		base = spellsCfg.ShadowFury.CastTime
+		backdraft = false
+	case SpellInferno:
+		base = spellsCfg.Inferno.CastTime
+		backdraft = false
+	default:
+		return 0
+	}
+	if base <= 0 {
+		return 0
+	}
+
+	result := CastResult{CastTime: time.Duration(base * float64(time.Second))}
+	e.applyHasteTimes(char, &result)
+	if backdraft && e.backdraftEnabled() && char.Backdraft.Active && char.Backdraft.Charges > 0 &&
+		char.CurrentTime < char.Backdraft.ExpiresAt {
+		if reduction := e.Config.Talents.Backdraft.CastTimeReduction; reduction > 0 {
+			result.CastTime = time.Duration(float64(result.CastTime) * (1.0 - reduction))
+		}
+	}
+	return result.CastTime
+}
+
+// PreviewGCD returns the global cooldown the spell would trigger if cast right
+// now, including haste and Backdraft. Like PreviewCastTime it does not touch
+// character state.
+func (e *Engine) PreviewGCD(char *character.Character, spell SpellType) time.Duration {
+	if char == nil {
+		return 0
+	}
+	result := CastResult{GCDTime: time.Duration(e.Config.Constants.GCD.Base * float64(time.Second))}
+	e.applyHasteTimes(char, &result)
+	if spell != SpellShadowfury && spell != SpellInferno && e.backdraftEnabled() && char.Backdraft.Active &&
+		char.Backdraft.Charges > 0 && char.CurrentTime < char.Backdraft.ExpiresAt {
+		if reduction := e.Config.Talents.Backdraft.GCDReduction; reduction > 0 {
+			result.GCDTime = time.Duration(float64(result.GCDTime) * (1.0 - reduction))
+			minGCD := time.Duration(e.Config.Constants.GCD.Minimum * float64(time.Second))
+			if minGCD > 0 && result.GCDTime < minGCD {
+				result.GCDTime = minGCD
+			}
+		}
+	}
+	return result.GCDTime
+}
@@ -58,7 +58,7 @@ func (e *Engine) CastShadowBolt(char *character.Character) CastResult {
 	damage = e.applyShadowTargetModifiers(damage, char)
 	damage *= e.pureShadowMultiplier(char, SpellShadowBolt)
 
-	bonusCrit := 0.0
+	bonusCrit := e.shadowCritBonus()
 	if e.Config.Player.HasRune(runes.RunePyroclasmicShadows) && char.Pyroclasm.Active && char.CurrentTime < char.Pyroclasm.ExpiresAt {
 		bonusCrit += runes.PyroclasmicShadowsShadowboltCritBonus
 	}
@@ -75,6 +75,7 @@ func (e *Engine) CastShadowBolt(char *character.Character) CastResult {
 	// Procs and stacks
 	e.addPureShadowStack(char)
 	e.addDuskTillDawnStack(char)
+	e.tryDecimation(char, SpellShadowBolt)
 	if hasShadowTrance {
 		char.ShadowTrance.Active = false
 		char.ShadowTrance.Charges = 0
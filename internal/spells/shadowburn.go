@@ -51,7 +51,7 @@ func (e *Engine) CastShadowburn(char *character.Character) CastResult {
 		}
 	}
 
-	if e.RollCrit(char, 0) {
+	if e.RollCrit(char, e.shadowCritBonus()) {
 		result.DidCrit = true
 		damage *= e.Config.Talents.Ruin.CritMultiplier
 	}
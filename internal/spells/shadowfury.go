@@ -36,7 +36,7 @@ func (e *Engine) CastShadowfury(char *character.Character) CastResult {
 	damage = e.applyShadowTargetModifiers(damage, char)
 	damage *= e.pureShadowMultiplier(char, SpellShadowfury)
 
-	if e.RollCrit(char, 0) {
+	if e.RollCrit(char, e.shadowCritBonus()) {
 		result.DidCrit = true
 		damage *= e.Config.Talents.Ruin.CritMultiplier
 	}
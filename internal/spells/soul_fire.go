@@ -7,7 +7,7 @@ import (
 	"wotlk-destro-sim/internal/runes"
 )
 
-// CastSoulFire casts Soul Fire. Only meaningful when Decisive Decimation is active.
+// CastSoulFire casts Soul Fire. Decisive Decimation, Decimation and Molten Core shorten or empower it.
 func (e *Engine) CastSoulFire(char *character.Character) CastResult {
 	spellData := e.Config.Spells.SoulFire
 
@@ -26,6 +26,9 @@ func (e *Engine) CastSoulFire(char *character.Character) CastResult {
 		char.DecisiveDecimation.Active = false
 	}
 
+	castTime = time.Duration(float64(castTime) * e.decimationCastMultiplier(char))
+	moltenCore := e.consumeMoltenCore(char)
+
 	result.CastTime = castTime
 	result.GCDTime = gcd
 	result.ManaSpent = manaCost
@@ -49,7 +52,14 @@ func (e *Engine) CastSoulFire(char *character.Character) CastResult {
 	damage := e.CalculateSpellDamage(base, spellData.SPCoefficient, char)
 	damage = e.applyFireTargetModifiers(damage, char)
 
-	if e.consumeEmpoweredImp(char) || e.consumeInnerFlame(char) || e.RollCrit(char, 0) {
+	bonusCrit := e.fireCritBonus()
+	if moltenCore {
+		mc := e.Config.Talents.MoltenCore
+		damage *= e.moltenCoreDamageMultiplier()
+		bonusCrit += float64(mc.Points) * mc.SoulFireCritPerPoint
+	}
+
+	if e.consumeEmpoweredImp(char) || e.consumeInnerFlame(char) || e.RollCrit(char, bonusCrit) {
 		result.DidCrit = true
 		damage *= e.Config.Talents.Ruin.CritMultiplier
 	}
@@ -57,6 +67,7 @@ func (e *Engine) CastSoulFire(char *character.Character) CastResult {
 	result.Damage = damage
 	e.tryProcInnerFlame(char)
 	e.addDuskTillDawnStack(char)
+	e.tryDecimation(char, SpellSoulFire)
 
 	return result
 }
@@ -0,0 +1,160 @@
+// Package ttest holds the Student-t helpers shared by the comparison tools:
+// paired differences, two-sided p-values and confidence intervals.
+package ttest
+
+import "math"
+
+// Diff summarizes the per-seed difference between two paired samples.
+type Diff struct {
+	N      int
+	Mean   float64
+	Stderr float64
+	P      float64
+}
+
+// Paired compares other against baseline element by element over their
+// common length.
+func Paired(baseline, other []float64) Diff {
+	n := len(baseline)
+	if len(other) < n {
+		n = len(other)
+	}
+	diffs := make([]float64, n)
+	for i := 0; i < n; i++ {
+		diffs[i] = other[i] - baseline[i]
+	}
+	mean, se := MeanStderr(diffs)
+	d := Diff{N: n, Mean: mean, Stderr: se, P: 1}
+	if se > 0 {
+		d.P = TwoSidedP(mean/se, float64(n-1))
+	} else if mean != 0 {
+		d.P = 0
+	}
+	return d
+}
+
+// MeanStderr returns the sample mean and the standard error of the mean.
+func MeanStderr(samples []float64) (float64, float64) {
+	n := float64(len(samples))
+	if n == 0 {
+		return 0, 0
+	}
+	var total float64
+	for _, v := range samples {
+		total += v
+	}
+	mean := total / n
+	if n < 2 {
+		return mean, 0
+	}
+	var ss float64
+	for _, v := range samples {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss/(n-1)) / math.Sqrt(n)
+}
+
+// HalfWidth returns the half-width of the two-sided confidence interval at
+// level (e.g. 0.95) for the mean of samples; 0 with fewer than two samples.
+func HalfWidth(samples []float64, level float64) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	_, se := MeanStderr(samples)
+	return Critical(level, float64(len(samples)-1)) * se
+}
+
+// TwoSidedP returns P(|T| >= |t|) for a Student-t with df degrees of
+// freedom, via the regularized incomplete beta function.
+func TwoSidedP(t, df float64) float64 {
+	return regIncBeta(df/2, 0.5, df/(df+t*t))
+}
+
+// Critical returns the two-sided Student-t quantile for a confidence level,
+// i.e. the t with TwoSidedP(t, df) == 1-level, found by bisection.
+func Critical(level, df float64) float64 {
+	alpha := 1 - level
+	if alpha <= 0 || df <= 0 {
+		return math.Inf(1)
+	}
+	if alpha >= 1 {
+		return 0
+	}
+	lo, hi := 0.0, 1.0
+	for TwoSidedP(hi, df) > alpha {
+		lo, hi = hi, hi*2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if TwoSidedP(mid, df) > alpha {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// regIncBeta evaluates I_x(a, b) with the continued fraction from Numerical
+// Recipes (betacf), using the symmetry relation for faster convergence.
+func regIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1 - front*betaContinuedFraction(b, a, 1-x)/b
+}
+
+func betaContinuedFraction(a, b, x float64) float64 {
+	const (
+		maxIter = 300
+		eps     = 1e-14
+		tiny    = 1e-300
+	)
+	c := 1.0
+	d := 1 - (a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIter; m++ {
+		fm := float64(m)
+		aa := fm * (b - fm) * x / ((a + 2*fm - 1) * (a + 2*fm))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (a + b + fm) * x / ((a + 2*fm) * (a + 2*fm + 1))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < eps {
+			break
+		}
+	}
+	return h
+}
@@ -0,0 +1,63 @@
+package ttest
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCriticalMatchesTables(t *testing.T) {
+	// Two-sided 95% quantiles from standard t tables.
+	tests := []struct {
+		df   float64
+		want float64
+	}{
+		{1, 12.706},
+		{2, 4.303},
+		{5, 2.571},
+		{10, 2.228},
+		{20, 2.086},
+		{21, 2.080},
+		{25, 2.060},
+		{29, 2.045},
+		{30, 2.042},
+		{60, 2.000},
+		{1000, 1.962},
+	}
+	for _, tt := range tests {
+		if got := Critical(0.95, tt.df); math.Abs(got-tt.want) > 5e-4 {
+			t.Errorf("Critical(0.95, %g) = %.4f, want %.3f", tt.df, got, tt.want)
+		}
+	}
+	if got := Critical(0.99, 10); math.Abs(got-3.169) > 5e-4 {
+		t.Errorf("Critical(0.99, 10) = %.4f, want 3.169", got)
+	}
+}
+
+func TestCriticalInvertsTwoSidedP(t *testing.T) {
+	for _, df := range []float64{1, 3, 7, 24, 150} {
+		if p := TwoSidedP(Critical(0.9, df), df); math.Abs(p-0.1) > 1e-9 {
+			t.Errorf("df %g: TwoSidedP(Critical(0.9)) = %.12f, want 0.1", df, p)
+		}
+	}
+}
+
+func TestPaired(t *testing.T) {
+	d := Paired([]float64{10, 20, 30, 40}, []float64{12, 21, 33, 41, 99})
+	if d.N != 4 {
+		t.Fatalf("N = %d, want 4", d.N)
+	}
+	if math.Abs(d.Mean-1.75) > 1e-12 {
+		t.Errorf("Mean = %g, want 1.75", d.Mean)
+	}
+	// diffs 2,1,3,1: sd = sqrt(2.75/3), se = sd/2
+	if want := math.Sqrt(2.75/3) / 2; math.Abs(d.Stderr-want) > 1e-12 {
+		t.Errorf("Stderr = %g, want %g", d.Stderr, want)
+	}
+	if d.P <= 0 || d.P >= 0.05 {
+		t.Errorf("P = %g, want a significant shift", d.P)
+	}
+
+	if same := Paired([]float64{1, 2}, []float64{1, 2}); same.P != 1 {
+		t.Errorf("identical samples: P = %g, want 1", same.P)
+	}
+}